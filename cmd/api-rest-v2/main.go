@@ -0,0 +1,18 @@
+// api-rest-v2 : connecteur REST déclaratif. Tout le comportement vient du bloc
+// `request` de chaque report du conf.yml (cf sdk/httpsource.Spec).
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/quantiio/quanti-sdk/sdk"
+	"github.com/quantiio/quanti-sdk/sdk/restrunner"
+)
+
+func main() {
+	if err := sdk.Process(restrunner.Process); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,518 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Coercer convertit les valeurs d'une ligne vers le type déclaré par le schéma
+// (DatabaseMetaData.Type, Format, FormatHint), champ par champ via FieldPath.
+//
+// Sans ça, un export CSV n'envoie que des chaînes et un nombre JSON arrive toujours
+// en float64 : c'est l'entrepôt qui tranche, et une valeur qu'il refuse disparaît
+// sans bruit. Ici une valeur inconvertible est LAISSÉE TELLE QUELLE et comptée par
+// champ : on ne perd rien, et le rapport dit quoi corriger.
+type Coercer struct {
+	fields []OrderedField
+
+	mu       sync.Mutex
+	failures map[string]*CoercionFailure
+}
+
+// CoercionFailure résume les valeurs inconvertibles d'un champ sur le run.
+type CoercionFailure struct {
+	RequestId    string        `json:"requestId"`
+	FieldPath    string        `json:"fieldPath"`
+	Type         string        `json:"type"`
+	Count        int           `json:"count"`
+	Error        string        `json:"error"`
+	SampleValues []interface{} `json:"sampleValues,omitempty"`
+}
+
+// #region NewCoercer
+func NewCoercer(schema Schema) *Coercer {
+	c := &Coercer{failures: map[string]*CoercionFailure{}}
+	for _, f := range schema.OrderedFields {
+		if f.FieldPath == "" || f.DatabaseMetaData.Managed || f.DatabaseMetaData.QuantiField {
+			continue
+		}
+		c.fields = append(c.fields, f)
+	}
+	return c
+}
+
+// #endregion
+
+// #region Apply
+// Apply convertit sur place les champs de data (enveloppe d'Upsert comprise, les
+// FieldPath commencent donc en général par "data."). Un champ absent ou nul est
+// ignoré. Renvoie le nombre de valeurs inconvertibles de la ligne.
+func (c *Coercer) Apply(data map[string]interface{}) int {
+	if c == nil {
+		return 0
+	}
+	requestID, _ := data["requestId"].(string)
+
+	failed := 0
+	for _, f := range c.fields {
+		value, ok := getPath(data, f.FieldPath)
+		if !ok || value == nil {
+			continue
+		}
+		meta := f.DatabaseMetaData
+		converted, err := CoerceValue(value, meta.Type, meta.Format, meta.FormatHint)
+		if err != nil {
+			failed++
+			c.recordFailure(requestID, f, value, err)
+			continue
+		}
+		setPath(data, f.FieldPath, converted)
+	}
+	return failed
+}
+
+// #endregion
+
+// #region recordFailure
+func (c *Coercer) recordFailure(requestID string, f OrderedField, value interface{}, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := requestID + "\x00" + f.FieldPath
+	failure := c.failures[key]
+	if failure == nil {
+		failure = &CoercionFailure{
+			RequestId: requestID,
+			FieldPath: f.FieldPath,
+			Type:      f.DatabaseMetaData.Type,
+			Error:     err.Error(),
+		}
+		c.failures[key] = failure
+	}
+	failure.Count++
+	// Pas d'échantillon pour une donnée personnelle : le rapport part dans les logs.
+	if len(failure.SampleValues) < 3 && !f.DatabaseMetaData.IsPII {
+		failure.SampleValues = append(failure.SampleValues, value)
+	}
+}
+
+// #endregion
+
+// #region Failures
+// Failures renvoie les échecs du run, triés par requête puis par champ.
+func (c *Coercer) Failures() []CoercionFailure {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make([]CoercionFailure, 0, len(c.failures))
+	for _, f := range c.failures {
+		out = append(out, *f)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].RequestId != out[j].RequestId {
+			return out[i].RequestId < out[j].RequestId
+		}
+		return out[i].FieldPath < out[j].FieldPath
+	})
+	return out
+}
+
+// #endregion
+
+// #region CoerceValue
+// CoerceValue convertit une valeur vers un type du schéma.
+//
+// La FormatHint s'applique AVANT le typage : un coût en micros (divide_1000000)
+// déclaré FLOAT devient 12.5 et non 12500000. divide_<n> ne change la valeur que
+// d'une colonne FLOAT/NUMERIC : sur une autre colonne, c'est une indication
+// d'affichage, et une colonne INTEGER garde ses micros entiers. `percentage`
+// accepte le suffixe "%" ("12.5%") et garde la valeur en points de pourcentage,
+// sans la rediviser.
+//
+// Format ne sert qu'aux dates : layout Go ("02/01/2006"), ou "epoch" / "epoch_ms"
+// pour un horodatage numérique. Sorties : DATE en "2006-01-02", TIMESTAMP en RFC 3339
+// UTC, INTEGER en int64, FLOAT en float64.
+func CoerceValue(value interface{}, typ, format, hint string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	typ = strings.ToUpper(strings.TrimSpace(typ))
+	value, err := applyFormatHint(value, hint, typ == "FLOAT" || typ == "FLOAT64" || typ == "NUMERIC")
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case "INTEGER", "INT64":
+		return toInteger(value)
+
+	case "FLOAT", "FLOAT64", "NUMERIC":
+		return toFloat(value)
+
+	case "BOOLEAN", "BOOL":
+		return toBoolean(value)
+
+	case "DATE":
+		t, err := toTime(value, format)
+		if err != nil {
+			return nil, err
+		}
+		return t.Format("2006-01-02"), nil
+
+	case "TIMESTAMP", "DATETIME":
+		t, err := toTime(value, format)
+		if err != nil {
+			return nil, err
+		}
+		return t.UTC().Format(time.RFC3339), nil
+
+	case "STRING":
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("a %T cannot be stored as STRING", value)
+		}
+		return stringifyValue(value), nil
+
+	default:
+		// Type inconnu ou vide : on ne sait pas juger, on ne touche à rien.
+		return value, nil
+	}
+}
+
+// #endregion
+
+// #region applyFormatHint
+// applyFormatHint : divide applique divide_<n>, réservé aux colonnes décimales.
+func applyFormatHint(value interface{}, hint string, divide bool) (interface{}, error) {
+	hint = strings.ToLower(strings.TrimSpace(hint))
+	switch {
+	case hint == "":
+		return value, nil
+
+	case hint == "percentage":
+		if s, ok := value.(string); ok {
+			return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%")), nil
+		}
+		return value, nil
+
+	case strings.HasPrefix(hint, "divide_"):
+		if !divide {
+			return value, nil
+		}
+		divisor, err := strconv.ParseFloat(strings.TrimPrefix(hint, "divide_"), 64)
+		if err != nil || divisor == 0 {
+			return nil, fmt.Errorf("format hint %q is not a valid divide_<n>", hint)
+		}
+		f, err := toFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		return f / divisor, nil
+
+	default:
+		// Une hint d'affichage inconnue n'empêche pas le typage.
+		return value, nil
+	}
+}
+
+// #endregion
+
+// #region toInteger
+// toInteger convertit sans jamais arrondir : une chaîne est lue en entier exact, un
+// flottant n'est accepté que dans ±2^53, au-delà il a déjà perdu des chiffres.
+func toInteger(value interface{}) (int64, error) {
+	switch t := value.(type) {
+	case int:
+		return int64(t), nil
+	case int64:
+		return t, nil
+	case int32:
+		return int64(t), nil
+	case string:
+		s := strings.TrimSpace(t)
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n, nil
+		} else if errors.Is(err, strconv.ErrRange) {
+			return 0, fmt.Errorf("%q is out of the INTEGER range", t)
+		}
+		// "12.0", "1e3" : acceptés s'ils sont entiers et exactement représentables.
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", t)
+		}
+		return floatToInteger(f)
+	}
+	f, err := toFloat(value)
+	if err != nil {
+		return 0, err
+	}
+	return floatToInteger(f)
+}
+
+// #endregion
+
+// #region floatToInteger
+// maxExactFloat : 2^53, le plus grand entier au-delà duquel un float64 ne les
+// représente plus tous.
+const maxExactFloat = 1 << 53
+
+func floatToInteger(f float64) (int64, error) {
+	if f != math.Trunc(f) || math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, fmt.Errorf("%v is not an integer", f)
+	}
+	if math.Abs(f) > maxExactFloat {
+		return 0, fmt.Errorf("%v is beyond ±2^53 and may have lost digits: send it as a string", f)
+	}
+	return int64(f), nil
+}
+
+// #endregion
+
+// #region toFloat
+func toFloat(value interface{}) (float64, error) {
+	switch t := value.(type) {
+	case float64:
+		return t, nil
+	case float32:
+		return float64(t), nil
+	case int:
+		return float64(t), nil
+	case int64:
+		return float64(t), nil
+	case int32:
+		return float64(t), nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", t)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("a %T is not a number", value)
+	}
+}
+
+// #endregion
+
+// #region toBoolean
+func toBoolean(value interface{}) (bool, error) {
+	switch t := value.(type) {
+	case bool:
+		return t, nil
+	case float64:
+		if t == 0 || t == 1 {
+			return t == 1, nil
+		}
+	case int:
+		if t == 0 || t == 1 {
+			return t == 1, nil
+		}
+	case int64:
+		if t == 0 || t == 1 {
+			return t == 1, nil
+		}
+	case string:
+		switch strings.ToLower(strings.TrimSpace(t)) {
+		case "true", "1", "yes", "y":
+			return true, nil
+		case "false", "0", "no", "n":
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("%v is not a boolean", value)
+}
+
+// #endregion
+
+// #region toTime
+// toTime lit une date ou un horodatage. Sans Format, on essaie les formes ISO
+// courantes : les API qui sortent de l'ISO déclarent leur layout dans le schéma.
+func toTime(value interface{}, format string) (time.Time, error) {
+	format = strings.TrimSpace(format)
+
+	if format == "epoch" || format == "epoch_ms" {
+		f, err := toFloat(value)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if format == "epoch_ms" {
+			return time.UnixMilli(int64(f)).UTC(), nil
+		}
+		return time.Unix(int64(f), 0).UTC(), nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("a %T is not a date (set format: epoch for numeric timestamps)", value)
+	}
+	s = strings.TrimSpace(s)
+
+	layouts := []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+	if format != "" {
+		layouts = []string{format}
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	if format != "" {
+		return time.Time{}, fmt.Errorf("%q does not match the format %q", s, format)
+	}
+	return time.Time{}, fmt.Errorf("%q is not an ISO date", s)
+}
+
+// #endregion
+
+// #region stringifyValue
+// stringifyValue : un entier JSON arrive en float64, le rendre via %v produirait
+// "1.234567e+06" pour un gros ID.
+func stringifyValue(value interface{}) string {
+	switch t := value.(type) {
+	case string:
+		return t
+	case float64:
+		if t == math.Trunc(t) && math.Abs(t) < 1e15 {
+			return strconv.FormatInt(int64(t), 10)
+		}
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// #endregion
+
+// #region getPath
+// getPath suit un FieldPath pointé, index de tableau compris (même forme que le
+// flatten de processor-v2).
+func getPath(data map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = data
+	for _, part := range strings.Split(path, ".") {
+		switch t := current.(type) {
+		case map[string]interface{}:
+			v, ok := t[part]
+			if !ok {
+				return nil, false
+			}
+			current = v
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, false
+			}
+			current = t[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// #endregion
+
+// #region setPath
+// setPath remplace une valeur existante ; n'est appelé qu'après un getPath réussi.
+func setPath(data map[string]interface{}, path string, value interface{}) {
+	parts := strings.Split(path, ".")
+	var current interface{} = data
+	for i, part := range parts {
+		last := i == len(parts)-1
+		switch t := current.(type) {
+		case map[string]interface{}:
+			if last {
+				t[part] = value
+				return
+			}
+			current = t[part]
+		case []interface{}:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(t) {
+				return
+			}
+			if last {
+				t[idx] = value
+				return
+			}
+			current = t[idx]
+		default:
+			return
+		}
+	}
+}
+
+// #endregion
+
+// ============================================================================
+// Coercition d'un run, une conversion par requête
+// ============================================================================
+
+// Coercers associe à chaque requête du run le Coercer de son schéma. La ligne est
+// aiguillée par data["requestId"] ; une requête inconnue n'est pas convertie.
+type Coercers map[string]*Coercer
+
+// #region NewCoercers
+// NewCoercers prépare la conversion des lignes des requêtes données. Optionnel : un
+// connecteur qui type déjà ses lignes n'en a pas besoin.
+func NewCoercers(requests []Request) Coercers {
+	cs := Coercers{}
+	for _, req := range requests {
+		id := req.ConnectorsAccountRequest.ID
+		if id == "" || cs[id] != nil {
+			continue
+		}
+		cs[id] = NewCoercer(req.ConnectorsAccountRequest.Schema)
+	}
+	return cs
+}
+
+// #endregion
+
+// #region Coercers.Apply
+// Apply convertit sur place la ligne avec le Coercer de sa requête. Renvoie le
+// nombre de valeurs inconvertibles.
+func (cs Coercers) Apply(data map[string]interface{}) int {
+	requestID, _ := data["requestId"].(string)
+	return cs[requestID].Apply(data)
+}
+
+// #endregion
+
+// #region ReportCoercion
+// ReportCoercion logue un warning par champ en échec et renvoie les échecs du run,
+// triés par requête puis par champ.
+func ReportCoercion(cs Coercers) []CoercionFailure {
+	ids := make([]string, 0, len(cs))
+	for id := range cs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var out []CoercionFailure
+	for _, id := range ids {
+		out = append(out, cs[id].Failures()...)
+	}
+	for _, f := range out {
+		Log("warn", fmt.Sprintf("Coercion: %d valeur(s) de %s non convertible(s) en %s (requête %s) : %s",
+			f.Count, f.FieldPath, f.Type, f.RequestId, f.Error), map[string]interface{}{
+			"requestId":    f.RequestId,
+			"fieldPath":    f.FieldPath,
+			"type":         f.Type,
+			"count":        f.Count,
+			"sampleValues": f.SampleValues,
+		})
+	}
+	return out
+}
+
+// #endregion
@@ -0,0 +1,117 @@
+package sdk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCoerceValue(t *testing.T) {
+	cases := []struct {
+		name              string
+		value             interface{}
+		typ, format, hint string
+		want              interface{}
+	}{
+		{"csv integer", "42", "INTEGER", "", "", int64(42)},
+		{"json integer", float64(42), "INTEGER", "", "", int64(42)},
+		{"large integer string kept exact", "12345678901234567", "INTEGER", "", "", int64(12345678901234567)},
+		{"integral float string", "12.0", "INTEGER", "", "", int64(12)},
+		{"csv float", " 12.5 ", "FLOAT", "", "", 12.5},
+		{"micros", float64(12500000), "FLOAT", "", "divide_1000000", 12.5},
+		{"micros as string", "2000000", "FLOAT", "", "divide_1000000", float64(2)},
+		// divide_ n'est qu'une indication d'affichage sur une colonne INTEGER.
+		{"micros kept on INTEGER", float64(12500000), "INTEGER", "", "divide_1000000", int64(12500000)},
+		{"percentage suffix", "12.5%", "FLOAT", "", "percentage", 12.5},
+		{"boolean", "yes", "BOOLEAN", "", "", true},
+		{"boolean number", float64(0), "BOOLEAN", "", "", false},
+		{"boolean int64", int64(1), "BOOLEAN", "", "", true},
+		{"iso date", "2026-08-12", "DATE", "", "", "2026-08-12"},
+		{"date with layout", "12/08/2026", "DATE", "02/01/2006", "", "2026-08-12"},
+		{"timestamp", "2026-08-12T10:00:00+02:00", "TIMESTAMP", "", "", "2026-08-12T08:00:00Z"},
+		{"epoch", float64(1786521600), "TIMESTAMP", "epoch", "", "2026-08-12T08:00:00Z"},
+		{"epoch ms", "1786521600000", "TIMESTAMP", "epoch_ms", "", "2026-08-12T08:00:00Z"},
+		{"string id", float64(1234567), "STRING", "", "", "1234567"},
+		{"unknown type", "x", "GEOGRAPHY", "", "", "x"},
+	}
+	for _, c := range cases {
+		got, err := CoerceValue(c.value, c.typ, c.format, c.hint)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %#v (%T), want %#v (%T)", c.name, got, got, c.want, c.want)
+		}
+	}
+}
+
+func TestCoerceValueErrors(t *testing.T) {
+	cases := []struct {
+		name              string
+		value             interface{}
+		typ, format, hint string
+	}{
+		{"not a number", "n/a", "INTEGER", "", ""},
+		{"fractional integer", 1.5, "INTEGER", "", ""},
+		{"float beyond 2^53", float64(1e17), "INTEGER", "", ""},
+		{"float beyond int64", float64(1e19), "INTEGER", "", ""},
+		{"string beyond int64", "99999999999999999999", "INTEGER", "", ""},
+		{"not a boolean", "maybe", "BOOLEAN", "", ""},
+		{"wrong layout", "2026-08-12", "DATE", "02/01/2006", ""},
+		{"numeric date without epoch", float64(1), "DATE", "", ""},
+		{"bad hint", "1", "FLOAT", "", "divide_zero"},
+		{"object as string", map[string]interface{}{}, "STRING", "", ""},
+	}
+	for _, c := range cases {
+		if _, err := CoerceValue(c.value, c.typ, c.format, c.hint); err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+}
+
+func TestCoercerApply(t *testing.T) {
+	schema := Schema{OrderedFields: []OrderedField{
+		{FieldPath: "data.cost", DatabaseMetaData: DatabaseMetaData{Type: "FLOAT", FormatHint: "divide_1000000"}},
+		{FieldPath: "data.clicks", DatabaseMetaData: DatabaseMetaData{Type: "INTEGER"}},
+		{FieldPath: "data.items.0.qty", DatabaseMetaData: DatabaseMetaData{Type: "INTEGER"}},
+		{FieldPath: "data.email", DatabaseMetaData: DatabaseMetaData{Type: "INTEGER", IsPII: true}},
+		{FieldPath: "data.absent", DatabaseMetaData: DatabaseMetaData{Type: "INTEGER"}},
+	}}
+	c := NewCoercer(schema)
+
+	row := map[string]interface{}{
+		"requestId": "ads",
+		"data": map[string]interface{}{
+			"cost":   "3500000",
+			"clicks": "n/a",
+			"items":  []interface{}{map[string]interface{}{"qty": "2"}},
+			"email":  "a@b.c",
+		},
+	}
+	if failed := c.Apply(row); failed != 2 {
+		t.Errorf("failed: got %d, want 2", failed)
+	}
+
+	data := row["data"].(map[string]interface{})
+	if data["cost"] != 3.5 {
+		t.Errorf("cost: got %#v", data["cost"])
+	}
+	// Une valeur inconvertible reste telle quelle : rien n'est perdu.
+	if data["clicks"] != "n/a" {
+		t.Errorf("clicks must be left untouched, got %#v", data["clicks"])
+	}
+	if qty := data["items"].([]interface{})[0].(map[string]interface{})["qty"]; qty != int64(2) {
+		t.Errorf("items.0.qty: got %#v", qty)
+	}
+
+	failures := c.Failures()
+	if len(failures) != 2 || failures[0].FieldPath != "data.clicks" || failures[1].FieldPath != "data.email" {
+		t.Fatalf("failures: %#v", failures)
+	}
+	if failures[0].Count != 1 || failures[0].RequestId != "ads" || !reflect.DeepEqual(failures[0].SampleValues, []interface{}{"n/a"}) {
+		t.Errorf("clicks failure: %#v", failures[0])
+	}
+	if len(failures[1].SampleValues) != 0 {
+		t.Errorf("PII values must not be sampled: %#v", failures[1].SampleValues)
+	}
+}
@@ -0,0 +1,335 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Dialectes SQL de GenerateDDL.
+const (
+	DialectPostgres  = "postgres"
+	DialectSnowflake = "snowflake"
+)
+
+// column est un champ du schéma résolu en colonne : nom final, type normalisé.
+type column struct {
+	Name        string
+	Type        string // STRING, INTEGER, FLOAT, NUMERIC, BOOLEAN, DATE, TIMESTAMP, DATETIME, JSON
+	Description string
+	PrimaryKey  bool
+	Partition   bool
+}
+
+// columnTypes : alias BigQuery acceptés dans DatabaseMetaData.Type → type normalisé.
+var columnTypes = map[string]string{
+	"STRING": "STRING", "INTEGER": "INTEGER", "INT64": "INTEGER",
+	"FLOAT": "FLOAT", "FLOAT64": "FLOAT", "NUMERIC": "NUMERIC",
+	"BOOLEAN": "BOOLEAN", "BOOL": "BOOLEAN",
+	"DATE": "DATE", "TIMESTAMP": "TIMESTAMP", "DATETIME": "DATETIME", "JSON": "JSON",
+}
+
+// #region schemaColumns
+// schemaColumns résout les colonnes d'un schéma : nom = DatabaseMetaData.Name, à
+// défaut FieldId. Un type inconnu ou un nom en double est une erreur : une table
+// générée à moitié fausse est pire que pas de table.
+func schemaColumns(schema Schema) ([]column, error) {
+	if strings.TrimSpace(schema.TableName) == "" {
+		return nil, fmt.Errorf("schema: tableName is required")
+	}
+	if len(schema.OrderedFields) == 0 {
+		return nil, fmt.Errorf("schema %s: no field", schema.TableName)
+	}
+
+	seen := map[string]bool{}
+	columns := make([]column, 0, len(schema.OrderedFields))
+	partitioned := false
+	for _, f := range schema.OrderedFields {
+		meta := f.DatabaseMetaData
+		name := meta.Name
+		if name == "" {
+			name = f.FieldId
+		}
+		if name == "" {
+			return nil, fmt.Errorf("schema %s: field %q has no name", schema.TableName, f.FieldPath)
+		}
+		if seen[strings.ToLower(name)] {
+			return nil, fmt.Errorf("schema %s: column %q is defined more than once", schema.TableName, name)
+		}
+		seen[strings.ToLower(name)] = true
+
+		typ, ok := columnTypes[strings.ToUpper(meta.Type)]
+		if !ok {
+			return nil, fmt.Errorf("schema %s: column %q has unsupported type %q", schema.TableName, name, meta.Type)
+		}
+
+		col := column{Name: name, Type: typ, Description: meta.Description, PrimaryKey: meta.QuantiId}
+		// Partition sur le premier IsQuantiDate de type date/heure seulement.
+		if meta.IsQuantiDate && !partitioned && (typ == "DATE" || typ == "TIMESTAMP" || typ == "DATETIME") {
+			col.Partition = true
+			partitioned = true
+		}
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
+
+// #endregion
+
+// ============================================================================
+// BigQuery
+// ============================================================================
+
+type bigQueryField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Mode        string `json:"mode"`
+	Description string `json:"description,omitempty"`
+}
+
+// #region GenerateBigQuerySchema
+// GenerateBigQuerySchema produit la ressource Table BigQuery (format de l'API et de
+// `bq mk --table`) : schema.fields, timePartitioning journalier sur la colonne
+// IsQuantiDate, clé primaire (non contrainte côté BigQuery) sur les QuantiId.
+func GenerateBigQuerySchema(schema Schema) ([]byte, error) {
+	columns, err := schemaColumns(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]bigQueryField, 0, len(columns))
+	var primaryKey []string
+	table := map[string]interface{}{
+		"tableReference": map[string]string{"tableId": schema.TableName},
+	}
+	for _, c := range columns {
+		mode := "NULLABLE"
+		if c.PrimaryKey || c.Partition {
+			mode = "REQUIRED"
+		}
+		fields = append(fields, bigQueryField{Name: c.Name, Type: c.Type, Mode: mode, Description: c.Description})
+		if c.PrimaryKey {
+			primaryKey = append(primaryKey, c.Name)
+		}
+		if c.Partition {
+			table["timePartitioning"] = map[string]string{"type": "DAY", "field": c.Name}
+		}
+	}
+	table["schema"] = map[string]interface{}{"fields": fields}
+	if len(primaryKey) > 0 {
+		table["tableConstraints"] = map[string]interface{}{
+			"primaryKey": map[string]interface{}{"columns": primaryKey},
+		}
+	}
+	return json.MarshalIndent(table, "", "  ")
+}
+
+// #endregion
+
+// ============================================================================
+// PostgreSQL / Snowflake
+// ============================================================================
+
+var postgresTypes = map[string]string{
+	"STRING": "TEXT", "INTEGER": "BIGINT", "FLOAT": "DOUBLE PRECISION", "NUMERIC": "NUMERIC",
+	"BOOLEAN": "BOOLEAN", "DATE": "DATE", "TIMESTAMP": "TIMESTAMPTZ", "DATETIME": "TIMESTAMP", "JSON": "JSONB",
+}
+
+var snowflakeTypes = map[string]string{
+	"STRING": "VARCHAR", "INTEGER": "NUMBER(38,0)", "FLOAT": "FLOAT", "NUMERIC": "NUMBER(38,9)",
+	"BOOLEAN": "BOOLEAN", "DATE": "DATE", "TIMESTAMP": "TIMESTAMP_TZ", "DATETIME": "TIMESTAMP_NTZ", "JSON": "VARIANT",
+}
+
+var plainIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// snowflakeReserved : mots réservés de Snowflake, interdits comme identifiant nu
+// (order, group, from, to, start…).
+var snowflakeReserved = map[string]bool{
+	"ACCOUNT": true, "ALL": true, "ALTER": true, "AND": true, "ANY": true, "AS": true,
+	"BETWEEN": true, "BY": true, "CASE": true, "CAST": true, "CHECK": true,
+	"COLUMN": true, "CONNECT": true, "CONNECTION": true, "CONSTRAINT": true,
+	"CREATE": true, "CROSS": true, "CURRENT": true, "CURRENT_DATE": true,
+	"CURRENT_TIME": true, "CURRENT_TIMESTAMP": true, "CURRENT_USER": true,
+	"DATABASE": true, "DELETE": true, "DISTINCT": true, "DROP": true, "ELSE": true,
+	"EXISTS": true, "FALSE": true, "FOLLOWING": true, "FOR": true, "FROM": true,
+	"FULL": true, "GRANT": true, "GROUP": true, "GSCLUSTER": true, "HAVING": true,
+	"ILIKE": true, "IN": true, "INCREMENT": true, "INNER": true, "INSERT": true,
+	"INTERSECT": true, "INTO": true, "IS": true, "ISSUE": true, "JOIN": true,
+	"LATERAL": true, "LEFT": true, "LIKE": true, "LOCALTIME": true,
+	"LOCALTIMESTAMP": true, "MINUS": true, "NATURAL": true, "NOT": true, "NULL": true,
+	"OF": true, "ON": true, "OR": true, "ORDER": true, "ORGANIZATION": true,
+	"QUALIFY": true, "REGEXP": true, "REVOKE": true, "RIGHT": true, "RLIKE": true,
+	"ROW": true, "ROWS": true, "SAMPLE": true, "SCHEMA": true, "SELECT": true,
+	"SET": true, "SOME": true, "START": true, "TABLE": true, "TABLESAMPLE": true,
+	"THEN": true, "TO": true, "TRIGGER": true, "TRUE": true, "TRY_CAST": true,
+	"UNION": true, "UNIQUE": true, "UPDATE": true, "USING": true, "VALUES": true,
+	"VIEW": true, "WHEN": true, "WHENEVER": true, "WHERE": true, "WINDOW": true,
+	"WITH": true,
+}
+
+// #region GenerateDDL
+// GenerateDDL produit le CREATE TABLE du schéma pour PostgreSQL ou Snowflake,
+// descriptions en commentaires de colonnes, QuantiId en PRIMARY KEY.
+//
+// Partitionnement sur la colonne IsQuantiDate :
+//   - PostgreSQL : PARTITION BY RANGE, avec une partition DEFAULT pour que la table
+//     accepte des lignes telle quelle (base de test locale). PostgreSQL exige que la
+//     clé primaire contienne la colonne de partition : elle y est ajoutée.
+//   - Snowflake : pas de partition déclarative, CLUSTER BY sur la colonne.
+func GenerateDDL(schema Schema, dialect string) (string, error) {
+	columns, err := schemaColumns(schema)
+	if err != nil {
+		return "", err
+	}
+
+	var types map[string]string
+	var quote func(string) string
+	switch strings.ToLower(dialect) {
+	case DialectPostgres:
+		types, quote = postgresTypes, quotePostgres
+	case DialectSnowflake:
+		types, quote = snowflakeTypes, quoteSnowflake
+	default:
+		return "", fmt.Errorf("ddl: dialect %q is not supported (postgres, snowflake)", dialect)
+	}
+	postgres := strings.ToLower(dialect) == DialectPostgres
+
+	var lines, primaryKey []string
+	var partition string
+	for _, c := range columns {
+		line := "  " + quote(c.Name) + " " + types[c.Type]
+		if c.PrimaryKey || c.Partition {
+			line += " NOT NULL"
+		}
+		if !postgres && c.Description != "" {
+			line += " COMMENT " + quoteLiteral(c.Description)
+		}
+		lines = append(lines, line)
+		if c.PrimaryKey {
+			primaryKey = append(primaryKey, quote(c.Name))
+		}
+		if c.Partition {
+			partition = quote(c.Name)
+		}
+	}
+	if postgres && partition != "" && len(primaryKey) > 0 && !contains(primaryKey, partition) {
+		primaryKey = append(primaryKey, partition)
+	}
+	if len(primaryKey) > 0 {
+		lines = append(lines, "  PRIMARY KEY ("+strings.Join(primaryKey, ", ")+")")
+	}
+
+	table := quote(schema.TableName)
+	var sb strings.Builder
+	sb.WriteString("CREATE TABLE " + table + " (\n")
+	sb.WriteString(strings.Join(lines, ",\n"))
+	sb.WriteString("\n)")
+	switch {
+	case partition != "" && postgres:
+		sb.WriteString(" PARTITION BY RANGE (" + partition + ")")
+	case partition != "":
+		sb.WriteString("\nCLUSTER BY (" + partition + ")")
+	}
+	sb.WriteString(";\n")
+
+	if postgres {
+		if partition != "" {
+			sb.WriteString("CREATE TABLE " + quote(schema.TableName+"_default") + " PARTITION OF " + table + " DEFAULT;\n")
+		}
+		for _, c := range columns {
+			if c.Description != "" {
+				sb.WriteString("COMMENT ON COLUMN " + table + "." + quote(c.Name) + " IS " + quoteLiteral(c.Description) + ";\n")
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+// #endregion
+
+// #region quotePostgres
+// quotePostgres met toujours l'identifiant entre guillemets : la casse du schéma est
+// conservée, et un nom réservé ("date", "user") reste utilisable.
+func quotePostgres(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// #endregion
+
+// #region quoteSnowflake
+// quoteSnowflake laisse nus les identifiants simples : entre guillemets, Snowflake les
+// rendrait sensibles à la casse et il faudrait les citer dans chaque requête. Un mot
+// réservé, lui, n'a pas le choix.
+func quoteSnowflake(name string) string {
+	if plainIdentifier.MatchString(name) && !snowflakeReserved[strings.ToUpper(name)] {
+		return name
+	}
+	return quotePostgres(name)
+}
+
+// #endregion
+
+// #region quoteLiteral
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// #endregion
+
+// ============================================================================
+// JSON Schema
+// ============================================================================
+
+// #region GenerateJSONSchema
+// GenerateJSONSchema décrit une ligne de la table (colonnes à plat, noms finaux) en
+// JSON Schema 2020-12. Toute colonne peut être nulle, sauf les QuantiId et la date
+// de partition, qui sont requises.
+func GenerateJSONSchema(schema Schema) ([]byte, error) {
+	columns, err := schemaColumns(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := map[string]interface{}{}
+	required := []string{}
+	for _, c := range columns {
+		prop := map[string]interface{}{}
+		switch c.Type {
+		case "STRING":
+			prop["type"] = "string"
+		case "INTEGER":
+			prop["type"] = "integer"
+		case "FLOAT", "NUMERIC":
+			prop["type"] = "number"
+		case "BOOLEAN":
+			prop["type"] = "boolean"
+		case "DATE":
+			prop["type"], prop["format"] = "string", "date"
+		case "TIMESTAMP", "DATETIME":
+			prop["type"], prop["format"] = "string", "date-time"
+		}
+		if c.PrimaryKey || c.Partition {
+			required = append(required, c.Name)
+		} else if t, ok := prop["type"].(string); ok {
+			prop["type"] = []string{t, "null"}
+		}
+		// JSON : n'importe quelle valeur, pas de "type".
+		if c.Description != "" {
+			prop["description"] = c.Description
+		}
+		properties[c.Name] = prop
+	}
+
+	doc := map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"title":                schema.TableName,
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// #endregion
@@ -0,0 +1,151 @@
+package sdk
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func ddlSchema() Schema {
+	return Schema{TableName: "ads_daily", OrderedFields: []OrderedField{
+		{FieldId: "date", DatabaseMetaData: DatabaseMetaData{Name: "date", Type: "DATE", IsQuantiDate: true, Description: "Jour"}},
+		{FieldId: "campaign_id", DatabaseMetaData: DatabaseMetaData{Type: "STRING", QuantiId: true, Description: "ID de l'annonce"}},
+		{FieldId: "clicks", DatabaseMetaData: DatabaseMetaData{Type: "INT64", IsMetric: true}},
+		{FieldId: "cost", DatabaseMetaData: DatabaseMetaData{Name: "Cost (EUR)", Type: "FLOAT", IsMetric: true}},
+	}}
+}
+
+func TestGenerateDDLPostgres(t *testing.T) {
+	ddl, err := GenerateDDL(ddlSchema(), DialectPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `CREATE TABLE "ads_daily" (
+  "date" DATE NOT NULL,
+  "campaign_id" TEXT NOT NULL,
+  "clicks" BIGINT,
+  "Cost (EUR)" DOUBLE PRECISION,
+  PRIMARY KEY ("campaign_id", "date")
+) PARTITION BY RANGE ("date");
+CREATE TABLE "ads_daily_default" PARTITION OF "ads_daily" DEFAULT;
+COMMENT ON COLUMN "ads_daily"."date" IS 'Jour';
+COMMENT ON COLUMN "ads_daily"."campaign_id" IS 'ID de l''annonce';
+`
+	if ddl != want {
+		t.Errorf("got:\n%s\nwant:\n%s", ddl, want)
+	}
+}
+
+func TestGenerateDDLSnowflake(t *testing.T) {
+	ddl, err := GenerateDDL(ddlSchema(), "Snowflake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `CREATE TABLE ads_daily (
+  date DATE NOT NULL COMMENT 'Jour',
+  campaign_id VARCHAR NOT NULL COMMENT 'ID de l''annonce',
+  clicks NUMBER(38,0),
+  "Cost (EUR)" FLOAT,
+  PRIMARY KEY (campaign_id)
+)
+CLUSTER BY (date);
+`
+	if ddl != want {
+		t.Errorf("got:\n%s\nwant:\n%s", ddl, want)
+	}
+}
+
+// Un nom de colonne réservé (order, group, from…) est cité, sinon la DDL est refusée.
+func TestGenerateDDLSnowflakeReservedColumn(t *testing.T) {
+	schema := Schema{TableName: "orders", OrderedFields: []OrderedField{
+		{FieldId: "order", DatabaseMetaData: DatabaseMetaData{Type: "STRING"}},
+		{FieldId: "Group", DatabaseMetaData: DatabaseMetaData{Type: "STRING"}},
+		{FieldId: "order_id", DatabaseMetaData: DatabaseMetaData{Type: "STRING"}},
+	}}
+	ddl, err := GenerateDDL(schema, "Snowflake")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `CREATE TABLE orders (
+  "order" VARCHAR,
+  "Group" VARCHAR,
+  order_id VARCHAR
+);
+`
+	if ddl != want {
+		t.Errorf("got:\n%s\nwant:\n%s", ddl, want)
+	}
+}
+
+func TestGenerateBigQuerySchema(t *testing.T) {
+	out, err := GenerateBigQuerySchema(ddlSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var table struct {
+		Schema struct {
+			Fields []bigQueryField `json:"fields"`
+		} `json:"schema"`
+		TimePartitioning map[string]string `json:"timePartitioning"`
+		TableConstraints struct {
+			PrimaryKey struct {
+				Columns []string `json:"columns"`
+			} `json:"primaryKey"`
+		} `json:"tableConstraints"`
+	}
+	if err := json.Unmarshal(out, &table); err != nil {
+		t.Fatal(err)
+	}
+	wantFields := []bigQueryField{
+		{Name: "date", Type: "DATE", Mode: "REQUIRED", Description: "Jour"},
+		{Name: "campaign_id", Type: "STRING", Mode: "REQUIRED", Description: "ID de l'annonce"},
+		{Name: "clicks", Type: "INTEGER", Mode: "NULLABLE"},
+		{Name: "Cost (EUR)", Type: "FLOAT", Mode: "NULLABLE"},
+	}
+	if !reflect.DeepEqual(table.Schema.Fields, wantFields) {
+		t.Errorf("fields: %#v", table.Schema.Fields)
+	}
+	if !reflect.DeepEqual(table.TimePartitioning, map[string]string{"type": "DAY", "field": "date"}) {
+		t.Errorf("timePartitioning: %#v", table.TimePartitioning)
+	}
+	if !reflect.DeepEqual(table.TableConstraints.PrimaryKey.Columns, []string{"campaign_id"}) {
+		t.Errorf("primary key: %#v", table.TableConstraints.PrimaryKey.Columns)
+	}
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	out, err := GenerateJSONSchema(ddlSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	props := doc["properties"].(map[string]interface{})
+	if date := props["date"].(map[string]interface{}); date["type"] != "string" || date["format"] != "date" {
+		t.Errorf("date: %#v", date)
+	}
+	if clicks := props["clicks"].(map[string]interface{}); !reflect.DeepEqual(clicks["type"], []interface{}{"integer", "null"}) {
+		t.Errorf("clicks: %#v", clicks)
+	}
+	if !reflect.DeepEqual(doc["required"], []interface{}{"date", "campaign_id"}) {
+		t.Errorf("required: %#v", doc["required"])
+	}
+}
+
+func TestSchemaGeneratorsErrors(t *testing.T) {
+	dup := ddlSchema()
+	dup.OrderedFields = append(dup.OrderedFields, OrderedField{FieldId: "Date", DatabaseMetaData: DatabaseMetaData{Type: "STRING"}})
+	unknown := ddlSchema()
+	unknown.OrderedFields[2].DatabaseMetaData.Type = "GEOGRAPHY"
+
+	for name, schema := range map[string]Schema{"duplicate": dup, "unknown type": unknown, "no table": {OrderedFields: dup.OrderedFields}} {
+		if _, err := GenerateDDL(schema, DialectPostgres); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := GenerateDDL(ddlSchema(), "mysql"); err == nil {
+		t.Error("unknown dialect must be refused")
+	}
+}
@@ -0,0 +1,428 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const MsgTypeSchemaDrift = "schema_drift"
+
+// Bornes mémoire du suivi : une API qui renvoie des clés dynamiques (un id par clé)
+// ne doit pas faire grossir le tracker à l'infini pendant un run de 365 jours.
+const (
+	driftMaxNewFields = 100
+	driftMaxSamples   = 3
+	driftSampleLength = 100
+)
+
+// envelopeKeys : clés posées par le connecteur autour de la donnée (cf Upsert), pas
+// des champs de l'API. Elles ne figurent pas dans le schéma et ne sont pas une dérive.
+var envelopeKeys = map[string]bool{
+	"requestId": true,
+	"adAccount": true,
+	"accountId": true,
+	"date":      true,
+}
+
+// SchemaDriftMsg est le message de fin de run listant les écarts entre les lignes
+// émises et Schema.OrderedFields, requête par requête. L'admin s'en sert pour
+// proposer une mise à jour du schéma.
+type SchemaDriftMsg struct {
+	Type      MsgType       `json:"type"`
+	Drifts    []SchemaDrift `json:"drifts"`
+	Timestamp string        `json:"timestamp"`
+}
+
+// SchemaDrift : écarts observés pour une requête.
+type SchemaDrift struct {
+	RequestId     string       `json:"requestId"`
+	TableName     string       `json:"tableName,omitempty"`
+	Rows          int          `json:"rows"`
+	NewFields     []DriftField `json:"newFields,omitempty"`     // Présents dans les lignes, absents du schéma
+	MissingFields []DriftField `json:"missingFields,omitempty"` // Dans le schéma, jamais vus dans une ligne
+	TypeChanges   []DriftField `json:"typeChanges,omitempty"`   // Valeurs incompatibles avec le type déclaré
+}
+
+// DriftField décrit un champ en écart, avec quelques valeurs d'exemple.
+type DriftField struct {
+	FieldPath     string        `json:"fieldPath"`
+	FieldId       string        `json:"fieldId,omitempty"`
+	ExpectedType  string        `json:"expectedType,omitempty"`
+	ObservedTypes []string      `json:"observedTypes,omitempty"`
+	SampleValues  []interface{} `json:"sampleValues,omitempty"`
+}
+
+// DriftTracker compare la forme aplatie des lignes émises au schéma de chaque
+// requête. Sûr en concurrence : un connecteur peut upserter depuis plusieurs
+// goroutines.
+type DriftTracker struct {
+	mu       sync.Mutex
+	requests map[string]*requestDrift
+	order    []string
+}
+
+type requestDrift struct {
+	tableName string
+	fields    map[string]OrderedField
+	rows      int
+	seen      map[string]bool
+	newFields map[string]*observedField
+	changes   map[string]*observedField
+}
+
+type observedField struct {
+	types   map[string]bool
+	samples []interface{}
+}
+
+// #region NewDriftTracker
+// NewDriftTracker prépare le suivi des requêtes données. Une requête sans champ au
+// schéma n'est pas suivie : tout y serait "nouveau", ce qui n'apprend rien.
+func NewDriftTracker(requests []Request) *DriftTracker {
+	t := &DriftTracker{requests: map[string]*requestDrift{}}
+	for _, req := range requests {
+		car := req.ConnectorsAccountRequest
+		if car.ID == "" || len(car.Schema.OrderedFields) == 0 {
+			continue
+		}
+		if _, exists := t.requests[car.ID]; exists {
+			continue
+		}
+		rd := &requestDrift{
+			tableName: car.Schema.TableName,
+			fields:    map[string]OrderedField{},
+			seen:      map[string]bool{},
+			newFields: map[string]*observedField{},
+			changes:   map[string]*observedField{},
+		}
+		for _, f := range car.Schema.OrderedFields {
+			if f.FieldPath != "" {
+				rd.fields[f.FieldPath] = f
+			}
+		}
+		t.requests[car.ID] = rd
+		t.order = append(t.order, car.ID)
+	}
+	return t
+}
+
+// #endregion
+
+// #region Observe
+// Observe enregistre une ligne telle que passée à Upsert (enveloppe comprise). Les
+// chemins sont ceux du flatten de processor-v2 : clés jointes par des points,
+// index de tableau compris.
+func (t *DriftTracker) Observe(data map[string]interface{}) {
+	if t == nil {
+		return
+	}
+	requestID, _ := data["requestId"].(string)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rd, ok := t.requests[requestID]
+	if !ok {
+		return
+	}
+	rd.rows++
+
+	flat := map[string]interface{}{}
+	for k, v := range data {
+		if envelopeKeys[k] {
+			if _, declared := rd.fields[k]; !declared {
+				continue
+			}
+		}
+		flattenInto(flat, k, v)
+	}
+
+	for path, value := range flat {
+		field, declared := rd.fields[path]
+		if !declared {
+			rd.observeNew(path, value)
+			continue
+		}
+		rd.seen[path] = true
+		if value == nil {
+			continue
+		}
+		if observed := observedType(value); !typeCompatible(field.DatabaseMetaData.Type, value) {
+			obs := rd.changes[path]
+			if obs == nil {
+				obs = &observedField{types: map[string]bool{}}
+				rd.changes[path] = obs
+			}
+			obs.types[observed] = true
+			if !field.DatabaseMetaData.IsPII {
+				obs.addSample(value)
+			}
+		}
+	}
+}
+
+// #endregion
+
+// #region observeNew
+func (rd *requestDrift) observeNew(path string, value interface{}) {
+	obs := rd.newFields[path]
+	if obs == nil {
+		if len(rd.newFields) >= driftMaxNewFields {
+			return
+		}
+		obs = &observedField{types: map[string]bool{}}
+		rd.newFields[path] = obs
+	}
+	if value != nil {
+		obs.types[observedType(value)] = true
+		obs.addSample(value)
+	}
+}
+
+// #endregion
+
+// #region addSample
+// addSample garde quelques valeurs distinctes, tronquées : de quoi reconnaître le
+// champ dans l'admin, pas une copie des données.
+func (o *observedField) addSample(value interface{}) {
+	if len(o.samples) >= driftMaxSamples {
+		return
+	}
+	if s, ok := value.(string); ok && len(s) > driftSampleLength {
+		value = s[:driftSampleLength] + "…"
+	}
+	for _, existing := range o.samples {
+		if fmt.Sprint(existing) == fmt.Sprint(value) {
+			return
+		}
+	}
+	o.samples = append(o.samples, value)
+}
+
+// #endregion
+
+// #region Report
+// Report renvoie les écarts des requêtes qui en ont, dans l'ordre des requêtes.
+// Une requête qui n'a émis aucune ligne n'a rien à dire : ses champs ne sont pas
+// "jamais vus", ils n'ont simplement pas eu l'occasion de l'être.
+func (t *DriftTracker) Report() []SchemaDrift {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var out []SchemaDrift
+	for _, id := range t.order {
+		rd := t.requests[id]
+		if rd.rows == 0 {
+			continue
+		}
+		drift := SchemaDrift{RequestId: id, TableName: rd.tableName, Rows: rd.rows}
+
+		newPaths := make([]string, 0, len(rd.newFields))
+		for path := range rd.newFields {
+			newPaths = append(newPaths, path)
+		}
+		sort.Strings(newPaths)
+		for _, path := range newPaths {
+			obs := rd.newFields[path]
+			drift.NewFields = append(drift.NewFields, DriftField{
+				FieldPath:     path,
+				ObservedTypes: sortedKeys(obs.types),
+				SampleValues:  obs.samples,
+			})
+		}
+
+		paths := make([]string, 0, len(rd.fields))
+		for path := range rd.fields {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			field := rd.fields[path]
+			meta := field.DatabaseMetaData
+			if !rd.seen[path] {
+				// Managed / QuantiField : colonnes posées par la plateforme, jamais
+				// présentes dans la ligne émise.
+				if meta.Managed || meta.QuantiField {
+					continue
+				}
+				drift.MissingFields = append(drift.MissingFields, DriftField{
+					FieldPath: path, FieldId: field.FieldId, ExpectedType: meta.Type,
+				})
+			}
+			if obs, ok := rd.changes[path]; ok {
+				drift.TypeChanges = append(drift.TypeChanges, DriftField{
+					FieldPath:     path,
+					FieldId:       field.FieldId,
+					ExpectedType:  meta.Type,
+					ObservedTypes: sortedKeys(obs.types),
+					SampleValues:  obs.samples,
+				})
+			}
+		}
+
+		if len(drift.NewFields)+len(drift.MissingFields)+len(drift.TypeChanges) > 0 {
+			out = append(out, drift)
+		}
+	}
+	return out
+}
+
+// #endregion
+
+// #region flattenInto
+// flattenInto reproduit le flatten de processor-v2. Un objet ou un tableau vide ne
+// produit aucune colonne.
+func flattenInto(out map[string]interface{}, prefix string, value interface{}) {
+	switch t := value.(type) {
+	case map[string]interface{}:
+		for k, v := range t {
+			flattenInto(out, prefix+"."+k, v)
+		}
+	case []interface{}:
+		for i, v := range t {
+			flattenInto(out, prefix+"."+strconv.Itoa(i), v)
+		}
+	default:
+		out[prefix] = value
+	}
+}
+
+// #endregion
+
+// #region observedType
+// observedType donne le type "warehouse" d'une valeur JSON, dans le vocabulaire de
+// DatabaseMetaData.Type.
+func observedType(value interface{}) string {
+	switch t := value.(type) {
+	case bool:
+		return "BOOLEAN"
+	case float64:
+		if t == float64(int64(t)) {
+			return "INTEGER"
+		}
+		return "FLOAT"
+	case float32:
+		return "FLOAT"
+	case int, int64, int32:
+		return "INTEGER"
+	case string:
+		return "STRING"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// #endregion
+
+// #region typeCompatible
+// typeCompatible décide si une valeur peut aller dans une colonne du type déclaré.
+// Une chaîne parsable dans le type est acceptée : un export CSV n'a que des chaînes,
+// ce n'est pas une dérive. STRING accepte tout scalaire, et un type déclaré inconnu
+// (ou vide) accepte tout : on ne signale que ce qu'on sait juger.
+func typeCompatible(declared string, value interface{}) bool {
+	s, isString := value.(string)
+	switch strings.ToUpper(declared) {
+	case "STRING":
+		return true
+	case "INTEGER", "INT64":
+		if isString {
+			_, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			return err == nil
+		}
+		return observedType(value) == "INTEGER"
+	case "FLOAT", "FLOAT64", "NUMERIC":
+		if isString {
+			_, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			return err == nil
+		}
+		t := observedType(value)
+		return t == "INTEGER" || t == "FLOAT"
+	case "BOOLEAN", "BOOL":
+		if isString {
+			_, err := strconv.ParseBool(strings.TrimSpace(s))
+			return err == nil
+		}
+		return observedType(value) == "BOOLEAN"
+	case "DATE":
+		if !isString {
+			return false
+		}
+		_, err := time.Parse("2006-01-02", strings.TrimSpace(s))
+		return err == nil
+	case "TIMESTAMP", "DATETIME":
+		if !isString {
+			t := observedType(value)
+			return t == "INTEGER" || t == "FLOAT"
+		}
+		s = strings.TrimSpace(s)
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+			if _, err := time.Parse(layout, s); err == nil {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// #endregion
+
+// #region sortedKeys
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// #endregion
+
+// ============================================================================
+// Message de fin de run
+// ============================================================================
+
+// #region ReportSchemaDrift
+// ReportSchemaDrift émet UN message schema_drift listant les écarts de toutes les
+// requêtes suivies par tracker. Rien n'est émis sans écart. Renvoie les écarts pour
+// que le connecteur puisse les logger à sa façon.
+func ReportSchemaDrift(tracker *DriftTracker) []SchemaDrift {
+	drifts := tracker.Report()
+	if len(drifts) == 0 {
+		return nil
+	}
+
+	if DebugMode {
+		for _, d := range drifts {
+			logger.Warnf("Schema drift sur %s : %d nouveau(x) champ(s), %d champ(s) jamais vu(s), %d changement(s) de type",
+				d.RequestId, len(d.NewFields), len(d.MissingFields), len(d.TypeChanges))
+		}
+		return drifts
+	}
+
+	entry := SchemaDriftMsg{
+		Type:      MsgTypeSchemaDrift,
+		Drifts:    drifts,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+	out, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur serialization schema drift: %v\n", err)
+		return drifts
+	}
+	fmt.Println(string(out))
+	return drifts
+}
+
+// #endregion
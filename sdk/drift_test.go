@@ -0,0 +1,118 @@
+package sdk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func driftRequest() Request {
+	field := func(path, typ string, meta DatabaseMetaData) OrderedField {
+		meta.Type = typ
+		return OrderedField{FieldId: path, FieldPath: path, DatabaseMetaData: meta}
+	}
+	return Request{ConnectorsAccountRequest: ConnectorsAccountRequest{
+		ID: "sales",
+		Schema: Schema{
+			TableName: "sales",
+			OrderedFields: []OrderedField{
+				field("data.id", "STRING", DatabaseMetaData{}),
+				field("data.amount", "FLOAT", DatabaseMetaData{IsMetric: true}),
+				field("data.quantity", "INTEGER", DatabaseMetaData{}),
+				field("data.email", "STRING", DatabaseMetaData{IsPII: true}),
+				field("data.coupon", "STRING", DatabaseMetaData{}),
+				field("data.phone", "INTEGER", DatabaseMetaData{IsPII: true}),
+				field("_quanti_date", "DATE", DatabaseMetaData{QuantiField: true}),
+			},
+		},
+	}}
+}
+
+func TestDriftTracker(t *testing.T) {
+	tracker := NewDriftTracker([]Request{driftRequest()})
+
+	tracker.Observe(map[string]interface{}{
+		"requestId": "sales", "adAccount": "A1", "date": "2026-08-12",
+		"data": map[string]interface{}{
+			"id": "1", "amount": 12.5, "quantity": "3", "email": "a@b.c", "phone": "+33 6",
+			"channel": "web",
+		},
+	})
+	tracker.Observe(map[string]interface{}{
+		"requestId": "sales",
+		"data": map[string]interface{}{
+			"id": "2", "amount": "n/a", "quantity": 1.5, "email": nil, "phone": nil,
+			"channel": "store", "tags": []interface{}{"x"},
+		},
+	})
+	// Requête inconnue : ignorée.
+	tracker.Observe(map[string]interface{}{"requestId": "other", "data": map[string]interface{}{"z": 1}})
+
+	drifts := tracker.Report()
+	if len(drifts) != 1 {
+		t.Fatalf("got %d drifts, want 1: %#v", len(drifts), drifts)
+	}
+	d := drifts[0]
+	if d.RequestId != "sales" || d.Rows != 2 {
+		t.Errorf("unexpected drift header: %#v", d)
+	}
+
+	if len(d.NewFields) != 2 || d.NewFields[0].FieldPath != "data.channel" || d.NewFields[1].FieldPath != "data.tags.0" {
+		t.Fatalf("new fields: %#v", d.NewFields)
+	}
+	if !reflect.DeepEqual(d.NewFields[0].SampleValues, []interface{}{"web", "store"}) {
+		t.Errorf("samples: %#v", d.NewFields[0].SampleValues)
+	}
+
+	// coupon jamais vu ; _quanti_date est posé par la plateforme, pas une dérive.
+	if len(d.MissingFields) != 1 || d.MissingFields[0].FieldPath != "data.coupon" {
+		t.Errorf("missing fields: %#v", d.MissingFields)
+	}
+
+	// amount "n/a" (FLOAT), quantity 1.5 (INTEGER), phone "+33 6" (INTEGER, PII).
+	// quantity "3" est une chaîne parsable : pas une dérive.
+	changes := map[string]DriftField{}
+	for _, c := range d.TypeChanges {
+		changes[c.FieldPath] = c
+	}
+	if len(changes) != 3 {
+		t.Fatalf("type changes: %#v", d.TypeChanges)
+	}
+	if c := changes["data.quantity"]; !reflect.DeepEqual(c.ObservedTypes, []string{"FLOAT"}) || c.ExpectedType != "INTEGER" {
+		t.Errorf("quantity: %#v", c)
+	}
+	if c := changes["data.phone"]; len(c.SampleValues) != 0 {
+		t.Errorf("PII samples must not be reported: %#v", c.SampleValues)
+	}
+}
+
+func TestDriftTrackerNoDrift(t *testing.T) {
+	tracker := NewDriftTracker([]Request{driftRequest()})
+	if drifts := tracker.Report(); drifts != nil {
+		t.Errorf("a request without rows has nothing to report, got %#v", drifts)
+	}
+
+	tracker.Observe(map[string]interface{}{
+		"requestId": "sales",
+		"data": map[string]interface{}{
+			"id": "1", "amount": 3, "quantity": 2, "email": "a@b.c", "coupon": nil, "phone": "0601",
+		},
+	})
+	if drifts := tracker.Report(); drifts != nil {
+		t.Errorf("expected no drift, got %#v", drifts)
+	}
+}
+
+func TestReportSchemaDrift(t *testing.T) {
+	tracker := NewDriftTracker([]Request{driftRequest()})
+	tracker.Observe(map[string]interface{}{"requestId": "sales", "data": map[string]interface{}{"new": 1}})
+
+	DebugMode = true
+	defer func() { DebugMode = false }()
+
+	if drifts := ReportSchemaDrift(tracker); len(drifts) != 1 {
+		t.Fatalf("got %#v", drifts)
+	}
+	if drifts := ReportSchemaDrift(nil); drifts != nil {
+		t.Errorf("no tracker, nothing to report, got %#v", drifts)
+	}
+}
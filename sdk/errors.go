@@ -2,6 +2,7 @@ package sdk
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 )
@@ -13,8 +14,24 @@ type QError struct {
 	Message string     `json:"message,omitempty"`
 	Details string     `json:"details,omitempty"`
 	Err     string     `json:"error"`
+
+	// Cause conserve l'erreur d'origine (url.Error, context.Canceled,
+	// *httpsource.Error…) pour errors.Is / errors.As. Jamais sérialisée : le format
+	// JSON reste porté par Err, seul champ que le parent relit.
+	Cause error `json:"-"`
+
+	// Params renseigne les paramètres du message client (cf LocalizedMessage). Non
+	// sérialisé : il n'apparaît qu'au travers du message rendu.
+	Params map[string]string `json:"-"`
 }
 
+// Familles de codes, telles que renvoyées par GetErrorCodeType.
+const (
+	FamilyDef  = "DEF"
+	FamilyTmp  = "TMP"
+	FamilyWarn = "WARN"
+)
+
 const (
 	ERR_DEF_AUTH_NOT_VALID               QErrorCode = 1000
 	ERR_DEF_INVALID_REQUEST              QErrorCode = 1010
@@ -42,23 +59,23 @@ const (
 )
 
 var errorCodeLabels = map[QErrorCode]string{
-	ERR_DEF_AUTH_NOT_VALID:               "DEF",
-	ERR_DEF_INVALID_REQUEST:              "DEF",
-	ERR_DEF_INVALID_DATA:                 "DEF",
-	ERR_DEF_NOT_FOUND:                    "DEF",
-	ERR_DEF_PERMISSION_DENIED:            "DEF",
-	ERR_DEF_INVALID_UPSERT:               "DEF",
-	ERR_DEF_INVALID_DATE:                 "DEF",
-	ERR_DEF_INVALID_REQUESTS:             "DEF",
-	ERR_TMP_RATE_LIMIT_EXCEEDED:          "TMP",
-	ERR_TMP_TIMEOUT:                      "TMP",
-	ERR_TMP_SERVICE_UNAVAILABLE:          "TMP",
-	ERR_DEF_API_UNAVAILABLE:              "DEF",
-	ERR_DEF_UNABLED_START_PROCESS:        "DEF",
-	ERR_DEF_CANT_INSERT_IN_DATAWAREHOUSE: "DEF",
-	ERR_DEF_PROCESSED_WITH_ERROR:         "DEF",
-	ERR_DEF_COST_LIMIT_EXCEEDED:          "DEF",
-	ERR_WARN_ACCOUNT_LIMITATION:          "WARN",
+	ERR_DEF_AUTH_NOT_VALID:               FamilyDef,
+	ERR_DEF_INVALID_REQUEST:              FamilyDef,
+	ERR_DEF_INVALID_DATA:                 FamilyDef,
+	ERR_DEF_NOT_FOUND:                    FamilyDef,
+	ERR_DEF_PERMISSION_DENIED:            FamilyDef,
+	ERR_DEF_INVALID_UPSERT:               FamilyDef,
+	ERR_DEF_INVALID_DATE:                 FamilyDef,
+	ERR_DEF_INVALID_REQUESTS:             FamilyDef,
+	ERR_TMP_RATE_LIMIT_EXCEEDED:          FamilyTmp,
+	ERR_TMP_TIMEOUT:                      FamilyTmp,
+	ERR_TMP_SERVICE_UNAVAILABLE:          FamilyTmp,
+	ERR_DEF_API_UNAVAILABLE:              FamilyDef,
+	ERR_DEF_UNABLED_START_PROCESS:        FamilyDef,
+	ERR_DEF_CANT_INSERT_IN_DATAWAREHOUSE: FamilyDef,
+	ERR_DEF_PROCESSED_WITH_ERROR:         FamilyDef,
+	ERR_DEF_COST_LIMIT_EXCEEDED:          FamilyDef,
+	ERR_WARN_ACCOUNT_LIMITATION:          FamilyWarn,
 }
 
 var ErrorCodes = map[QErrorCode]string{
@@ -100,7 +117,11 @@ func ParseQErrorCode(val interface{}) (QErrorCode, bool) {
 	}
 }
 
+// GetErrorCodeType renvoie la famille (DEF, TMP, WARN) d'un code du SDK ou
+// enregistré par le connecteur (cf RegisterErrorCode), "" pour un code inconnu.
 func GetErrorCodeType(code QErrorCode) string {
+	errorCodesMu.RLock()
+	defer errorCodesMu.RUnlock()
 	if label, ok := errorCodeLabels[code]; ok {
 		return label
 	}
@@ -120,11 +141,107 @@ func (e *QError) Error() string {
 	return fmt.Sprintf("code: %d, message: %s", e.Code, e.ErrorMessage())
 }
 
+// Unwrap renvoie la cause d'origine quand elle est connue. Pour une QError
+// construite à la main (Err seul, ou relue depuis un checkpoint), on retombe sur le
+// texte : la chaîne est perdue mais le message reste consultable.
 func (e *QError) Unwrap() error {
 	if e == nil {
 		return nil
 	}
-	return fmt.Errorf("%s", e.Err)
+	if e.Cause != nil {
+		return e.Cause
+	}
+	if e.Err == "" {
+		return nil
+	}
+	return errors.New(e.Err)
+}
+
+// #region NewQError
+// NewQError construit une QError qui enveloppe err : Err en porte le texte pour le
+// JSON, Cause l'erreur elle-même pour errors.Is / errors.As.
+func NewQError(code QErrorCode, err error) *QError {
+	q := &QError{Code: code, Cause: err}
+	if err != nil {
+		q.Err = err.Error()
+	}
+	return q
+}
+
+// #region DefError
+// DefError construit une erreur définitive (famille DEF) : le service s'arrête et ne
+// reprendra pas tant que la cause n'est pas corrigée.
+func DefError(code QErrorCode, err error) *QError {
+	return familyError(FamilyDef, code, err)
+}
+
+// #region TmpError
+// TmpError construit une erreur temporaire (famille TMP) : le service s'arrête et
+// reprendra depuis le state du checkpoint.
+func TmpError(code QErrorCode, err error) *QError {
+	return familyError(FamilyTmp, code, err)
+}
+
+// #region WarnError
+// WarnError construit un avertissement (famille WARN). Même règle que pour
+// ERR_WARN_ACCOUNT_LIMITATION : seulement quand la source le dit explicitement.
+func WarnError(code QErrorCode, err error) *QError {
+	return familyError(FamilyWarn, code, err)
+}
+
+// #region familyError
+// familyError tient la promesse du constructeur : un code d'une autre famille, ou
+// inconnu, ferait de DefError(ERR_TMP_TIMEOUT, …) une erreur rejouée sans fin. Il
+// est remplacé par ERR_DEF_PROCESSED_WITH_ERROR, et cité dans Err pour qu'on
+// retrouve l'appel fautif. Jamais par un code WARN ou TMP, quel que soit le
+// constructeur : un avertissement masquerait l'échec, une reprise le rejouerait.
+func familyError(family string, code QErrorCode, err error) *QError {
+	got := GetErrorCodeType(code)
+	if got == family {
+		return NewQError(code, err)
+	}
+	q := NewQError(ERR_DEF_PROCESSED_WITH_ERROR, err)
+	q.Err = fmt.Sprintf("code %d (family %q) is not a %s code", code, got, family)
+	if err != nil {
+		q.Err += ": " + err.Error()
+	}
+	return q
+}
+
+// #region AsQError
+// AsQError retrouve la QError dans la chaîne de err, s'il y en a une.
+func AsQError(err error) (*QError, bool) {
+	var q *QError
+	if errors.As(err, &q) && q != nil {
+		return q, true
+	}
+	return nil, false
+}
+
+// #region IsDefinitive
+func IsDefinitive(err error) bool {
+	return hasFamily(err, FamilyDef)
+}
+
+// #region IsTemporary
+func IsTemporary(err error) bool {
+	return hasFamily(err, FamilyTmp)
+}
+
+// #region IsWarning
+func IsWarning(err error) bool {
+	return hasFamily(err, FamilyWarn)
+}
+
+// #region hasFamily
+// hasFamily s'appuie sur GetErrorCodeType : un code inconnu n'appartient à aucune
+// famille, et aucun prédicat ne doit le classer par défaut.
+func hasFamily(err error, family string) bool {
+	q, ok := AsQError(err)
+	if !ok {
+		return false
+	}
+	return GetErrorCodeType(q.Code) == family
 }
 
 // Méthode pour obtenir le code d'erreur
@@ -145,7 +262,9 @@ func (e *QError) ErrorMessage() string {
 		return ""
 	}
 
+	errorCodesMu.RLock()
 	message, ok := ErrorCodes[QErrorCode(e.Code)]
+	errorCodesMu.RUnlock()
 	if ok {
 		return message
 	}
@@ -166,7 +285,7 @@ func (e *QError) MarshalJSON() ([]byte, error) {
 		Details string `json:"details,omitempty"`
 	}{
 		Alias:   (*Alias)(e),
-		Message: e.ErrorMessage(), // => libellé du code
+		Message: e.LocalizedMessage(CurrentLocale()), // => libellé du code, dans la langue du client
 		Details: e.Message,
 	})
 }
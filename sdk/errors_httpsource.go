@@ -0,0 +1,88 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/quantiio/quanti-sdk/sdk/httpsource"
+)
+
+// #region QErrorFromHTTPSource
+// QErrorFromHTTPSource traduit une erreur du moteur httpsource en QError, pour que
+// chaque connecteur déclaratif n'ait plus à recopier la table Kind → QError.
+//
+// Le Kind donne le code de base, le statut HTTP l'affine : un 403 est un droit
+// manquant et pas des credentials à refaire, un 404 une ressource absente, un
+// timeout une erreur temporaire. Message reprend le message du moteur, déjà passé au
+// Redactor ; Cause garde l'erreur d'origine pour errors.As.
+//
+// Retourne nil pour nil et pour ErrStop (arrêt demandé, pas une erreur). Une QError
+// déjà construite est renvoyée telle quelle.
+func QErrorFromHTTPSource(err error) *QError {
+	if err == nil {
+		return nil
+	}
+	if q, ok := AsQError(err); ok {
+		return q
+	}
+
+	var src *httpsource.Error
+	if !errors.As(err, &src) {
+		// Erreur étrangère (typiquement le ctx.Err() d'une attente interrompue).
+		switch {
+		case httpsource.IsTimeout(err):
+			return NewQError(ERR_TMP_TIMEOUT, err)
+		case errors.Is(err, context.Canceled):
+			return NewQError(ERR_TMP_SERVICE_UNAVAILABLE, err)
+		}
+		return NewQError(ERR_DEF_API_UNAVAILABLE, err)
+	}
+	if src.Kind == httpsource.KindStopped {
+		return nil
+	}
+
+	q := NewQError(codeForHTTPSource(src), err)
+	q.Message = src.Message
+	return q
+}
+
+// #endregion
+
+// #region codeForHTTPSource
+func codeForHTTPSource(e *httpsource.Error) QErrorCode {
+	switch e.Status {
+	case http.StatusForbidden:
+		return ERR_DEF_PERMISSION_DENIED
+	case http.StatusNotFound:
+		return ERR_DEF_NOT_FOUND
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return ERR_TMP_TIMEOUT
+	}
+
+	switch e.Kind {
+	case httpsource.KindInvalidSpec:
+		return ERR_DEF_INVALID_REQUEST
+	case httpsource.KindAuth:
+		return ERR_DEF_AUTH_NOT_VALID
+	case httpsource.KindRateLimit:
+		return ERR_TMP_RATE_LIMIT_EXCEEDED
+	case httpsource.KindInvalidData:
+		return ERR_DEF_INVALID_DATA
+	case httpsource.KindJobTimeout:
+		return ERR_TMP_TIMEOUT
+	case httpsource.KindJobFailed:
+		return ERR_DEF_API_UNAVAILABLE
+	default:
+		switch {
+		case httpsource.IsTimeout(e):
+			return ERR_TMP_TIMEOUT
+		case errors.Is(e, context.Canceled):
+			// Worker arrêté en cours de collecte : rien à corriger, on reprendra.
+			return ERR_TMP_SERVICE_UNAVAILABLE
+		}
+		return ERR_DEF_API_UNAVAILABLE
+	}
+}
+
+// #endregion
@@ -0,0 +1,98 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/quantiio/quanti-sdk/sdk/httpsource"
+)
+
+func TestQErrorFromHTTPSource(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want QErrorCode
+	}{
+		{"invalid spec", &httpsource.Error{Kind: httpsource.KindInvalidSpec, Message: "source.url is required"}, ERR_DEF_INVALID_REQUEST},
+		{"401", &httpsource.Error{Kind: httpsource.KindAuth, Status: 401}, ERR_DEF_AUTH_NOT_VALID},
+		{"403", &httpsource.Error{Kind: httpsource.KindAuth, Status: 403}, ERR_DEF_PERMISSION_DENIED},
+		{"429", &httpsource.Error{Kind: httpsource.KindRateLimit, Status: 429}, ERR_TMP_RATE_LIMIT_EXCEEDED},
+		{"404", &httpsource.Error{Kind: httpsource.KindUnavailable, Status: 404}, ERR_DEF_NOT_FOUND},
+		{"500", &httpsource.Error{Kind: httpsource.KindUnavailable, Status: 500}, ERR_DEF_API_UNAVAILABLE},
+		{"504", &httpsource.Error{Kind: httpsource.KindUnavailable, Status: 504}, ERR_TMP_TIMEOUT},
+		{"network timeout", &httpsource.Error{Kind: httpsource.KindUnavailable, Cause: context.DeadlineExceeded}, ERR_TMP_TIMEOUT},
+		{"invalid data", &httpsource.Error{Kind: httpsource.KindInvalidData}, ERR_DEF_INVALID_DATA},
+		{"job failed", &httpsource.Error{Kind: httpsource.KindJobFailed}, ERR_DEF_API_UNAVAILABLE},
+		{"job timeout", &httpsource.Error{Kind: httpsource.KindJobTimeout}, ERR_TMP_TIMEOUT},
+		{"wrapped", fmt.Errorf("date 2026-08-12: %w", &httpsource.Error{Kind: httpsource.KindRateLimit}), ERR_TMP_RATE_LIMIT_EXCEEDED},
+		{"canceled", &httpsource.Error{Kind: httpsource.KindUnavailable, Cause: context.Canceled}, ERR_TMP_SERVICE_UNAVAILABLE},
+		{"foreign deadline", context.DeadlineExceeded, ERR_TMP_TIMEOUT},
+		{"foreign canceled", context.Canceled, ERR_TMP_SERVICE_UNAVAILABLE},
+		{"foreign", errors.New("boom"), ERR_DEF_API_UNAVAILABLE},
+	}
+	for _, c := range cases {
+		got := QErrorFromHTTPSource(c.err)
+		if got == nil || got.Code != c.want {
+			t.Errorf("%s: got %v, want code %d", c.name, got, c.want)
+		}
+	}
+}
+
+func TestQErrorFromHTTPSourceNotAnError(t *testing.T) {
+	if got := QErrorFromHTTPSource(nil); got != nil {
+		t.Errorf("nil: got %v", got)
+	}
+	if got := QErrorFromHTTPSource(httpsource.ErrStop); got != nil {
+		t.Errorf("ErrStop: got %v", got)
+	}
+	existing := TmpError(ERR_TMP_SERVICE_UNAVAILABLE, nil)
+	if got := QErrorFromHTTPSource(existing); got != existing {
+		t.Errorf("an existing QError must be returned as is, got %v", got)
+	}
+}
+
+// Le message redacté arrive dans QError.Message, et le timeout survit au masquage de
+// la cause par le Redactor.
+func TestQErrorFromHTTPSourceRedacted(t *testing.T) {
+	redactor := httpsource.NewRedactor(map[string]any{"apikey": "SECRET-KEY"})
+	err := redactor.Err(&httpsource.Error{
+		Kind:    httpsource.KindUnavailable,
+		Message: "request failed (key SECRET-KEY)",
+		Cause:   fmt.Errorf("Get https://x?k=SECRET-KEY: %w", context.DeadlineExceeded),
+	})
+
+	got := QErrorFromHTTPSource(err)
+	if got.Code != ERR_TMP_TIMEOUT {
+		t.Errorf("code: got %d, want %d", got.Code, ERR_TMP_TIMEOUT)
+	}
+	if got.Message != "request failed (key ***)" {
+		t.Errorf("message: got %q", got.Message)
+	}
+	if !errors.Is(got, context.DeadlineExceeded) {
+		t.Error("errors.Is(context.DeadlineExceeded) should survive redaction")
+	}
+	var src *httpsource.Error
+	if !errors.As(got, &src) {
+		t.Error("errors.As should reach the *httpsource.Error")
+	}
+}
+
+// Une collecte interrompue par l'arrêt du worker reste temporaire après masquage.
+func TestQErrorFromHTTPSourceRedactedCanceled(t *testing.T) {
+	redactor := httpsource.NewRedactor(map[string]any{"apikey": "SECRET-KEY"})
+	err := redactor.Err(&httpsource.Error{
+		Kind:    httpsource.KindUnavailable,
+		Message: "request failed",
+		Cause:   fmt.Errorf("Get https://x?k=SECRET-KEY: %w", context.Canceled),
+	})
+
+	got := QErrorFromHTTPSource(err)
+	if got.Code != ERR_TMP_SERVICE_UNAVAILABLE {
+		t.Errorf("code: got %d, want %d", got.Code, ERR_TMP_SERVICE_UNAVAILABLE)
+	}
+	if errors.Is(got, context.DeadlineExceeded) {
+		t.Error("a canceled collection is not a timeout")
+	}
+}
@@ -0,0 +1,223 @@
+package sdk
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Langues des messages destinés au client (last_execution_message). Les logs, eux,
+// restent tels quels : ils sont lus par l'équipe, pas par le client.
+const (
+	LocaleFR = "fr"
+	LocaleEN = "en"
+
+	// LocaleEnv permet de choisir la langue sans toucher à la conf (worker, tests).
+	LocaleEnv = "QUANTI_LOCALE"
+)
+
+// defaultLocale : l'anglais reste le défaut, c'est le texte qu'affichait
+// MarshalJSON avant le catalogue.
+const defaultLocale = LocaleEN
+
+var currentLocale string
+
+// errorMessages est le catalogue code → langue → message. L'anglais des codes du SDK
+// n'y figure pas : il vient de ErrorCodes, qui reste la référence.
+//
+// Un message peut contenir des paramètres `{nom}` remplis depuis QError.Params (cf
+// LocalizedMessage).
+var errorMessages = map[QErrorCode]map[string]string{
+	ERR_DEF_AUTH_NOT_VALID:               {LocaleFR: "Authentification invalide"},
+	ERR_DEF_INVALID_REQUEST:              {LocaleFR: "Requête invalide"},
+	ERR_DEF_INVALID_DATA:                 {LocaleFR: "Données invalides"},
+	ERR_DEF_NOT_FOUND:                    {LocaleFR: "Ressource introuvable"},
+	ERR_DEF_PERMISSION_DENIED:            {LocaleFR: "Permission refusée"},
+	ERR_TMP_RATE_LIMIT_EXCEEDED:          {LocaleFR: "Limite de requêtes atteinte"},
+	ERR_TMP_TIMEOUT:                      {LocaleFR: "Délai d'attente dépassé"},
+	ERR_TMP_SERVICE_UNAVAILABLE:          {LocaleFR: "Service indisponible"},
+	ERR_DEF_INVALID_UPSERT:               {LocaleFR: "Écriture des données impossible"},
+	ERR_DEF_INVALID_DATE:                 {LocaleFR: "Date invalide"},
+	ERR_DEF_INVALID_REQUESTS:             {LocaleFR: "Requêtes invalides"},
+	ERR_DEF_API_UNAVAILABLE:              {LocaleFR: "API indisponible"},
+	ERR_DEF_UNABLED_START_PROCESS:        {LocaleFR: "Le démarrage du traitement est désactivé"},
+	ERR_DEF_CANT_INSERT_IN_DATAWAREHOUSE: {LocaleFR: "Insertion impossible dans l'entrepôt de données"},
+	ERR_DEF_PROCESSED_WITH_ERROR:         {LocaleFR: "Traitement terminé avec des erreurs"},
+	ERR_DEF_COST_LIMIT_EXCEEDED:          {LocaleFR: "Limite de coût de l'entrepôt de données dépassée"},
+	ERR_WARN_ACCOUNT_LIMITATION:          {LocaleFR: "Limitation du compte signalée par la source"},
+}
+
+// contextParams : paramètres ajoutés en fin de message quand le texte ne les cite
+// pas lui-même, dans cet ordre. Ce sont ceux qui disent au client OÙ regarder.
+var contextParams = []string{"account", "date"}
+
+var contextLabels = map[string]map[string]string{
+	LocaleFR: {"account": "compte", "date": "date"},
+	LocaleEN: {"account": "account", "date": "date"},
+}
+
+// #region SetLocale
+// SetLocale fixe la langue des messages client. Process l'appelle avec
+// ConfigFile.Locale ; une valeur vide rend la main à la variable d'environnement.
+func SetLocale(locale string) {
+	errorCodesMu.Lock()
+	defer errorCodesMu.Unlock()
+	currentLocale = normalizeLocale(locale)
+}
+
+// #endregion
+
+// #region CurrentLocale
+// CurrentLocale : langue fixée par SetLocale, sinon QUANTI_LOCALE, sinon l'anglais.
+func CurrentLocale() string {
+	errorCodesMu.RLock()
+	locale := currentLocale
+	errorCodesMu.RUnlock()
+	if locale != "" {
+		return locale
+	}
+	if env := normalizeLocale(os.Getenv(LocaleEnv)); env != "" {
+		return env
+	}
+	return defaultLocale
+}
+
+// #endregion
+
+// #region normalizeLocale
+// normalizeLocale ramène "fr-FR", "fr_FR" ou "FR" à "fr". Une langue sans catalogue
+// donne "" : mieux vaut l'anglais qu'un message vide.
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	switch locale {
+	case LocaleFR, LocaleEN:
+		return locale
+	default:
+		return ""
+	}
+}
+
+// #endregion
+
+// #region RegisterErrorMessage
+// RegisterErrorMessage ajoute ou remplace la traduction d'un code existant (du SDK
+// ou enregistré par RegisterErrorCode).
+func RegisterErrorMessage(code QErrorCode, locale, message string) error {
+	normalized := normalizeLocale(locale)
+	if normalized == "" {
+		return fmt.Errorf("error code %d: locale %q is not supported (fr, en)", code, locale)
+	}
+	if strings.TrimSpace(message) == "" {
+		return fmt.Errorf("error code %d: empty %s message", code, normalized)
+	}
+
+	errorCodesMu.Lock()
+	defer errorCodesMu.Unlock()
+
+	if _, exists := errorCodeLabels[code]; !exists {
+		return fmt.Errorf("error code %d is not defined, register it first", code)
+	}
+	if errorMessages[code] == nil {
+		errorMessages[code] = map[string]string{}
+	}
+	errorMessages[code][normalized] = message
+	return nil
+}
+
+// #endregion
+
+// #region WithParam
+// WithParam renseigne un paramètre du message client (account, date, ou un nom
+// cité par un message enregistré). Renvoie e pour pouvoir chaîner.
+func (e *QError) WithParam(key, value string) *QError {
+	if e == nil {
+		return nil
+	}
+	if e.Params == nil {
+		e.Params = map[string]string{}
+	}
+	e.Params[key] = value
+	return e
+}
+
+// #endregion
+
+// #region LocalizedMessage
+// LocalizedMessage renvoie le libellé du code dans la langue demandée, paramètres
+// substitués. Les paramètres account et date que le texte ne cite pas sont ajoutés en
+// fin de message : "Permission refusée (compte : Shop A, date : 2026-08-12)".
+//
+// Repli : langue demandée → anglais du catalogue → ErrorCodes → ErrorMessage(). Une
+// traduction vide compte comme absente : seul le code 0, qui n'est pas une erreur,
+// donne un message vide.
+func (e *QError) LocalizedMessage(locale string) string {
+	if e == nil {
+		return e.ErrorMessage()
+	}
+	if e.Code == 0 {
+		return ""
+	}
+
+	locale = normalizeLocale(locale)
+	if locale == "" {
+		locale = defaultLocale
+	}
+
+	errorCodesMu.RLock()
+	message := errorMessages[e.Code][locale]
+	if strings.TrimSpace(message) == "" {
+		message = errorMessages[e.Code][LocaleEN]
+	}
+	errorCodesMu.RUnlock()
+	if strings.TrimSpace(message) == "" {
+		message = e.ErrorMessage()
+	}
+
+	return renderMessage(message, e.Params, locale)
+}
+
+// #endregion
+
+// #region renderMessage
+func renderMessage(message string, params map[string]string, locale string) string {
+	if len(params) == 0 {
+		return message
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	used := map[string]bool{}
+	for _, k := range keys {
+		placeholder := "{" + k + "}"
+		if strings.Contains(message, placeholder) {
+			message = strings.ReplaceAll(message, placeholder, params[k])
+			used[k] = true
+		}
+	}
+
+	labels := contextLabels[locale]
+	var parts []string
+	for _, k := range contextParams {
+		if v := params[k]; v != "" && !used[k] {
+			if locale == LocaleFR {
+				parts = append(parts, labels[k]+" : "+v)
+			} else {
+				parts = append(parts, labels[k]+": "+v)
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return message
+	}
+	return message + " (" + strings.Join(parts, ", ") + ")"
+}
+
+// #endregion
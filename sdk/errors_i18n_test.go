@@ -0,0 +1,85 @@
+package sdk
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// resetLocale remet la langue par défaut après un test qui la change.
+func resetLocale(t *testing.T) {
+	t.Cleanup(func() { SetLocale("") })
+}
+
+func TestLocalizedMessage(t *testing.T) {
+	qerr := DefError(ERR_DEF_PERMISSION_DENIED, nil)
+
+	if got := qerr.LocalizedMessage("fr-FR"); got != "Permission refusée" {
+		t.Errorf("fr: got %q", got)
+	}
+	if got := qerr.LocalizedMessage(LocaleEN); got != "Permission Denied" {
+		t.Errorf("en: got %q", got)
+	}
+	// Langue sans catalogue ⇒ anglais plutôt qu'un message vide.
+	if got := qerr.LocalizedMessage("de"); got != "Permission Denied" {
+		t.Errorf("unsupported locale: got %q", got)
+	}
+
+	qerr.WithParam("account", "Shop A").WithParam("date", "2026-08-12")
+	if got := qerr.LocalizedMessage(LocaleFR); got != "Permission refusée (compte : Shop A, date : 2026-08-12)" {
+		t.Errorf("fr with params: got %q", got)
+	}
+	if got := qerr.LocalizedMessage(LocaleEN); got != "Permission Denied (account: Shop A, date: 2026-08-12)" {
+		t.Errorf("en with params: got %q", got)
+	}
+}
+
+func TestLocalizedMessageRegisteredTemplate(t *testing.T) {
+	unregister(t, 2600, "ERR_TMP_REPORT_NOT_READY")
+	MustRegisterErrorCodes(ErrorCodeDef{
+		Code:    2600,
+		Label:   "ERR_TMP_REPORT_NOT_READY",
+		Family:  FamilyTmp,
+		Message: "Report not ready",
+		Messages: map[string]string{
+			"fr": "Le rapport du {date} est encore en génération",
+		},
+	})
+
+	qerr := TmpError(2600, nil).WithParam("date", "2026-08-12").WithParam("account", "Shop A")
+	// date est cité par le texte : seul account est ajouté en fin de message.
+	if got := qerr.LocalizedMessage(LocaleFR); got != "Le rapport du 2026-08-12 est encore en génération (compte : Shop A)" {
+		t.Errorf("fr: got %q", got)
+	}
+	if got := qerr.LocalizedMessage(LocaleEN); got != "Report not ready (account: Shop A, date: 2026-08-12)" {
+		t.Errorf("en fallback: got %q", got)
+	}
+
+	if err := RegisterErrorMessage(9999, LocaleFR, "x"); err == nil {
+		t.Error("RegisterErrorMessage should refuse an unknown code")
+	}
+	if err := RegisterErrorMessage(2600, LocaleFR, " "); err == nil {
+		t.Error("RegisterErrorMessage should refuse an empty message")
+	}
+	if got := qerr.LocalizedMessage(LocaleFR); got != "Le rapport du 2026-08-12 est encore en génération (compte : Shop A)" {
+		t.Errorf("a refused message must not replace the translation, got %q", got)
+	}
+}
+
+// Seul le message change avec la langue : code et erreur restent identiques pour
+// les consommateurs machine.
+func TestMarshalJSONUsesLocale(t *testing.T) {
+	resetLocale(t)
+	qerr := TmpError(ERR_TMP_TIMEOUT, nil).WithParam("account", "Shop A")
+
+	t.Setenv(LocaleEnv, "fr")
+	out, _ := json.Marshal(qerr)
+	if want := `{"code":2010,"error":"","message":"Délai d'attente dépassé (compte : Shop A)"}`; string(out) != want {
+		t.Errorf("env fr: got %s, want %s", out, want)
+	}
+
+	SetLocale("en")
+	out, _ = json.Marshal(qerr)
+	if want := `{"code":2010,"error":"","message":"Timeout (account: Shop A)"}`; string(out) != want {
+		t.Errorf("config en overrides env: got %s, want %s", out, want)
+	}
+}
@@ -0,0 +1,128 @@
+package sdk
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrorCodeDef décrit un code d'erreur propre à un connecteur ("rapport encore en
+// génération", "compte suspendu"…), plus fin que les codes génériques du SDK.
+//
+// La famille fait foi pour le comportement (arrêt définitif, reprise, simple
+// avertissement) et doit correspondre à la plage du code : 1xxx DEF, 2xxx TMP,
+// 3xxx WARN. Le parent ne lit que le code : un code hors de sa plage serait traité
+// avec la mauvaise famille côté plateforme.
+type ErrorCodeDef struct {
+	Code    QErrorCode
+	Label   string // Nom stable, ex: "ERR_TMP_REPORT_NOT_READY"
+	Family  string // FamilyDef, FamilyTmp ou FamilyWarn
+	Message string // Libellé, équivalent de ErrorCodes pour les codes du SDK
+
+	// Messages : traductions optionnelles du libellé, par langue ("fr", "en"), avec
+	// paramètres `{nom}` (cf LocalizedMessage).
+	Messages map[string]string
+}
+
+var (
+	errorCodesMu sync.RWMutex
+
+	// registeredLabels indexe les labels des codes, ceux du SDK compris, pour refuser
+	// deux codes différents sous le même nom.
+	registeredLabels = map[string]QErrorCode{
+		"ERR_DEF_AUTH_NOT_VALID":               ERR_DEF_AUTH_NOT_VALID,
+		"ERR_DEF_INVALID_REQUEST":              ERR_DEF_INVALID_REQUEST,
+		"ERR_DEF_INVALID_DATA":                 ERR_DEF_INVALID_DATA,
+		"ERR_DEF_NOT_FOUND":                    ERR_DEF_NOT_FOUND,
+		"ERR_DEF_PERMISSION_DENIED":            ERR_DEF_PERMISSION_DENIED,
+		"ERR_DEF_INVALID_UPSERT":               ERR_DEF_INVALID_UPSERT,
+		"ERR_DEF_INVALID_DATE":                 ERR_DEF_INVALID_DATE,
+		"ERR_DEF_INVALID_REQUESTS":             ERR_DEF_INVALID_REQUESTS,
+		"ERR_DEF_API_UNAVAILABLE":              ERR_DEF_API_UNAVAILABLE,
+		"ERR_DEF_UNABLED_START_PROCESS":        ERR_DEF_UNABLED_START_PROCESS,
+		"ERR_DEF_CANT_INSERT_IN_DATAWAREHOUSE": ERR_DEF_CANT_INSERT_IN_DATAWAREHOUSE,
+		"ERR_DEF_PROCESSED_WITH_ERROR":         ERR_DEF_PROCESSED_WITH_ERROR,
+		"ERR_DEF_COST_LIMIT_EXCEEDED":          ERR_DEF_COST_LIMIT_EXCEEDED,
+		"ERR_TMP_RATE_LIMIT_EXCEEDED":          ERR_TMP_RATE_LIMIT_EXCEEDED,
+		"ERR_TMP_TIMEOUT":                      ERR_TMP_TIMEOUT,
+		"ERR_TMP_SERVICE_UNAVAILABLE":          ERR_TMP_SERVICE_UNAVAILABLE,
+		"ERR_WARN_ACCOUNT_LIMITATION":          ERR_WARN_ACCOUNT_LIMITATION,
+	}
+)
+
+// familyRanges : plage de codes autorisée par famille, bornes incluses.
+var familyRanges = map[string][2]QErrorCode{
+	FamilyDef:  {1000, 1999},
+	FamilyTmp:  {2000, 2999},
+	FamilyWarn: {3000, 3999},
+}
+
+// #region RegisterErrorCode
+// RegisterErrorCode ajoute un code propre au connecteur. Une fois enregistré, le code
+// est résolu par GetErrorCodeType, ErrorMessage et les prédicats IsTemporary & co
+// exactement comme un code du SDK.
+//
+// À appeler au démarrage (init ou début du main) : un conflit doit faire échouer le
+// binaire tout de suite, pas au premier checkpoint en erreur.
+func RegisterErrorCode(def ErrorCodeDef) error {
+	def.Family = strings.ToUpper(strings.TrimSpace(def.Family))
+
+	bounds, ok := familyRanges[def.Family]
+	if !ok {
+		return fmt.Errorf("error code %d: family %q is not supported (DEF, TMP, WARN)", def.Code, def.Family)
+	}
+	if def.Code < bounds[0] || def.Code > bounds[1] {
+		return fmt.Errorf("error code %d: out of the %s range (%d-%d)", def.Code, def.Family, bounds[0], bounds[1])
+	}
+	if strings.TrimSpace(def.Label) == "" {
+		return fmt.Errorf("error code %d: label is required", def.Code)
+	}
+	if strings.TrimSpace(def.Message) == "" {
+		return fmt.Errorf("error code %d: message is required", def.Code)
+	}
+
+	errorCodesMu.Lock()
+	defer errorCodesMu.Unlock()
+
+	if _, exists := errorCodeLabels[def.Code]; exists {
+		return fmt.Errorf("error code %d is already defined (%s)", def.Code, ErrorCodes[def.Code])
+	}
+	if other, exists := registeredLabels[def.Label]; exists {
+		return fmt.Errorf("error code label %q is already used by code %d", def.Label, other)
+	}
+
+	localized := map[string]string{}
+	for locale, message := range def.Messages {
+		normalized := normalizeLocale(locale)
+		if normalized == "" {
+			return fmt.Errorf("error code %d: locale %q is not supported (fr, en)", def.Code, locale)
+		}
+		if strings.TrimSpace(message) == "" {
+			return fmt.Errorf("error code %d: empty %s message", def.Code, normalized)
+		}
+		localized[normalized] = message
+	}
+
+	errorCodeLabels[def.Code] = def.Family
+	ErrorCodes[def.Code] = def.Message
+	registeredLabels[def.Label] = def.Code
+	if len(localized) > 0 {
+		errorMessages[def.Code] = localized
+	}
+	return nil
+}
+
+// #endregion
+
+// #region MustRegisterErrorCodes
+// MustRegisterErrorCodes enregistre plusieurs codes et panique au premier refus :
+// c'est la forme prévue pour un `var _ = ...` ou un init() de connecteur.
+func MustRegisterErrorCodes(defs ...ErrorCodeDef) {
+	for _, def := range defs {
+		if err := RegisterErrorCode(def); err != nil {
+			panic(fmt.Sprintf("quanti-sdk: %v", err))
+		}
+	}
+}
+
+// #endregion
@@ -0,0 +1,79 @@
+package sdk
+
+import "testing"
+
+// unregister retire un code enregistré par un test, pour que les tests restent
+// indépendants les uns des autres.
+func unregister(t *testing.T, code QErrorCode, label string) {
+	t.Cleanup(func() {
+		errorCodesMu.Lock()
+		defer errorCodesMu.Unlock()
+		delete(errorCodeLabels, code)
+		delete(ErrorCodes, code)
+		delete(registeredLabels, label)
+		delete(errorMessages, code)
+	})
+}
+
+func TestRegisterErrorCodeResolves(t *testing.T) {
+	unregister(t, 2500, "ERR_TMP_REPORT_NOT_READY")
+	if err := RegisterErrorCode(ErrorCodeDef{
+		Code:    2500,
+		Label:   "ERR_TMP_REPORT_NOT_READY",
+		Family:  "tmp",
+		Message: "Report still generating",
+	}); err != nil {
+		t.Fatalf("RegisterErrorCode: %v", err)
+	}
+
+	if got := GetErrorCodeType(2500); got != FamilyTmp {
+		t.Errorf("GetErrorCodeType: got %q, want TMP", got)
+	}
+	qerr := TmpError(2500, nil)
+	if got := qerr.ErrorMessage(); got != "Report still generating" {
+		t.Errorf("ErrorMessage: got %q", got)
+	}
+	if !IsTemporary(qerr) {
+		t.Error("IsTemporary should resolve a registered code")
+	}
+}
+
+func TestRegisterErrorCodeRejections(t *testing.T) {
+	unregister(t, 1500, "ERR_DEF_ACCOUNT_SUSPENDED")
+	if err := RegisterErrorCode(ErrorCodeDef{Code: 1500, Label: "ERR_DEF_ACCOUNT_SUSPENDED", Family: FamilyDef, Message: "Account suspended"}); err != nil {
+		t.Fatalf("RegisterErrorCode: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		def  ErrorCodeDef
+	}{
+		{"out of range", ErrorCodeDef{Code: 2501, Label: "X", Family: FamilyDef, Message: "m"}},
+		{"wrong family range", ErrorCodeDef{Code: 3001, Label: "X", Family: FamilyTmp, Message: "m"}},
+		{"unknown family", ErrorCodeDef{Code: 1501, Label: "X", Family: "INFO", Message: "m"}},
+		{"clash with the SDK", ErrorCodeDef{Code: ERR_DEF_NOT_FOUND, Label: "X", Family: FamilyDef, Message: "m"}},
+		{"clash with a registered code", ErrorCodeDef{Code: 1500, Label: "X", Family: FamilyDef, Message: "m"}},
+		{"label reused", ErrorCodeDef{Code: 1501, Label: "ERR_DEF_ACCOUNT_SUSPENDED", Family: FamilyDef, Message: "m"}},
+		{"label of the SDK", ErrorCodeDef{Code: 2501, Label: "ERR_TMP_TIMEOUT", Family: FamilyTmp, Message: "m"}},
+		{"no label", ErrorCodeDef{Code: 1501, Family: FamilyDef, Message: "m"}},
+		{"no message", ErrorCodeDef{Code: 1501, Label: "X", Family: FamilyDef}},
+		{"empty translation", ErrorCodeDef{Code: 1501, Label: "X", Family: FamilyDef, Message: "m", Messages: map[string]string{"fr": " "}}},
+	}
+	for _, c := range cases {
+		if err := RegisterErrorCode(c.def); err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+	if got := GetErrorCodeType(1501); got != "" {
+		t.Errorf("a rejected code must not be registered, got family %q", got)
+	}
+}
+
+func TestMustRegisterErrorCodesPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic on a clashing code")
+		}
+	}()
+	MustRegisterErrorCodes(ErrorCodeDef{Code: ERR_TMP_TIMEOUT, Label: "X", Family: FamilyTmp, Message: "m"})
+}
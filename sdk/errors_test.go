@@ -0,0 +1,98 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestQErrorWrapsCause(t *testing.T) {
+	cause := &url.Error{Op: "Get", URL: "https://api.example.com", Err: context.DeadlineExceeded}
+	qerr := TmpError(ERR_TMP_TIMEOUT, fmt.Errorf("fetch: %w", cause))
+
+	if !errors.Is(qerr, context.DeadlineExceeded) {
+		t.Error("errors.Is should reach context.DeadlineExceeded through the QError")
+	}
+	var urlErr *url.Error
+	if !errors.As(qerr, &urlErr) {
+		t.Error("errors.As should reach the *url.Error through the QError")
+	}
+	if qerr.Err != "fetch: "+cause.Error() {
+		t.Errorf("Err should carry the cause text, got %q", qerr.Err)
+	}
+}
+
+func TestQErrorWithoutCause(t *testing.T) {
+	if err := (&QError{Code: ERR_DEF_INVALID_DATA}).Unwrap(); err != nil {
+		t.Errorf("Unwrap without Err nor Cause should be nil, got %v", err)
+	}
+	legacy := &QError{Code: ERR_DEF_INVALID_DATA, Err: "boom"}
+	if err := legacy.Unwrap(); err == nil || err.Error() != "boom" {
+		t.Errorf("Unwrap should fall back on Err, got %v", err)
+	}
+}
+
+func TestQErrorJSONUnchanged(t *testing.T) {
+	qerr := DefError(ERR_DEF_AUTH_NOT_VALID, errors.New("401"))
+	qerr.Message = "token revoked"
+
+	out, err := json.Marshal(qerr)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"code":1000,"error":"401","message":"Auth not valid","details":"token revoked"}`
+	if string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+}
+
+func TestFamilyConstructorsCheckTheCode(t *testing.T) {
+	qerr := DefError(ERR_TMP_TIMEOUT, errors.New("deadline"))
+	if qerr.Code != ERR_DEF_PROCESSED_WITH_ERROR || !IsDefinitive(qerr) {
+		t.Errorf("a TMP code passed to DefError must fall back to a DEF code: %+v", qerr)
+	}
+	if !strings.Contains(qerr.Err, "code 2010") || !strings.Contains(qerr.Err, "deadline") {
+		t.Errorf("Err must name the rejected code and keep the cause: %q", qerr.Err)
+	}
+	if q := TmpError(ERR_DEF_INVALID_DATA, nil); q.Code != ERR_DEF_PROCESSED_WITH_ERROR {
+		t.Errorf("TmpError fallback: %+v", q)
+	}
+	// Un code erroné ne devient jamais un simple avertissement.
+	if q := WarnError(ERR_TMP_TIMEOUT, nil); q.Code != ERR_DEF_PROCESSED_WITH_ERROR || IsWarning(q) || !strings.Contains(q.Err, "code 2010") {
+		t.Errorf("WarnError with a TMP code: %+v", q)
+	}
+	if q := WarnError(4242, nil); q.Code != ERR_DEF_PROCESSED_WITH_ERROR {
+		t.Errorf("WarnError with an unknown code: %+v", q)
+	}
+}
+
+func TestQErrorFamilyPredicates(t *testing.T) {
+	cases := []struct {
+		name           string
+		err            error
+		def, tmp, warn bool
+	}{
+		{"def", DefError(ERR_DEF_INVALID_REQUEST, nil), true, false, false},
+		{"tmp", TmpError(ERR_TMP_RATE_LIMIT_EXCEEDED, nil), false, true, false},
+		{"warn", WarnError(ERR_WARN_ACCOUNT_LIMITATION, nil), false, false, true},
+		{"wrapped tmp", fmt.Errorf("date 2026-01-01: %w", TmpError(ERR_TMP_TIMEOUT, nil)), false, true, false},
+		{"unknown code", &QError{Code: 4242}, false, false, false},
+		{"foreign error", errors.New("x"), false, false, false},
+		{"nil", nil, false, false, false},
+	}
+	for _, c := range cases {
+		if got := IsDefinitive(c.err); got != c.def {
+			t.Errorf("%s: IsDefinitive = %v, want %v", c.name, got, c.def)
+		}
+		if got := IsTemporary(c.err); got != c.tmp {
+			t.Errorf("%s: IsTemporary = %v, want %v", c.name, got, c.tmp)
+		}
+		if got := IsWarning(c.err); got != c.warn {
+			t.Errorf("%s: IsWarning = %v, want %v", c.name, got, c.warn)
+		}
+	}
+}
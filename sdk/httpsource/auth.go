@@ -9,25 +9,34 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
 // authenticator porte l'état d'authentification d'une collecte : pour les modes
 // oauth2_*, le token obtenu est réutilisé sur toutes les pages plutôt que redemandé à
 // chaque requête (certains providers rate-limitent durement l'endpoint token).
+//
+// Partagé par les requêtes parallèles et les requêtes enfants : mu garantit qu'un
+// seul échange de token a lieu à la fois, les autres requêtes attendent son résultat.
 type authenticator struct {
 	spec     *Spec
 	vars     Vars
 	client   *http.Client
 	redactor *Redactor
 
+	// now : horloge des signatures (hmac, aws_sigv4, assertion JWT) et de
+	// l'expiration du token, celle de l'Engine dans Fetch.
+	now func() time.Time
+
+	mu          sync.Mutex
 	token       string
 	tokenExpiry time.Time
 }
 
 // #region newAuthenticator
 func newAuthenticator(spec *Spec, vars Vars, client *http.Client, redactor *Redactor) *authenticator {
-	return &authenticator{spec: spec, vars: vars, client: client, redactor: redactor}
+	return &authenticator{spec: spec, vars: vars, client: client, redactor: redactor, now: time.Now}
 }
 
 // #endregion
@@ -85,7 +94,13 @@ func (a *authenticator) apply(ctx context.Context, req *http.Request) error {
 		req.Header.Set("Authorization", "Basic "+encoded)
 		return nil
 
-	case AuthOAuth2ClientCredentials, AuthOAuth2Refresh:
+	case AuthHMAC:
+		return a.signHMAC(req)
+
+	case AuthAWSSigV4:
+		return a.signSigV4(req)
+
+	case AuthOAuth2ClientCredentials, AuthOAuth2Refresh, AuthOAuth2JWTBearer:
 		token, err := a.ensureToken(ctx)
 		if err != nil {
 			return err
@@ -110,7 +125,9 @@ func (a *authenticator) apply(ctx context.Context, req *http.Request) error {
 // l'arrivée de la requête chez le provider (un 401 en milieu de pagination, donc une
 // collecte partielle).
 func (a *authenticator) ensureToken(ctx context.Context) (string, error) {
-	if a.token != "" && time.Now().Add(30*time.Second).Before(a.tokenExpiry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && a.now().Add(30*time.Second).Before(a.tokenExpiry) {
 		return a.token, nil
 	}
 	return a.fetchToken(ctx)
@@ -121,7 +138,15 @@ func (a *authenticator) ensureToken(ctx context.Context) (string, error) {
 // #region invalidateToken
 // invalidateToken force le renouvellement au prochain appel. Utilisé sur 401 : un
 // token peut avoir été révoqué avant son expiration annoncée.
-func (a *authenticator) invalidateToken() {
+//
+// used est le token refusé : si une requête parallèle l'a déjà renouvelé, le token
+// courant est neuf et il ne faut pas le jeter.
+func (a *authenticator) invalidateToken(used string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != used {
+		return
+	}
 	a.token = ""
 	a.tokenExpiry = time.Time{}
 }
@@ -130,7 +155,11 @@ func (a *authenticator) invalidateToken() {
 
 // #region usesToken
 func (a *authenticator) usesToken() bool {
-	return a.spec.Auth.Mode == AuthOAuth2ClientCredentials || a.spec.Auth.Mode == AuthOAuth2Refresh
+	switch a.spec.Auth.Mode {
+	case AuthOAuth2ClientCredentials, AuthOAuth2Refresh, AuthOAuth2JWTBearer:
+		return true
+	}
+	return false
 }
 
 // #endregion
@@ -153,9 +182,19 @@ func (a *authenticator) fetchToken(ctx context.Context) (string, error) {
 	}
 
 	form := url.Values{}
-	if auth.Mode == AuthOAuth2ClientCredentials {
+	switch auth.Mode {
+	case AuthOAuth2ClientCredentials:
 		form.Set("grant_type", "client_credentials")
-	} else {
+	case AuthOAuth2JWTBearer:
+		// Assertion neuve à chaque échange : son iat/exp doivent encadrer l'instant
+		// de l'appel.
+		assertion, aErr := a.signAssertion(tokenURL)
+		if aErr != nil {
+			return "", aErr
+		}
+		form.Set("grant_type", jwtBearerGrantType)
+		form.Set("assertion", assertion)
+	default:
 		refreshToken, rErr := Render(auth.RefreshToken, a.vars)
 		if rErr != nil {
 			return "", rErr
@@ -169,11 +208,14 @@ func (a *authenticator) fetchToken(ctx context.Context) (string, error) {
 	if clientSecret != "" {
 		form.Set("client_secret", clientSecret)
 	}
-	if len(auth.Scopes) > 0 {
-		form.Set("scope", strings.Join(auth.Scopes, " "))
-	}
-	if auth.Audience != "" {
-		form.Set("audience", auth.Audience)
+	// En jwt bearer, scopes et audience sont déjà dans l'assertion signée.
+	if auth.Mode != AuthOAuth2JWTBearer {
+		if len(auth.Scopes) > 0 {
+			form.Set("scope", strings.Join(auth.Scopes, " "))
+		}
+		if auth.Audience != "" {
+			form.Set("audience", auth.Audience)
+		}
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(form.Encode()))
@@ -224,7 +266,7 @@ func (a *authenticator) fetchToken(ctx context.Context) (string, error) {
 	if parsed.ExpiresIn <= 0 {
 		ttl = time.Hour
 	}
-	a.tokenExpiry = time.Now().Add(ttl)
+	a.tokenExpiry = a.now().Add(ttl)
 
 	return a.token, nil
 }
@@ -0,0 +1,138 @@
+package httpsource
+
+import (
+	"context"
+	"fmt"
+)
+
+// Child décrit une requête ENFANT exécutée pour chaque ligne de la requête parente :
+// lister des campagnes, puis appeler leurs statistiques une par une.
+//
+// C'est une spec complète (source, pagination, retry, records, et même un child à
+// son tour), templatée en plus avec la ligne parente : {{parent.id}},
+// {{parent.<chemin>}}. Seules les lignes enfants sont émises ; records.inject de
+// l'enfant peut recopier les champs parents utiles ({{parent.name}}).
+//
+// Sans bloc auth, l'enfant reprend celle du parent et partage son token OAuth ; sans
+// bloc retry, sa politique de reprise. L'auth ne peut pas utiliser {{parent.*}}.
+type Child struct {
+	Spec
+
+	// MergeParent : ajoute la ligne parente entière à chaque ligne enfant, sous
+	// ParentKey (défaut `parent`). Un objet et non des champs à plat : pas de
+	// collision possible avec les colonnes de l'enfant, et le flatten de
+	// processor-v2 produit `data.parent.<champ>`.
+	MergeParent bool   `json:"mergeParent"`
+	ParentKey   string `json:"parentKey"`
+
+	// ParentIDPath : chemin de l'identifiant parent cité dans les erreurs (défaut
+	// `id`). Sans lui, "404 sur /stats" ne dit pas QUELLE campagne a échoué.
+	ParentIDPath string `json:"parentIdPath"`
+
+	// Concurrency : nombre de parents traités en parallèle (défaut 1). Les lignes
+	// restent émises dans l'ordre des parents : les lignes d'un enfant terminé en
+	// avance attendent en mémoire que les précédents soient émis.
+	Concurrency int `json:"concurrency"`
+
+	// inheritsAuth : pas de bloc auth, celui du parent a été recopié (applyDefaults).
+	inheritsAuth bool
+}
+
+// #region fetchChained
+// fetchChained parcourt le parent et exécute l'enfant pour chaque ligne. Les
+// statistiques renvoyées cumulent parent et enfants ; Rows compte les lignes
+// enfants, les seules émises.
+//
+// Une erreur d'enfant arrête la collecte, attribuée à son parent. Une erreur du
+// callback emit remonte telle quelle.
+func (e *Engine) fetchChained(ctx context.Context, spec *Spec, vars Vars, emit EmitFunc, auth *authenticator, redactor *Redactor) (Stats, error) {
+	child := spec.Child
+	childAuth := auth
+	if !child.inheritsAuth {
+		childAuth = newAuthenticator(&child.Spec, vars, auth.client, redactor)
+		childAuth.now = auth.now
+	}
+
+	parentSpec := *spec
+	parentSpec.Child = nil
+
+	if child.Concurrency > 1 {
+		return e.fetchChainedConcurrently(ctx, &parentSpec, child, vars, emit, auth, childAuth, redactor)
+	}
+
+	var children Stats
+	var emitErr error
+	stopped := false
+
+	parents, err := e.fetch(ctx, &parentSpec, vars, func(parent map[string]any) error {
+		childVars := vars
+		childVars.Parent = parent
+
+		var childEmit EmitFunc = func(row map[string]any) error {
+			if child.MergeParent {
+				row[child.ParentKey] = parent
+			}
+			if err := emit(row); err != nil {
+				if KindOf(err) == KindStopped {
+					stopped = true
+				}
+				emitErr = err
+				return err
+			}
+			return nil
+		}
+
+		s, err := e.fetchChild(ctx, child, childVars, childEmit, childAuth, redactor)
+		children.Pages += s.Pages
+		children.Rows += s.Rows
+		children.Attempts += s.Attempts
+		children.Waited += s.Waited
+
+		switch {
+		case stopped:
+			return ErrStop
+		case err == nil:
+			return nil
+		case err == emitErr:
+			return err
+		default:
+			return attributeToParent(err, parent, child.ParentIDPath)
+		}
+	}, auth, redactor)
+
+	stats := Stats{
+		Pages:    parents.Pages + children.Pages,
+		Rows:     children.Rows,
+		Attempts: parents.Attempts + children.Attempts,
+		Waited:   parents.Waited + children.Waited,
+	}
+	return stats, err
+}
+
+// #endregion
+
+// #region fetchChild
+func (e *Engine) fetchChild(ctx context.Context, child *Child, vars Vars, emit EmitFunc, auth *authenticator, redactor *Redactor) (Stats, error) {
+	if child.Child != nil {
+		return e.fetchChained(ctx, &child.Spec, vars, emit, auth, redactor)
+	}
+	return e.fetch(ctx, &child.Spec, vars, emit, auth, redactor)
+}
+
+// #endregion
+
+// #region attributeToParent
+func attributeToParent(err error, parent map[string]any, idPath string) error {
+	id := "?"
+	if v, ok := navigateOptional(parent, idPath); ok {
+		id = stringify(v)
+	}
+	e, ok := err.(*Error)
+	if !ok {
+		return fmt.Errorf("child request for parent %s=%s: %w", idPath, id, err)
+	}
+	return &Error{Kind: e.Kind, Status: e.Status, Cause: e.Cause,
+		Message: fmt.Sprintf("child request for parent %s=%s: %s", idPath, id, e.Message)}
+}
+
+// #endregion
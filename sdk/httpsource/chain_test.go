@@ -0,0 +1,177 @@
+package httpsource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// #region TestFetch_ChildPerParentRecord
+func TestFetch_ChildPerParentRecord(t *testing.T) {
+	tokenCalls := 0
+	var statsCalls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			tokenCalls++
+			fmt.Fprint(w, `{"access_token":"AT","expires_in":3600}`)
+		case r.Header.Get("Authorization") != "Bearer AT":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/campaigns" && r.URL.Query().Get("page") == "1":
+			fmt.Fprint(w, `{"data":[{"id":"c1","name":"Soldes"},{"id":"c2","name":"Rentrée"}]}`)
+		case r.URL.Path == "/campaigns":
+			fmt.Fprint(w, `{"data":[{"id":"c3","name":"Noël"}]}`)
+		default:
+			id := strings.Split(r.URL.Path, "/")[2]
+			statsCalls = append(statsCalls, id+"@"+r.URL.Query().Get("cursor"))
+			if r.URL.Query().Get("cursor") == "" {
+				fmt.Fprintf(w, `{"rows":[{"day":"2026-08-11","clicks":1}],"next":"n-%s"}`, id)
+				return
+			}
+			fmt.Fprint(w, `{"rows":[{"day":"2026-08-12","clicks":2}],"next":null}`)
+		}
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source": map[string]any{"url": srv.URL + "/campaigns"},
+		"auth": map[string]any{
+			"mode": AuthOAuth2ClientCredentials, "tokenUrl": srv.URL + "/token",
+			"clientId": "cid", "clientSecret": "secret",
+		},
+		"pagination": map[string]any{"type": "page", "size": 2},
+		"records":    map[string]any{"path": "data"},
+		"child": map[string]any{
+			"source":      map[string]any{"url": srv.URL + "/campaigns/{{parent.id}}/stats"},
+			"pagination":  map[string]any{"type": "cursor", "cursorPath": "next"},
+			"records":     map[string]any{"path": "rows", "inject": map[string]any{"campaign": "{{parent.name}}"}},
+			"mergeParent": true,
+		},
+	})
+	rows, stats := collect(t, fastEngine(nil), spec, Vars{})
+
+	// 3 campagnes × 2 pages de stats.
+	if len(rows) != 6 || stats.Rows != 6 {
+		t.Fatalf("rows=%d stats=%+v", len(rows), stats)
+	}
+	if got := strings.Join(statsCalls, ","); got != "c1@,c1@n-c1,c2@,c2@n-c2,c3@,c3@n-c3" {
+		t.Errorf("child calls: %s", got)
+	}
+	last := rows[5]
+	if last["campaign"] != "Noël" || last["clicks"] != float64(2) || last["parent"].(map[string]any)["id"] != "c3" {
+		t.Errorf("merged row: %v", last)
+	}
+	// 2 pages parent + 6 pages enfant.
+	if stats.Pages != 8 {
+		t.Errorf("pages: %d", stats.Pages)
+	}
+	// L'enfant hérite de l'auth du parent ET de son token.
+	if tokenCalls != 1 {
+		t.Errorf("token calls: got %d, want 1", tokenCalls)
+	}
+}
+
+// #endregion
+
+// #region TestFetch_ChildErrorNamesTheParent
+func TestFetch_ChildErrorNamesTheParent(t *testing.T) {
+	var statsCalls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/campaigns" {
+			fmt.Fprint(w, `[{"id":"c1"},{"id":"c2"},{"id":"c3"}]`)
+			return
+		}
+		id := strings.Split(r.URL.Path, "/")[2]
+		statsCalls = append(statsCalls, id)
+		if id == "c2" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"campaign archived"}`)
+			return
+		}
+		fmt.Fprint(w, `[{"clicks":1}]`)
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source": map[string]any{"url": srv.URL + "/campaigns"},
+		"child":  map[string]any{"source": map[string]any{"url": srv.URL + "/campaigns/{{parent.id}}/stats"}},
+	})
+	_, err := fastEngine(nil).Fetch(context.Background(), spec, Vars{}, func(map[string]any) error { return nil })
+
+	var e *Error
+	if err == nil || !strings.Contains(err.Error(), "parent id=c2") {
+		t.Fatalf("error should name the parent, got %v", err)
+	}
+	if e, _ = err.(*Error); e == nil || e.Status != http.StatusNotFound {
+		t.Errorf("kind and status must be kept: %#v", err)
+	}
+	if strings.Join(statsCalls, ",") != "c1,c2" {
+		t.Errorf("the chain must stop at the failing parent: %v", statsCalls)
+	}
+}
+
+// #endregion
+
+// #region TestFetch_ChildStopAndEmitErrors
+func TestFetch_ChildStopAndEmitErrors(t *testing.T) {
+	childCalls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/campaigns" {
+			fmt.Fprint(w, `[{"id":"c1"},{"id":"c2"}]`)
+			return
+		}
+		childCalls++
+		fmt.Fprint(w, `[{"clicks":1},{"clicks":2}]`)
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source": map[string]any{"url": srv.URL + "/campaigns"},
+		"child":  map[string]any{"source": map[string]any{"url": srv.URL + "/campaigns/{{parent.id}}/stats"}},
+	})
+
+	stats, err := fastEngine(nil).Fetch(context.Background(), spec, Vars{}, func(map[string]any) error { return ErrStop })
+	if err != nil || childCalls != 1 || stats.Pages != 2 {
+		t.Fatalf("ErrStop must stop the whole chain: err=%v calls=%d stats=%+v", err, childCalls, stats)
+	}
+
+	boom := fmt.Errorf("upsert refused")
+	_, err = fastEngine(nil).Fetch(context.Background(), spec, Vars{}, func(map[string]any) error { return boom })
+	if err != boom {
+		t.Fatalf("an emit error must come back untouched, got %v", err)
+	}
+}
+
+// #endregion
+
+// #region TestValidate_Child
+func TestValidate_Child(t *testing.T) {
+	_, err := ParseSpec(map[string]any{
+		"source": map[string]any{"url": "https://x.com/campaigns"},
+		"child":  map[string]any{"records": map[string]any{"path": "rows"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "child.source.url is required") || KindOf(err) != KindInvalidSpec {
+		t.Fatalf("got %v", err)
+	}
+
+	spec := mustSpec(t, map[string]any{
+		"source": map[string]any{"url": "https://x.com/campaigns", "timeoutSeconds": 10},
+		"auth":   map[string]any{"mode": "bearer", "value": "{{credentials.token}}"},
+		"retry":  map[string]any{"maxAttempts": 2},
+		"child": map[string]any{
+			"source": map[string]any{"url": "https://x.com/campaigns/{{parent.id}}", "headers": map[string]any{"X-Key": "{{credentials.key}}"}},
+		},
+	})
+	c := spec.Child
+	if c.Auth.Mode != AuthBearer || c.Retry.MaxAttempts != 2 || c.Source.TimeoutSeconds != 10 || c.ParentIDPath != "id" {
+		t.Errorf("child defaults: %+v", c)
+	}
+	if got := spec.SecretTemplates(); len(got) != 3 {
+		t.Errorf("child secrets must be redacted too: %v", got)
+	}
+}
+
+// #endregion
@@ -0,0 +1,200 @@
+package httpsource
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Valeurs de records.compression. Vide = corps lu tel quel.
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZip  = "zip"
+	// CompressionAuto : décidé à chaque réponse par les premiers octets, puis le
+	// Content-Type. Un corps qui n'est ni gzip ni zip est lu tel quel.
+	CompressionAuto = "auto"
+)
+
+// ZipOptions : sélection des fichiers d'une archive zip.
+//
+// Entries est un motif glob (`*.csv`, `reports/*.csv`). Sans `/`, il porte sur le
+// nom du fichier seul, quel que soit son dossier dans l'archive ; avec `/`, sur le
+// chemin complet. Vide = tous les fichiers. Les fichiers retenus sont lus dans l'ordre
+// de l'archive et leurs lignes concaténées ; le nom de chacun est disponible dans
+// records.inject via {{entry}}.
+type ZipOptions struct {
+	Entries string `json:"entries"`
+}
+
+// maxUnpackedBytes borne le volume décompressé d'une réponse : une archive corrompue
+// ou malveillante ne doit pas épuiser la mémoire du pod.
+const maxUnpackedBytes = 1 << 30
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// bodyEntry est un document à parser : le corps entier, ou un fichier d'archive.
+type bodyEntry struct {
+	Name string
+	Body []byte
+}
+
+// #region unpackBody
+// unpackBody applique records.compression au corps reçu. Toujours au moins une entrée
+// en cas de succès.
+//
+// uncompressed : le transport HTTP a déjà retiré un Content-Encoding gzip (il le fait
+// seul quand il a négocié l'encodage) — il n'y a alors plus rien à décompresser.
+func unpackBody(body []byte, header http.Header, uncompressed bool, rec *Records) ([]bodyEntry, error) {
+	mode := rec.Compression
+	if mode == CompressionAuto {
+		mode = detectCompression(body, header)
+	}
+
+	switch mode {
+	case CompressionGzip:
+		if uncompressed && !bytes.HasPrefix(body, gzipMagic) {
+			return []bodyEntry{{Body: body}}, nil
+		}
+		return gunzip(body)
+	case CompressionZip:
+		var entries string
+		if rec.Zip != nil {
+			entries = rec.Zip.Entries
+		}
+		return unzip(body, entries)
+	default:
+		return []bodyEntry{{Body: body}}, nil
+	}
+}
+
+// #endregion
+
+// #region detectCompression
+func detectCompression(body []byte, header http.Header) string {
+	switch {
+	case bytes.HasPrefix(body, gzipMagic):
+		return CompressionGzip
+	case bytes.HasPrefix(body, zipMagic):
+		return CompressionZip
+	}
+
+	// Sans signature reconnue, un Content-Encoding gzip veut dire que le transport a
+	// déjà décodé le corps. Un Content-Type d'archive, lui, annonce un fichier : s'il
+	// n'en a pas la signature, mieux vaut l'erreur du décompresseur qu'un parse de
+	// données binaires.
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	switch {
+	case strings.Contains(contentType, "gzip"):
+		return CompressionGzip
+	case strings.Contains(contentType, "zip"):
+		return CompressionZip
+	}
+	return CompressionNone
+}
+
+// #endregion
+
+// #region gunzip
+// gunzip décompresse un corps gzip. Le nom d'origine stocké dans l'en-tête gzip
+// (`report.csv` d'un `report.csv.gz`), s'il existe, sert de nom d'entrée.
+func gunzip(body []byte) ([]bodyEntry, error) {
+	if !bytes.HasPrefix(body, gzipMagic) {
+		return nil, newErr(KindInvalidData, 0, nil,
+			"records.compression is gzip but the response is not gzip (first bytes: %q)", truncate(string(body), 120))
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, newErr(KindInvalidData, 0, err, "response is not valid gzip")
+	}
+	defer reader.Close()
+
+	unpacked, err := readBounded(reader)
+	if err != nil {
+		return nil, newErr(KindInvalidData, 0, err, "cannot decompress the gzip response")
+	}
+	return []bodyEntry{{Name: reader.Name, Body: unpacked}}, nil
+}
+
+// #endregion
+
+// #region unzip
+// unzip lit les fichiers d'une archive qui correspondent au motif. Aucun fichier
+// retenu est une erreur qui liste le contenu de l'archive : c'est presque toujours
+// un motif faux (ou un rapport dont le fichier a été renommé), pas une journée vide.
+func unzip(body []byte, pattern string) ([]bodyEntry, error) {
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, newErr(KindInvalidData, 0, err, "response is not a valid zip archive (first bytes: %q)", truncate(string(body), 120))
+	}
+
+	var entries []bodyEntry
+	var names []string
+	total := 0
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		names = append(names, f.Name)
+		if !matchEntry(pattern, f.Name) {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, newErr(KindInvalidData, 0, err, "zip entry %q cannot be opened", f.Name)
+		}
+		content, err := readBounded(rc)
+		rc.Close()
+		if err != nil {
+			return nil, newErr(KindInvalidData, 0, err, "zip entry %q cannot be decompressed", f.Name)
+		}
+		if total += len(content); total > maxUnpackedBytes {
+			return nil, newErr(KindInvalidData, 0, nil, "zip archive exceeds %d bytes once decompressed", maxUnpackedBytes)
+		}
+		entries = append(entries, bodyEntry{Name: f.Name, Body: content})
+	}
+
+	if len(entries) == 0 {
+		return nil, newErr(KindInvalidData, 0, nil, "zip archive has no entry matching %q (entries: %s)",
+			pattern, truncate(strings.Join(names, ", "), 300))
+	}
+	return entries, nil
+}
+
+// #endregion
+
+// #region matchEntry
+func matchEntry(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(name)
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// #endregion
+
+// #region readBounded
+func readBounded(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxUnpackedBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxUnpackedBytes {
+		return nil, newErr(KindInvalidData, 0, nil, "response exceeds %d bytes once decompressed", maxUnpackedBytes)
+	}
+	return content, nil
+}
+
+// #endregion
@@ -0,0 +1,194 @@
+package httpsource
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipBytes(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Name = name
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipBytes(t *testing.T, files ...[2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, f := range files {
+		fw, err := w.Create(f[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(f[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// #region TestFetch_GzipCSV
+// Téléchargement `.csv.gz` servi en application/gzip, sans Content-Encoding : le
+// transport HTTP n'y touche pas, c'est au moteur de décompresser.
+func TestFetch_GzipCSV(t *testing.T) {
+	payload := gzipBytes(t, "sales.csv", "sku,qty\nA,1\nB,2\n")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Write(payload)
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source":  map[string]any{"url": srv.URL},
+		"records": map[string]any{"format": "csv", "compression": "gzip", "inject": map[string]any{"file": "{{entry}}"}},
+	})
+
+	rows, _ := collect(t, fastEngine(nil), spec, Vars{})
+	if len(rows) != 2 || rows[1]["sku"] != "B" || rows[1]["file"] != "sales.csv" {
+		t.Fatalf("rows: %v", rows)
+	}
+}
+
+// #endregion
+
+// #region TestFetch_GzipInvalidJSONQuotesTheDocument
+// L'erreur cite le document décompressé qui n'a pas pu être lu, pas l'archive.
+func TestFetch_GzipInvalidJSONQuotesTheDocument(t *testing.T) {
+	payload := gzipBytes(t, "sales.json", `{"data": [oops`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(payload)
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source":  map[string]any{"url": srv.URL},
+		"records": map[string]any{"path": "data", "compression": "gzip"},
+	})
+
+	_, err := fastEngine(nil).Fetch(context.Background(), spec, Vars{}, func(map[string]any) error { return nil })
+	if KindOf(err) != KindInvalidData || !strings.Contains(err.Error(), `(first bytes: "{\"data\": [oops")`) {
+		t.Fatalf("got %v", err)
+	}
+}
+
+// #endregion
+
+// #region TestFetch_ZipEntriesAreFilteredAndConcatenated
+func TestFetch_ZipEntriesAreFilteredAndConcatenated(t *testing.T) {
+	payload := zipBytes(t,
+		[2]string{"export/2026-08-12/fr.csv", "sku,qty\nA,1\n"},
+		[2]string{"export/2026-08-12/README.txt", "not a csv"},
+		[2]string{"export/2026-08-12/de.csv", "sku,qty\nB,2\nC,3\n"},
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(payload)
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source": map[string]any{"url": srv.URL},
+		"records": map[string]any{
+			"format":      "csv",
+			"compression": "auto",
+			"zip":         map[string]any{"entries": "*.csv"},
+			"inject":      map[string]any{"file": "{{entry}}"},
+		},
+	})
+
+	rows, _ := collect(t, fastEngine(nil), spec, Vars{})
+	if len(rows) != 3 {
+		t.Fatalf("rows: %v", rows)
+	}
+	if rows[0]["file"] != "export/2026-08-12/fr.csv" || rows[2]["sku"] != "C" || rows[2]["file"] != "export/2026-08-12/de.csv" {
+		t.Errorf("rows must keep the archive order and carry their entry name: %v", rows)
+	}
+}
+
+// #endregion
+
+// #region TestUnpackBody
+func TestUnpackBody(t *testing.T) {
+	csvHeader := http.Header{"Content-Type": {"text/csv"}}
+
+	t.Run("auto leaves a plain body alone", func(t *testing.T) {
+		got, err := unpackBody([]byte(`{"data":[]}`), http.Header{"Content-Type": {"application/json"}}, false, &Records{Compression: CompressionAuto})
+		if err != nil || len(got) != 1 || string(got[0].Body) != `{"data":[]}` {
+			t.Fatalf("got %v, %v", got, err)
+		}
+	})
+
+	t.Run("auto detects gzip from magic bytes", func(t *testing.T) {
+		got, err := unpackBody(gzipBytes(t, "", "a\n1\n"), csvHeader, false, &Records{Compression: CompressionAuto})
+		if err != nil || string(got[0].Body) != "a\n1\n" {
+			t.Fatalf("got %v, %v", got, err)
+		}
+	})
+
+	t.Run("gzip already decoded by the transport", func(t *testing.T) {
+		got, err := unpackBody([]byte("a\n1\n"), csvHeader, true, &Records{Compression: CompressionGzip})
+		if err != nil || string(got[0].Body) != "a\n1\n" {
+			t.Fatalf("got %v, %v", got, err)
+		}
+	})
+
+	t.Run("gzip expected but plain body", func(t *testing.T) {
+		_, err := unpackBody([]byte("a\n1\n"), csvHeader, false, &Records{Compression: CompressionGzip})
+		if KindOf(err) != KindInvalidData {
+			t.Fatalf("want invalid_data, got %v", err)
+		}
+	})
+
+	t.Run("announced zip that is not one", func(t *testing.T) {
+		_, err := unpackBody([]byte("<html>maintenance</html>"), http.Header{"Content-Type": {"application/zip"}}, false, &Records{Compression: CompressionAuto})
+		if KindOf(err) != KindInvalidData || !strings.Contains(err.Error(), "zip") {
+			t.Fatalf("want a zip invalid_data error, got %v", err)
+		}
+	})
+
+	t.Run("no matching entry lists the archive", func(t *testing.T) {
+		_, err := unpackBody(zipBytes(t, [2]string{"report.xlsx", "x"}), nil, false,
+			&Records{Compression: CompressionZip, Zip: &ZipOptions{Entries: "*.csv"}})
+		if KindOf(err) != KindInvalidData || !strings.Contains(err.Error(), "report.xlsx") {
+			t.Fatalf("got %v", err)
+		}
+	})
+}
+
+// #endregion
+
+// #region TestValidate_Compression
+func TestValidate_Compression(t *testing.T) {
+	base := func(records map[string]any) map[string]any {
+		return map[string]any{"source": map[string]any{"url": "https://x.com"}, "records": records}
+	}
+
+	if _, err := ParseSpec(base(map[string]any{"compression": "GZIP"})); err != nil {
+		t.Errorf("gzip should be accepted case-insensitively: %v", err)
+	}
+	if _, err := ParseSpec(base(map[string]any{"compression": "brotli"})); err == nil || !strings.Contains(err.Error(), "records.compression") {
+		t.Errorf("brotli should be rejected, got %v", err)
+	}
+	if _, err := ParseSpec(base(map[string]any{"compression": "zip", "zip": map[string]any{"entries": "[a-"}})); err == nil || !strings.Contains(err.Error(), "records.zip.entries") {
+		t.Errorf("a malformed glob should be rejected, got %v", err)
+	}
+}
+
+// #endregion
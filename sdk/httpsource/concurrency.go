@@ -0,0 +1,205 @@
+package httpsource
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// #region fetchPagesConcurrently
+// fetchPagesConcurrently est la boucle de pages de fetch avec Pagination.Concurrency
+// requêtes en vol. Les pages sont lues DANS L'ORDRE : la page k est émise, puis sert
+// à décider s'il y a une suite, avant de regarder la page k+1. Le résultat est donc
+// identique à la boucle séquentielle, fin de pagination et maxPages compris ; les
+// pages demandées par anticipation au-delà de la dernière sont annulées et ignorées.
+//
+// Toute sortie (erreur, ErrStop, fin) annule les requêtes en vol et attend leurs
+// goroutines : rien ne survit à Fetch.
+func (e *Engine) fetchPagesConcurrently(
+	ctx context.Context,
+	client *http.Client,
+	spec *Spec,
+	vars Vars,
+	emit EmitFunc,
+	auth *authenticator,
+	redactor *Redactor,
+	stats Stats,
+) (Stats, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	type pageResult struct {
+		rows     []map[string]any
+		parsed   any
+		header   http.Header
+		attempts int
+		waited   time.Duration
+		err      error
+	}
+
+	launch := func(k int) chan pageResult {
+		// Tampon de 1 : une page dont personne n'attend plus le résultat ne bloque pas
+		// sa goroutine.
+		ch := make(chan pageResult, 1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			page, attempts, waited, err := e.fetchPage(ctx, client, spec, vars, auth, pagerAt(&spec.Pagination, k), redactor)
+			r := pageResult{attempts: attempts, waited: waited, err: err}
+			if err == nil {
+				r.parsed, r.header = page.parsed, page.header
+				if r.rows, err = page.rows(spec, vars); err != nil {
+					r.err = redactor.Err(err)
+				}
+			}
+			ch <- r
+		}()
+		return ch
+	}
+
+	maxPages := spec.Pagination.MaxPages
+	var inFlight []chan pageResult
+	next := 0
+	for ; next < spec.Pagination.Concurrency && next < maxPages; next++ {
+		inFlight = append(inFlight, launch(next))
+	}
+
+	tracker := newPaginator(&spec.Pagination)
+	for len(inFlight) > 0 {
+		r := <-inFlight[0]
+		inFlight = inFlight[1:]
+		stats.Attempts += r.attempts
+		stats.Waited += r.waited
+		if r.err != nil {
+			return stats, r.err
+		}
+		stats.Pages++
+
+		if stop, err := emitRows(r.rows, emit, &stats); stop || err != nil {
+			return stats, err
+		}
+		if !tracker.advance(r.parsed, len(r.rows), r.header) {
+			e.logDone(stats)
+			return stats, nil
+		}
+		if next < maxPages {
+			inFlight = append(inFlight, launch(next))
+			next++
+		}
+	}
+
+	e.logger.Warnf("httpsource: stopped at the %d-page safety cap (pagination.maxPages) — data may be incomplete", maxPages)
+	e.logDone(stats)
+	return stats, nil
+}
+
+// #endregion
+
+// #region fetchChainedConcurrently
+// fetchChainedConcurrently est fetchChained avec Child.Concurrency enfants en vol.
+// Chaque enfant accumule ses lignes ; elles sont émises dans l'ordre des parents,
+// depuis la goroutine du parent : emit n'est jamais appelé en parallèle.
+//
+// La mémoire est donc bornée par les lignes de Concurrency enfants, pas par le
+// total. Première erreur (dans l'ordre des parents) ou ErrStop : les enfants en vol
+// sont annulés et attendus.
+func (e *Engine) fetchChainedConcurrently(
+	ctx context.Context,
+	parentSpec *Spec,
+	child *Child,
+	vars Vars,
+	emit EmitFunc,
+	auth, childAuth *authenticator,
+	redactor *Redactor,
+) (Stats, error) {
+	childCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	type childResult struct {
+		parent map[string]any
+		rows   []map[string]any
+		stats  Stats
+		err    error
+	}
+
+	launch := func(parent map[string]any) chan childResult {
+		ch := make(chan childResult, 1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			childVars := vars
+			childVars.Parent = parent
+			r := childResult{parent: parent}
+			r.stats, r.err = e.fetchChild(childCtx, child, childVars, func(row map[string]any) error {
+				if child.MergeParent {
+					row[child.ParentKey] = parent
+				}
+				r.rows = append(r.rows, row)
+				return nil
+			}, childAuth, redactor)
+			ch <- r
+		}()
+		return ch
+	}
+
+	var children Stats
+	var queue []chan childResult
+	stopped := false
+
+	// flush émet les lignes de l'enfant le plus ancien.
+	flush := func() error {
+		r := <-queue[0]
+		queue = queue[1:]
+		children.Pages += r.stats.Pages
+		children.Attempts += r.stats.Attempts
+		children.Waited += r.stats.Waited
+		if r.err != nil {
+			return attributeToParent(r.err, r.parent, child.ParentIDPath)
+		}
+		for _, row := range r.rows {
+			if err := emit(row); err != nil {
+				if KindOf(err) == KindStopped {
+					stopped = true
+				}
+				return err
+			}
+			children.Rows++
+		}
+		return nil
+	}
+
+	parents, err := e.fetch(ctx, parentSpec, vars, func(parent map[string]any) error {
+		if len(queue) == child.Concurrency {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		queue = append(queue, launch(parent))
+		return nil
+	}, auth, redactor)
+	for err == nil && !stopped && len(queue) > 0 {
+		err = flush()
+	}
+	if stopped {
+		err = nil
+	}
+
+	stats := Stats{
+		Pages:    parents.Pages + children.Pages,
+		Rows:     children.Rows,
+		Attempts: parents.Attempts + children.Attempts,
+		Waited:   parents.Waited + children.Waited,
+	}
+	return stats, err
+}
+
+// #endregion
@@ -0,0 +1,242 @@
+package httpsource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// inFlightCounter mesure le nombre maximal de requêtes simultanées vues par le
+// serveur.
+type inFlightCounter struct {
+	current, max int32
+}
+
+func (c *inFlightCounter) enter() func() {
+	n := atomic.AddInt32(&c.current, 1)
+	for {
+		m := atomic.LoadInt32(&c.max)
+		if n <= m || atomic.CompareAndSwapInt32(&c.max, m, n) {
+			break
+		}
+	}
+	return func() { atomic.AddInt32(&c.current, -1) }
+}
+
+// #region TestFetch_ConcurrentPagesKeepOrder
+func TestFetch_ConcurrentPagesKeepOrder(t *testing.T) {
+	counter := &inFlightCounter{}
+	var requested sync.Map
+	tokenCalls := int32(0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if atomic.AddInt32(&tokenCalls, 1) > 1 {
+				t.Errorf("the OAuth token must be fetched once for all parallel pages")
+			}
+			time.Sleep(20 * time.Millisecond)
+			fmt.Fprint(w, `{"access_token":"AT","expires_in":3600}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer AT" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		defer counter.enter()()
+
+		// 7 lignes par pages de 2. Les premières pages répondent le plus lentement :
+		// l'ordre d'arrivée est l'inverse de l'ordre voulu.
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		requested.Store(page, true)
+		time.Sleep(time.Duration(10-page) * 3 * time.Millisecond)
+		var items []string
+		for i := (page-1)*2 + 1; i <= page*2 && i <= 7; i++ {
+			items = append(items, fmt.Sprintf(`{"n":%d}`, i))
+		}
+		fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(items, ","))
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source": map[string]any{"url": srv.URL + "/items"},
+		"auth": map[string]any{
+			"mode": AuthOAuth2ClientCredentials, "tokenUrl": srv.URL + "/token",
+			"clientId": "cid", "clientSecret": "secret",
+		},
+		"pagination": map[string]any{"type": "page", "size": 2, "startAt": 1, "concurrency": 3},
+		"records":    map[string]any{"path": "data"},
+	})
+	rows, stats := collect(t, fastEngine(nil), spec, Vars{})
+
+	if len(rows) != 7 || stats.Pages != 4 {
+		t.Fatalf("rows=%d stats=%+v", len(rows), stats)
+	}
+	for i, row := range rows {
+		if row["n"] != float64(i+1) {
+			t.Fatalf("row %d out of order: %v", i, row)
+		}
+	}
+	if counter.max < 2 || counter.max > 3 {
+		t.Errorf("max requests in flight: got %d, want 2..3", counter.max)
+	}
+	// Anticipation bornée : au plus concurrency-1 pages au-delà de la dernière.
+	requested.Range(func(k, _ any) bool {
+		if k.(int) > 6 {
+			t.Errorf("page %d requested beyond the prefetch window", k)
+		}
+		return true
+	})
+}
+
+// #endregion
+
+// #region TestFetch_ConcurrentPagesRespectMaxPages
+func TestFetch_ConcurrentPagesRespectMaxPages(t *testing.T) {
+	var requested sync.Map
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		requested.Store(page, true)
+		fmt.Fprintf(w, `[{"n":%d},{"n":%d}]`, page*2-1, page*2)
+	}))
+	defer srv.Close()
+
+	rows, stats := collect(t, fastEngine(nil), mustSpec(t, map[string]any{
+		"source":     map[string]any{"url": srv.URL},
+		"pagination": map[string]any{"type": "page", "size": 2, "startAt": 1, "concurrency": 3, "maxPages": 2},
+	}), Vars{})
+	if len(rows) != 4 || stats.Pages != 2 {
+		t.Fatalf("rows=%d stats=%+v", len(rows), stats)
+	}
+	requested.Range(func(k, _ any) bool {
+		if k.(int) > 2 {
+			t.Errorf("page %d requested beyond maxPages", k)
+		}
+		return true
+	})
+}
+
+// #endregion
+
+// #region TestFetch_ConcurrentPagesStopCleanly
+func TestFetch_ConcurrentPagesStopCleanly(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		// Les pages suivantes répondent avant : la 400 de la page 3 n'est rendue
+		// qu'une fois les pages 1 et 2 émises.
+		time.Sleep(time.Duration(10-page) * 3 * time.Millisecond)
+		if page == 3 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"bad page"}`)
+			return
+		}
+		fmt.Fprintf(w, `[{"n":%d},{"n":%d}]`, page*2-1, page*2)
+	}))
+	defer srv.Close()
+	spec := mustSpec(t, map[string]any{
+		"source":     map[string]any{"url": srv.URL},
+		"pagination": map[string]any{"type": "page", "size": 2, "startAt": 1, "concurrency": 3},
+	})
+
+	t.Run("fatal error", func(t *testing.T) {
+		var rows []map[string]any
+		_, err := fastEngine(nil).Fetch(context.Background(), spec, Vars{}, func(row map[string]any) error {
+			rows = append(rows, row)
+			return nil
+		})
+		if KindOf(err) != KindInvalidSpec || len(rows) != 4 {
+			t.Fatalf("pages before the failing one must be emitted, then the 400 returned: rows=%d err=%v", len(rows), err)
+		}
+	})
+
+	t.Run("ErrStop", func(t *testing.T) {
+		calls := 0
+		_, err := fastEngine(nil).Fetch(context.Background(), spec, Vars{}, func(map[string]any) error {
+			calls++
+			return ErrStop
+		})
+		if err != nil || calls != 1 {
+			t.Fatalf("calls=%d err=%v", calls, err)
+		}
+	})
+}
+
+// #endregion
+
+// #region TestFetch_ConcurrentChildrenKeepParentOrder
+func TestFetch_ConcurrentChildrenKeepParentOrder(t *testing.T) {
+	counter := &inFlightCounter{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/campaigns" {
+			fmt.Fprint(w, `{"data":[{"id":1},{"id":2},{"id":3},{"id":4},{"id":5}]}`)
+			return
+		}
+		defer counter.enter()()
+		id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/stats/"))
+		time.Sleep(time.Duration(6-id) * 4 * time.Millisecond)
+		if id == 4 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"rows":[{"v":"%d-a"},{"v":"%d-b"}]}`, id, id)
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source":  map[string]any{"url": srv.URL + "/campaigns"},
+		"records": map[string]any{"path": "data"},
+		"child": map[string]any{
+			"source":      map[string]any{"url": srv.URL + "/stats/{{parent.id}}"},
+			"records":     map[string]any{"path": "rows"},
+			"concurrency": 2,
+		},
+	})
+
+	var got []string
+	_, err := fastEngine(nil).Fetch(context.Background(), spec, Vars{}, func(row map[string]any) error {
+		got = append(got, row["v"].(string))
+		return nil
+	})
+
+	if strings.Join(got, ",") != "1-a,1-b,2-a,2-b,3-a,3-b" {
+		t.Errorf("rows must follow the parent order up to the failing parent: %v", got)
+	}
+	if err == nil || !strings.Contains(err.Error(), "parent id=4") {
+		t.Errorf("the failure must name parent 4: %v", err)
+	}
+	if counter.max != 2 {
+		t.Errorf("max children in flight: got %d, want 2", counter.max)
+	}
+}
+
+// #endregion
+
+// #region TestValidate_Concurrency
+func TestValidate_Concurrency(t *testing.T) {
+	_, err := ParseSpec(map[string]any{
+		"source":     map[string]any{"url": "https://x.com"},
+		"pagination": map[string]any{"type": "cursor", "cursorPath": "next", "concurrency": 4},
+	})
+	if err == nil || !strings.Contains(err.Error(), "pagination.concurrency") {
+		t.Errorf("cursor pagination cannot be parallel: %v", err)
+	}
+
+	_, err = ParseSpec(map[string]any{
+		"source":     map[string]any{"url": "https://x.com"},
+		"pagination": map[string]any{"type": "offset", "size": 100, "concurrency": 64},
+	})
+	if err == nil || !strings.Contains(err.Error(), "between 1 and") {
+		t.Errorf("concurrency must be capped: %v", err)
+	}
+
+	if p := pagerAt(&Pagination{Type: PageOffset, Size: 50}, 3); p.offset != 150 {
+		t.Errorf("offset of the 4th page: %d", p.offset)
+	}
+}
+
+// #endregion
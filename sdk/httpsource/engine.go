@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,12 +26,18 @@ type nopLogger struct{}
 func (nopLogger) Infof(string, ...any) {}
 func (nopLogger) Warnf(string, ...any) {}
 
-// Engine exécute une Spec. Sans état entre deux Fetch : réutilisable et sûr à garder
-// en variable de package dans un proc.
+// Engine exécute une Spec. Réutilisable et sûr à garder en variable de package dans
+// un proc. Seul état partagé entre deux Fetch : les limiteurs de débit par hôte
+// (cf ratelimit.go), précisément pour que des collectes parallèles vers une même
+// API se partagent son quota.
 type Engine struct {
 	client *http.Client
 	logger Logger
 	sleep  func(context.Context, time.Duration) error
+	now    func() time.Time
+
+	limitersMu sync.Mutex
+	limiters   map[string]*hostLimiter
 }
 
 // Option configure l'Engine.
@@ -76,6 +84,7 @@ func New(opts ...Option) *Engine {
 	e := &Engine{
 		logger: nopLogger{},
 		sleep:  realSleep,
+		now:    time.Now,
 		client: &http.Client{
 			Transport: &http.Transport{
 				Proxy: http.ProxyFromEnvironment,
@@ -141,18 +150,63 @@ func (e *Engine) Fetch(ctx context.Context, spec *Spec, vars Vars, emit EmitFunc
 	}
 
 	redactor := NewRedactor(vars.Credentials)
-	auth := newAuthenticator(spec, vars, e.client, redactor)
-	pager := newPaginator(&spec.Pagination)
 
 	// Timeout par requête et non global : sur une pagination longue, un timeout global
 	// couperait au milieu et laisserait la date à moitié chargée.
 	client := *e.client
 	client.Timeout = time.Duration(spec.Source.TimeoutSeconds) * time.Second
-	auth.client = &client
+	auth := newAuthenticator(spec, vars, &client, redactor)
+	auth.now = e.now
+
+	if spec.Window != nil {
+		return e.fetchWindows(ctx, spec, vars, emit, auth, redactor)
+	}
+	return e.fetchSpec(ctx, spec, vars, emit, auth, redactor)
+}
+
+// #endregion
+
+// #region fetchSpec
+func (e *Engine) fetchSpec(ctx context.Context, spec *Spec, vars Vars, emit EmitFunc, auth *authenticator, redactor *Redactor) (Stats, error) {
+	if spec.Child != nil {
+		return e.fetchChained(ctx, spec, vars, emit, auth, redactor)
+	}
+	return e.fetch(ctx, spec, vars, emit, auth, redactor)
+}
+
+// #endregion
+
+// #region fetch
+// fetch est le corps de Fetch pour UNE spec, avec un authenticator fourni : les
+// requêtes enfants d'une chaîne partagent ainsi le token OAuth du parent.
+func (e *Engine) fetch(ctx context.Context, spec *Spec, vars Vars, emit EmitFunc, auth *authenticator, redactor *Redactor) (Stats, error) {
+	var stats Stats
+	pager := newPaginator(&spec.Pagination)
+
+	client := *e.client
+	client.Timeout = time.Duration(spec.Source.TimeoutSeconds) * time.Second
+	if spec.Job != nil {
+		var err error
+		if vars, err = e.runJob(ctx, &client, spec, vars, auth, redactor, &stats); err != nil {
+			return stats, err
+		}
+		if spec.Job.AnonymousDownload {
+			auth = newAuthenticator(&Spec{Auth: Auth{Mode: AuthNone}}, vars, &client, redactor)
+		}
+	}
+	if spec.Records.Stream {
+		// Le délai devient une inactivité pour les pages, cf idleTimeout. Après le
+		// job : ses appels create/poll gardent le timeout ordinaire.
+		client.Timeout = 0
+	}
 
 	e.logger.Infof("httpsource: %s %s (auth=%s, pagination=%s, format=%s)",
 		spec.Source.Method, redactURL(spec.Source.URL), describeAuth(spec), spec.Pagination.Type, spec.Records.Format)
 
+	if spec.Pagination.Concurrency > 1 {
+		return e.fetchPagesConcurrently(ctx, &client, spec, vars, emit, auth, redactor, stats)
+	}
+
 	for {
 		if stats.Pages >= spec.Pagination.MaxPages {
 			// Warning et pas erreur : les lignes déjà émises sont valides, et une
@@ -161,7 +215,7 @@ func (e *Engine) Fetch(ctx context.Context, spec *Spec, vars Vars, emit EmitFunc
 			break
 		}
 
-		body, parsed, header, attempts, waited, err := e.fetchPage(ctx, &client, spec, vars, auth, pager, redactor)
+		page, attempts, waited, err := e.fetchPage(ctx, &client, spec, vars, auth, pager, redactor)
 		stats.Attempts += attempts
 		stats.Waited += waited
 		if err != nil {
@@ -169,35 +223,106 @@ func (e *Engine) Fetch(ctx context.Context, spec *Spec, vars Vars, emit EmitFunc
 		}
 		stats.Pages++
 
-		rows, err := extractRecords(body, spec, vars)
+		if page.stream != nil {
+			streamEmit := emit
+			if body, ok := page.stream.(*idleBody); ok {
+				streamEmit = body.idle.pause(emit)
+			}
+			parsed, rowCount, emitErr, err := streamPage(page.stream, spec, vars, streamEmit)
+			page.stream.Close()
+			stats.Rows += rowCount
+			switch {
+			case emitErr != nil && KindOf(emitErr) == KindStopped:
+				return stats, nil
+			case emitErr != nil:
+				return stats, emitErr
+			case err != nil:
+				return stats, redactor.Err(err)
+			}
+			if !pager.advance(parsed, rowCount, page.header) {
+				break
+			}
+			continue
+		}
+
+		rows, err := page.rows(spec, vars)
 		if err != nil {
 			return stats, redactor.Err(err)
 		}
-
-		for _, row := range rows {
-			if emitErr := emit(row); emitErr != nil {
-				if KindOf(emitErr) == KindStopped {
-					stats.Rows += len(rows)
-					return stats, nil
-				}
-				return stats, emitErr
-			}
-			stats.Rows++
+		if stop, err := emitRows(rows, emit, &stats); stop || err != nil {
+			return stats, err
 		}
 
-		if !pager.advance(parsed, len(rows), header) {
+		if !pager.advance(page.parsed, len(rows), page.header) {
 			break
 		}
 	}
 
+	e.logDone(stats)
+	return stats, nil
+}
+
+// #endregion
+
+// #region logDone
+func (e *Engine) logDone(stats Stats) {
 	e.logger.Infof("httpsource: done — %d row(s) over %d page(s), %d HTTP attempt(s), %s waited",
 		stats.Rows, stats.Pages, stats.Attempts, stats.Waited)
+}
 
-	return stats, nil
+// #endregion
+
+// #region rows
+// rows extrait les lignes d'une page. Une archive zip donne plusieurs fichiers :
+// leurs lignes sont concaténées, et chacun est lu avec son nom dans {{entry}}.
+func (p *fetchedPage) rows(spec *Spec, vars Vars) ([]map[string]any, error) {
+	var rows []map[string]any
+	for _, entry := range p.entries {
+		entryVars := vars
+		entryVars.Entry = entry.Name
+		entryRows, err := extractRecords(entry.Body, spec, entryVars)
+		if err != nil {
+			if e, ok := err.(*Error); ok && entry.Name != "" {
+				err = &Error{Kind: e.Kind, Status: e.Status, Cause: e.Cause,
+					Message: fmt.Sprintf("entry %q: %s", entry.Name, e.Message)}
+			}
+			return nil, err
+		}
+		rows = append(rows, entryRows...)
+	}
+	return rows, nil
 }
 
 // #endregion
 
+// #region emitRows
+// emitRows pousse les lignes d'une page dans emit. stop indique que la collecte doit
+// s'arrêter : ErrStop (err nil) ou erreur du callback (rendue telle quelle).
+func emitRows(rows []map[string]any, emit EmitFunc, stats *Stats) (stop bool, err error) {
+	for _, row := range rows {
+		if emitErr := emit(row); emitErr != nil {
+			if KindOf(emitErr) == KindStopped {
+				stats.Rows += len(rows)
+				return true, nil
+			}
+			return true, emitErr
+		}
+		stats.Rows++
+	}
+	return false, nil
+}
+
+// #endregion
+
+// fetchedPage est une page reçue avec succès : ses documents décompressés, ou le
+// corps encore ouvert en mode records.stream (à fermer par l'appelant).
+type fetchedPage struct {
+	entries []bodyEntry
+	stream  io.ReadCloser
+	parsed  any
+	header  http.Header
+}
+
 // #region fetchPage
 // fetchPage récupère UNE page, retries compris.
 func (e *Engine) fetchPage(
@@ -208,7 +333,7 @@ func (e *Engine) fetchPage(
 	auth *authenticator,
 	pager *paginator,
 	redactor *Redactor,
-) (body []byte, parsed any, header http.Header, attempts int, waited time.Duration, err error) {
+) (page *fetchedPage, attempts int, waited time.Duration, err error) {
 
 	// tokenRetried borne le renouvellement de token à UNE reprise par page : un 401
 	// persistant après un token neuf est une vraie erreur d'auth, pas un token expiré.
@@ -219,68 +344,127 @@ func (e *Engine) fetchPage(
 
 		req, buildErr := e.buildRequest(ctx, spec, vars, pager)
 		if buildErr != nil {
-			return nil, nil, nil, attempts, waited, redactor.Err(buildErr)
+			return nil, attempts, waited, redactor.Err(buildErr)
+		}
+
+		// Chaque tentative consomme du quota : le créneau est pris avant chacune,
+		// reprises comprises. L'attente précède la signature : une requête hmac ou
+		// aws_sigv4 signée puis retenue des minutes partirait périmée.
+		limiter := e.limiterFor(spec.RateLimit, req.URL.Host)
+		if limiter != nil {
+			if d := limiter.reserve(e.now()); d > 0 {
+				if d >= time.Second {
+					e.logger.Infof("httpsource: rate limit for %s, waiting %s", req.URL.Host, d.Round(time.Millisecond))
+				}
+				if sleepErr := e.sleep(ctx, d); sleepErr != nil {
+					return nil, attempts, waited, sleepErr
+				}
+				waited += d
+			}
 		}
+
 		if authErr := auth.apply(ctx, req); authErr != nil {
-			return nil, nil, nil, attempts, waited, redactor.Err(authErr)
+			return nil, attempts, waited, redactor.Err(authErr)
+		}
+
+		var idle *idleTimeout
+		if spec.Records.Stream {
+			var idleCtx context.Context
+			idleCtx, idle = startIdleTimeout(ctx, time.Duration(spec.Source.TimeoutSeconds)*time.Second)
+			req = req.WithContext(idleCtx)
 		}
 
 		resp, doErr := client.Do(req)
 		if doErr != nil {
+			if idle != nil {
+				idle.stop()
+			}
 			// Erreur transport (DNS, TCP, TLS, timeout). Toujours retentable : c'est
 			// très majoritairement passager.
 			if attempt == spec.Retry.MaxAttempts-1 {
-				return nil, nil, nil, attempts, waited, redactor.Err(
+				return nil, attempts, waited, redactor.Err(
 					newErr(KindUnavailable, 0, doErr, "request failed after %d attempt(s) (%s)", attempts, redactURL(req.URL.String())))
 			}
 			d := waitForBackoff(spec.Retry.OnNetwork, attempt)
 			e.logger.Warnf("httpsource: network error (attempt %d/%d), retrying in %s: %v",
 				attempt+1, spec.Retry.MaxAttempts, d, redactor.String(doErr.Error()))
 			if sleepErr := e.sleep(ctx, d); sleepErr != nil {
-				return nil, nil, nil, attempts, waited, sleepErr
+				return nil, attempts, waited, sleepErr
 			}
 			waited += d
 			continue
 		}
 
+		// records.stream : le corps d'un succès est rendu NON LU, le décodage se fait au
+		// fil de la lecture. Les erreurs, elles, sont lues en entier comme d'habitude.
+		if spec.Records.Stream {
+			if o, _ := classify(resp.StatusCode); o == outcomeSuccess {
+				e.observeQuota(limiter, spec.RateLimit, resp.Header, nil)
+				return &fetchedPage{stream: &idleBody{ReadCloser: resp.Body, idle: idle}, header: resp.Header}, attempts, waited, nil
+			}
+		}
+
 		respBody, readErr := io.ReadAll(resp.Body)
 		resp.Body.Close()
+		if idle != nil {
+			idle.stop()
+		}
 		if readErr != nil {
 			if attempt == spec.Retry.MaxAttempts-1 {
-				return nil, nil, nil, attempts, waited, redactor.Err(
+				return nil, attempts, waited, redactor.Err(
 					newErr(KindUnavailable, resp.StatusCode, readErr, "cannot read the response body"))
 			}
 			d := waitForBackoff(spec.Retry.OnNetwork, attempt)
 			if sleepErr := e.sleep(ctx, d); sleepErr != nil {
-				return nil, nil, nil, attempts, waited, sleepErr
+				return nil, attempts, waited, sleepErr
 			}
 			waited += d
 			continue
 		}
 
+		e.observeQuota(limiter, spec.RateLimit, resp.Header, respBody)
+
 		outcome, kind := classify(resp.StatusCode)
 
-		switch outcome {
-		case outcomeSuccess:
-			if spec.Records.Format == FormatJSON || spec.Pagination.needsParsedBody() {
-				if unmarshalErr := json.Unmarshal(respBody, &parsed); unmarshalErr != nil && spec.Records.Format == FormatJSON {
-					return nil, nil, nil, attempts, waited, redactor.Err(
-						newErr(KindInvalidData, resp.StatusCode, unmarshalErr,
-							"response is not valid JSON (first bytes: %q)", truncate(string(respBody), 120)))
+		if outcome == outcomeSuccess {
+			entries, unpackErr := unpackBody(respBody, resp.Header, resp.Uncompressed, &spec.Records)
+			if unpackErr != nil {
+				return nil, attempts, waited, redactor.Err(unpackErr)
+			}
+			// La pagination lit le dernier fichier : une archive paginée porte son
+			// curseur dans le dernier document, comme un export jsonl sur sa dernière ligne.
+			last := entries[len(entries)-1].Body
+			parsed, decodeErr := decodeForPagination(last, spec)
+			if decodeErr != nil {
+				// Le document décompressé, pas le corps reçu : une archive citée telle
+				// quelle ne montrerait que du binaire.
+				return nil, attempts, waited, redactor.Err(
+					newErr(KindInvalidData, resp.StatusCode, decodeErr,
+						"response is not valid JSON (first bytes: %q)", truncate(string(last), 120)))
+			}
+			// GraphQL : un 200 peut porter des erreurs. Elles suivent ensuite le même
+			// chemin qu'un statut HTTP de même nature (refresh du token, retry, fatal).
+			if spec.Source.GraphQL != nil {
+				if o, k, failed := classifyGraphQLErrors(parsed); failed {
+					outcome, kind, parsed = o, k, nil
 				}
 			}
-			return respBody, parsed, resp.Header, attempts, waited, nil
+			if outcome == outcomeSuccess {
+				return &fetchedPage{entries: entries, parsed: parsed, header: resp.Header}, attempts, waited, nil
+			}
+		}
 
+		switch outcome {
 		case outcomeFatal:
 			// 401/403 avec un token OAuth : le token a pu être révoqué avant son
 			// expiration annoncée. Un seul renouvellement + une seule reprise.
 			if kind == KindAuth && auth.usesToken() && !tokenRetried {
 				tokenRetried = true
-				auth.invalidateToken()
+				auth.invalidateToken(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
 				e.logger.Warnf("httpsource: HTTP %d, refreshing the OAuth2 token and retrying once", resp.StatusCode)
 				continue
 			}
-			return nil, nil, nil, attempts, waited, redactor.Err(
+			return nil, attempts, waited, redactor.Err(
 				newErr(kind, resp.StatusCode, nil, "%s", truncate(string(respBody), 300)))
 
 		case outcomeRetry:
@@ -292,7 +476,7 @@ func (e *Engine) fetchPage(
 			}
 
 			if attempt == spec.Retry.MaxAttempts-1 {
-				return nil, nil, nil, attempts, waited, redactor.Err(
+				return nil, attempts, waited, redactor.Err(
 					newErr(kind, resp.StatusCode, nil,
 						"still failing after %d attempt(s): %s", attempts, truncate(string(respBody), 300)))
 			}
@@ -300,13 +484,13 @@ func (e *Engine) fetchPage(
 			e.logger.Warnf("httpsource: HTTP %d (attempt %d/%d), retrying in %s",
 				resp.StatusCode, attempt+1, spec.Retry.MaxAttempts, d)
 			if sleepErr := e.sleep(ctx, d); sleepErr != nil {
-				return nil, nil, nil, attempts, waited, sleepErr
+				return nil, attempts, waited, sleepErr
 			}
 			waited += d
 		}
 	}
 
-	return nil, nil, nil, attempts, waited, redactor.Err(
+	return nil, attempts, waited, redactor.Err(
 		newErr(KindUnavailable, 0, nil, "exhausted %d attempt(s) without a usable response", attempts))
 }
 
@@ -317,7 +501,7 @@ func (e *Engine) fetchPage(
 // du JSON pour rien quand on collecte du CSV sans pagination par curseur.
 func (p Pagination) needsParsedBody() bool {
 	switch p.Type {
-	case PageCursor, PageNextURL:
+	case PageCursor, PageNextURL, PageRelay:
 		return true
 	case PagePage:
 		return p.StopWhen == "totalPages"
@@ -328,6 +512,47 @@ func (p Pagination) needsParsedBody() bool {
 
 // #endregion
 
+// #region decodeForPagination
+// decodeForPagination décode le corps pour la pagination (curseur, has_more…) et le
+// classement des erreurs GraphQL. Seul un JSON illisible est une erreur ici : pour les
+// autres formats, extractRecords produira l'erreur détaillée (ligne, position).
+func decodeForPagination(body []byte, spec *Spec) (any, error) {
+	needed := spec.Pagination.needsParsedBody()
+
+	switch spec.Records.Format {
+	case FormatJSON:
+		var parsed any
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			return nil, err
+		}
+		return parsed, nil
+
+	case FormatJSONL:
+		if needed {
+			return lastJSONLine(body), nil
+		}
+
+	case FormatXML:
+		if needed {
+			if tree, err := parseXMLTree(body, spec.Records.XML); err == nil {
+				return tree, nil
+			}
+		}
+
+	default:
+		// CSV paginé par curseur dans un corps JSON : toléré si le corps n'en est pas.
+		if needed {
+			var parsed any
+			if json.Unmarshal(body, &parsed) == nil {
+				return parsed, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// #endregion
+
 // #region buildRequest
 func (e *Engine) buildRequest(ctx context.Context, spec *Spec, vars Vars, pager *paginator) (*http.Request, error) {
 	target := pager.overrideURL()
@@ -364,7 +589,17 @@ func (e *Engine) buildRequest(ctx context.Context, spec *Spec, vars Vars, pager
 	}
 
 	var bodyReader io.Reader
-	if spec.Source.Body != nil {
+	if spec.Source.GraphQL != nil {
+		body, err := buildGraphQLBody(spec.Source.GraphQL, vars, pager)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, newErr(KindInvalidSpec, 0, err, "source.graphql cannot be serialized")
+		}
+		bodyReader = bytes.NewReader(encoded)
+	} else if spec.Source.Body != nil {
 		rendered, err := renderBody(spec.Source.Body, vars)
 		if err != nil {
 			return nil, newErr(KindInvalidSpec, 0, err, "source.body cannot be rendered")
@@ -390,9 +625,14 @@ func (e *Engine) buildRequest(ctx context.Context, spec *Spec, vars Vars, pager
 	}
 
 	if req.Header.Get("Accept") == "" {
-		if spec.Records.Format == FormatCSV {
+		switch spec.Records.Format {
+		case FormatCSV:
 			req.Header.Set("Accept", "text/csv, */*")
-		} else {
+		case FormatJSONL:
+			req.Header.Set("Accept", "application/x-ndjson, application/jsonl, */*")
+		case FormatXML:
+			req.Header.Set("Accept", "application/xml, text/xml, */*")
+		default:
 			req.Header.Set("Accept", "application/json")
 		}
 	}
@@ -3,17 +3,19 @@ package httpsource
 import "fmt"
 
 // Kind classe une erreur du moteur par NATURE, sans jamais nommer de code d'erreur
-// Quanti : le module ne doit pas importer le package `sdk` (le proc, lui, importe les
-// deux, et fait la traduction Kind → QError). Ça garde `httpsource` testable seul et
-// réutilisable hors contexte processor.
+// Quanti : le module ne doit pas importer le package `sdk`. Ça garde `httpsource`
+// testable seul et réutilisable hors contexte processor.
 //
-// Table de correspondance attendue côté proc :
+// La traduction Kind → QError est faite côté SDK par sdk.QErrorFromHTTPSource, qui
+// affine le code avec Status (403, 404, timeouts). Table de base :
 //
 //	KindInvalidSpec    → ERR_DEF_INVALID_REQUEST     (notre conf.yml est faux)
 //	KindAuth           → ERR_DEF_AUTH_NOT_VALID      (401/403 : credentials à refaire)
 //	KindRateLimit      → ERR_TMP_RATE_LIMIT_EXCEEDED (429 après épuisement des retries)
 //	KindUnavailable    → ERR_DEF_API_UNAVAILABLE     (5xx, réseau, timeout)
 //	KindInvalidData    → ERR_DEF_INVALID_DATA        (réponse illisible / path absent)
+//	KindJobFailed      → ERR_DEF_API_UNAVAILABLE     (rapport asynchrone en échec)
+//	KindJobTimeout     → ERR_TMP_TIMEOUT             (rapport jamais prêt : à rejouer)
 //	KindStopped        → pas une erreur : arrêt demandé par l'appelant
 type Kind int
 
@@ -27,6 +29,12 @@ const (
 	KindUnavailable
 	KindInvalidData
 	KindStopped
+
+	// KindJobFailed : l'API a déclaré le rapport asynchrone en échec (cf Job).
+	// KindJobTimeout : il n'était toujours pas prêt à job.poll.maxWaitSeconds — un
+	// rapport lent n'est pas un rapport faux, rejouer plus tard a un sens.
+	KindJobFailed
+	KindJobTimeout
 )
 
 // #region String
@@ -44,6 +52,10 @@ func (k Kind) String() string {
 		return "invalid_data"
 	case KindStopped:
 		return "stopped"
+	case KindJobFailed:
+		return "job_failed"
+	case KindJobTimeout:
+		return "job_timeout"
 	default:
 		return "unknown"
 	}
@@ -0,0 +1,243 @@
+package httpsource
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filtres de template : {{variable|filtre|filtre:arg}}, appliqués de gauche à droite.
+//
+// Chaque filtre est une syntaxe de plus à connaître pour relire un conf.yml : on
+// n'en ajoute un que quand une API réelle l'exige (signature, format de date
+// exotique, valeur JSON dans un corps).
+
+// filterArg : arité d'un filtre.
+type filterArg int
+
+const (
+	argNone filterArg = iota
+	argRequired
+)
+
+// filterCtx : ce que voit un filtre en plus de sa valeur.
+type filterCtx struct {
+	vars Vars
+
+	// secret : la variable vient des credentials. Un message d'erreur ne doit alors
+	// jamais citer la valeur, même transformée.
+	secret bool
+}
+
+type filterDef struct {
+	arg filterArg
+	// usage : exemple cité dans les erreurs (`format:2006-01-02`).
+	usage string
+	// argDesc : nature de l'argument, pour "filter X requires <argDesc>".
+	argDesc string
+	// check valide l'argument sans valeur ni variables (cf Spec.Validate).
+	check func(arg string) error
+	apply func(value any, arg string, c filterCtx) (any, error)
+}
+
+// filters : le registre.
+var filters = map[string]filterDef{
+	"default": {arg: argRequired, usage: "default:fallback", argDesc: "a fallback value",
+		// Appliqué par Render à la résolution de la variable (cf renderExpression) ;
+		// arrivé ici, elle existait et n'était pas vide.
+		apply: func(v any, _ string, _ filterCtx) (any, error) { return v, nil }},
+
+	"lower": {usage: "lower", apply: func(v any, _ string, _ filterCtx) (any, error) {
+		return strings.ToLower(stringify(v)), nil
+	}},
+	"upper": {usage: "upper", apply: func(v any, _ string, _ filterCtx) (any, error) {
+		return strings.ToUpper(stringify(v)), nil
+	}},
+	"urlencode": {usage: "urlencode", apply: func(v any, _ string, _ filterCtx) (any, error) {
+		return url.QueryEscape(stringify(v)), nil
+	}},
+	"base64": {usage: "base64", apply: func(v any, _ string, _ filterCtx) (any, error) {
+		return base64.StdEncoding.EncodeToString([]byte(stringify(v))), nil
+	}},
+	"sha256": {usage: "sha256", apply: func(v any, _ string, _ filterCtx) (any, error) {
+		sum := sha256.Sum256([]byte(stringify(v)))
+		return hex.EncodeToString(sum[:]), nil
+	}},
+
+	// hmac_sha256 : l'argument NOMME la variable portant la clé
+	// (`hmac_sha256:credentials.secret`) ; une clé écrite en dur dans le conf.yml
+	// serait un secret en clair dans la config.
+	"hmac_sha256": {arg: argRequired, usage: "hmac_sha256:credentials.secret", argDesc: "the variable holding the key",
+		check: func(arg string) error {
+			if !knownVariable(arg) {
+				return fmt.Errorf("filter hmac_sha256: %q is not a template variable (the argument names the variable holding the key)", arg)
+			}
+			return nil
+		},
+		apply: func(v any, arg string, c filterCtx) (any, error) {
+			key, err := c.vars.lookup(arg)
+			if err != nil {
+				return nil, err
+			}
+			mac := hmac.New(sha256.New, []byte(stringify(key)))
+			mac.Write([]byte(stringify(v)))
+			return hex.EncodeToString(mac.Sum(nil)), nil
+		}},
+
+	// json : la valeur encodée en JSON, pour l'insérer dans un corps écrit à la
+	// main (chaîne échappée et entre guillemets, objet sérialisé).
+	"json": {usage: "json", apply: func(v any, _ string, _ filterCtx) (any, error) {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("filter json: the value cannot be encoded")
+		}
+		return string(encoded), nil
+	}},
+
+	// Dates : entrée et sortie au format Quanti (2006-01-02), donc chaînables ;
+	// `format` termine la chaîne au format de l'API.
+	"addDays": {arg: argRequired, usage: "addDays:-1", argDesc: "a number of days",
+		check: func(arg string) error {
+			if _, err := strconv.Atoi(arg); err != nil {
+				return fmt.Errorf("filter addDays: %q is not a whole number of days", arg)
+			}
+			return nil
+		},
+		apply: func(v any, arg string, c filterCtx) (any, error) {
+			d, err := filterDate("addDays", v, c)
+			if err != nil {
+				return nil, err
+			}
+			n, _ := strconv.Atoi(arg)
+			return d.AddDate(0, 0, n).Format(dateLayout), nil
+		}},
+	"startOfMonth": {usage: "startOfMonth", apply: func(v any, _ string, c filterCtx) (any, error) {
+		d, err := filterDate("startOfMonth", v, c)
+		if err != nil {
+			return nil, err
+		}
+		return time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, time.UTC).Format(dateLayout), nil
+	}},
+	"endOfMonth": {usage: "endOfMonth", apply: func(v any, _ string, c filterCtx) (any, error) {
+		d, err := filterDate("endOfMonth", v, c)
+		if err != nil {
+			return nil, err
+		}
+		return time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Format(dateLayout), nil
+	}},
+	"format": {arg: argRequired, usage: "format:2006-01-02", argDesc: "a layout",
+		apply: func(v any, arg string, c filterCtx) (any, error) {
+			// La valeur d'entrée est une date au format Quanti ; on la reformate au
+			// layout Go demandé. `epoch` est traité à part car ce n'est pas un
+			// layout Go.
+			d, err := filterDate("format", v, c)
+			if err != nil {
+				return nil, err
+			}
+			if arg == "epoch" {
+				return strconv.FormatInt(d.Unix(), 10), nil
+			}
+			return d.Format(arg), nil
+		}},
+}
+
+// filterCall : un maillon du pipeline.
+type filterCall struct {
+	name, arg string
+	def       filterDef
+}
+
+var filterNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// #region parsePipeline
+// parsePipeline analyse la partie `|a|b:arg` d'une expression. Les erreurs ne
+// dépendent que du texte de la spec : c'est ce qui permet de les lever dès
+// Spec.Validate, avant tout appel.
+func parsePipeline(raw string) ([]filterCall, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var calls []filterCall
+	for i, segment := range strings.Split(raw, "|")[1:] {
+		name, arg, hasArg := strings.Cut(segment, ":")
+		name, arg = strings.TrimSpace(name), strings.TrimSpace(arg)
+
+		if !filterNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid filter expression %q", strings.TrimSpace(segment))
+		}
+		def, ok := filters[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter %q (available: %s)", name, strings.Join(filterNames(), ", "))
+		}
+		switch {
+		case def.arg == argNone && hasArg:
+			return nil, fmt.Errorf("filter %s takes no argument", name)
+		case def.arg == argRequired && !hasArg, def.arg == argRequired && arg == "" && name != "default":
+			return nil, fmt.Errorf("filter %s requires %s, e.g. |%s", name, def.argDesc, def.usage)
+		}
+		if name == "default" && i > 0 {
+			return nil, fmt.Errorf("filter default must come first: it replaces a missing or empty variable")
+		}
+		if def.check != nil {
+			if err := def.check(arg); err != nil {
+				return nil, err
+			}
+		}
+		calls = append(calls, filterCall{name: name, arg: arg, def: def})
+	}
+	return calls, nil
+}
+
+// #endregion
+
+// #region applyFilters
+func applyFilters(value any, calls []filterCall, c filterCtx) (string, error) {
+	for _, call := range calls {
+		out, err := call.def.apply(value, call.arg, c)
+		if err != nil {
+			return "", err
+		}
+		value = out
+	}
+	return stringify(value), nil
+}
+
+// #endregion
+
+// #region filterDate
+// filterDate lit une date YYYY-MM-DD. La valeur n'est citée dans l'erreur que si
+// elle ne vient pas des credentials.
+func filterDate(filter string, v any, c filterCtx) (time.Time, error) {
+	s := stringify(v)
+	d, err := time.Parse(dateLayout, s)
+	if err != nil {
+		if c.secret {
+			return time.Time{}, fmt.Errorf("filter %s: the value is not a YYYY-MM-DD date", filter)
+		}
+		return time.Time{}, fmt.Errorf("filter %s: value %q is not a YYYY-MM-DD date", filter, truncate(s, 40))
+	}
+	return d, nil
+}
+
+// #endregion
+
+// #region filterNames
+func filterNames() []string {
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// #endregion
@@ -0,0 +1,131 @@
+package httpsource
+
+import "strings"
+
+// GraphQL décrit une source GraphQL (Shopify, Monday, GitHub…). La requête part
+// toujours en POST avec le corps standard {"query", "variables", "operationName"} :
+// source.body est alors interdit, c'est ce bloc qui le construit.
+//
+// Query et les STRINGS de Variables sont des templates comme le reste de la spec.
+// Les accolades GraphQL ne gênent pas : seul `{{` est interprété.
+type GraphQL struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+// #region buildGraphQLBody
+// buildGraphQLBody rend la requête et ses variables, puis y pose le curseur de la
+// pagination relay. Une map neuve à chaque page : le curseur de la page N ne doit
+// pas survivre dans la spec.
+func buildGraphQLBody(g *GraphQL, vars Vars, pager *paginator) (map[string]any, error) {
+	query, err := Render(g.Query, vars)
+	if err != nil {
+		return nil, newErr(KindInvalidSpec, 0, err, "source.graphql.query cannot be rendered")
+	}
+
+	variables := map[string]any{}
+	if len(g.Variables) > 0 {
+		rendered, err := renderBody(map[string]any(g.Variables), vars)
+		if err != nil {
+			return nil, newErr(KindInvalidSpec, 0, err, "source.graphql.variables cannot be rendered")
+		}
+		variables = rendered.(map[string]any)
+	}
+	pager.applyToVariables(variables)
+
+	body := map[string]any{"query": query, "variables": variables}
+	if g.OperationName != "" {
+		body["operationName"] = g.OperationName
+	}
+	return body, nil
+}
+
+// #endregion
+
+// #region classifyGraphQLErrors
+// classifyGraphQLErrors lit le tableau `errors` de premier niveau d'une réponse 200.
+// Un serveur GraphQL répond 200 même quand la requête échoue : sans cette lecture,
+// un token expiré ou un quota dépassé donnerait une page "vide" et une table vide.
+//
+// Un `errors` non vide fait échouer la page même si `data` est partiellement rempli :
+// des lignes partielles en base sont pires qu'une erreur visible. Le classement lit
+// extensions.code (Apollo, Shopify), type (GitHub) puis le message :
+//
+//	UNAUTHENTICATED, FORBIDDEN, ACCESS_DENIED…     → KindAuth (fatal)
+//	THROTTLED, RATE_LIMITED, complexité dépassée…  → KindRateLimit (retentable)
+//	INTERNAL_SERVER_ERROR, SERVICE_UNAVAILABLE…    → KindUnavailable (retentable)
+//	GRAPHQL_PARSE_FAILED, GRAPHQL_VALIDATION_FAILED → KindInvalidSpec (notre requête)
+//	tout le reste                                   → KindInvalidData (fatal)
+func classifyGraphQLErrors(parsed any) (attemptOutcome, Kind, bool) {
+	root, ok := parsed.(map[string]any)
+	if !ok {
+		return outcomeSuccess, KindStopped, false
+	}
+	errs, ok := root["errors"].([]any)
+	if !ok || len(errs) == 0 {
+		return outcomeSuccess, KindStopped, false
+	}
+
+	// Le plus grave l'emporte : une erreur d'auth dans le lot rend inutile de
+	// réessayer pour le quota.
+	outcome, kind := outcomeFatal, KindInvalidData
+	best := 0
+	for _, raw := range errs {
+		o, k, rank := classifyGraphQLError(raw)
+		if rank > best {
+			outcome, kind, best = o, k, rank
+		}
+	}
+	return outcome, kind, true
+}
+
+// #endregion
+
+// #region classifyGraphQLError
+// classifyGraphQLError renvoie aussi un rang de gravité pour départager un lot.
+func classifyGraphQLError(raw any) (attemptOutcome, Kind, int) {
+	e, _ := raw.(map[string]any)
+	var code string
+	if ext, ok := e["extensions"].(map[string]any); ok {
+		code, _ = ext["code"].(string)
+	}
+	if code == "" {
+		code, _ = e["type"].(string)
+	}
+	message, _ := e["message"].(string)
+	code, message = strings.ToUpper(code), strings.ToLower(message)
+
+	switch {
+	case containsAny(code, "UNAUTHENTICATED", "UNAUTHORIZED", "FORBIDDEN", "ACCESS_DENIED", "PERMISSION") ||
+		containsAny(message, "unauthorized", "not authenticated", "invalid token", "access denied", "permission"):
+		return outcomeFatal, KindAuth, 5
+	case containsAny(code, "GRAPHQL_PARSE_FAILED", "GRAPHQL_VALIDATION_FAILED", "BAD_USER_INPUT"):
+		return outcomeFatal, KindInvalidSpec, 4
+	case containsAny(code, "THROTTLED", "RATE_LIMIT", "TOO_MANY_REQUESTS", "COMPLEXITY", "MAX_COST") ||
+		containsAny(message, "rate limit", "throttled", "too many requests", "complexity budget"):
+		return outcomeRetry, KindRateLimit, 3
+	case containsAny(code, "INTERNAL_SERVER_ERROR", "SERVICE_UNAVAILABLE", "TIMEOUT") ||
+		containsAny(message, "timeout", "temporarily unavailable"):
+		return outcomeRetry, KindUnavailable, 2
+	default:
+		return outcomeFatal, KindInvalidData, 1
+	}
+}
+
+// #endregion
+
+// #region containsAny
+func containsAny(s string, subs ...string) bool {
+	if s == "" {
+		return false
+	}
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// #endregion
@@ -0,0 +1,160 @@
+package httpsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// #region TestFetch_GraphQLRelayPagination
+// Shopify-like : query templatée, variables templatées, curseur Relay écrit dans
+// `after` à partir de la 2e page, arrêt sur hasNextPage=false.
+func TestFetch_GraphQLRelayPagination(t *testing.T) {
+	var bodies []map[string]any
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+
+		if _, ok := body["variables"].(map[string]any)["after"]; !ok {
+			fmt.Fprint(w, `{"data":{"orders":{"nodes":[{"id":"1"},{"id":"2"}],"pageInfo":{"hasNextPage":true,"endCursor":"c2"}}}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"orders":{"nodes":[{"id":"3"}],"pageInfo":{"hasNextPage":false,"endCursor":"c3"}}}}`)
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source": map[string]any{
+			"url": srv.URL + "/graphql.json",
+			"graphql": map[string]any{
+				"query":         `query Orders($q: String!, $after: String) { orders(first: 2, after: $after, query: $q) { nodes { id } pageInfo { hasNextPage endCursor } } }`,
+				"variables":     map[string]any{"q": "created_at:{{date}}", "first": 2},
+				"operationName": "Orders",
+			},
+		},
+		"pagination": map[string]any{"type": "relay", "pageInfoPath": "data.orders.pageInfo"},
+		"records":    map[string]any{"path": "data.orders.nodes"},
+	})
+	if spec.Source.Method != "POST" || spec.Pagination.Param != "after" {
+		t.Fatalf("defaults: method=%s param=%s", spec.Source.Method, spec.Pagination.Param)
+	}
+
+	rows, stats := collect(t, fastEngine(nil), spec, Vars{Date: "2026-08-12"})
+
+	if len(rows) != 3 || stats.Pages != 2 {
+		t.Fatalf("got %d rows over %d pages", len(rows), stats.Pages)
+	}
+	first, second := bodies[0], bodies[1]
+	if first["operationName"] != "Orders" || first["variables"].(map[string]any)["q"] != "created_at:2026-08-12" {
+		t.Errorf("first body: %v", first)
+	}
+	if first["variables"].(map[string]any)["first"] != float64(2) {
+		t.Errorf("non-string variables must be kept as is: %v", first["variables"])
+	}
+	if second["variables"].(map[string]any)["after"] != "c2" {
+		t.Errorf("second page must carry the cursor: %v", second["variables"])
+	}
+}
+
+// #endregion
+
+// #region TestFetch_GraphQLErrorsOn200
+func TestFetch_GraphQLErrorsOn200(t *testing.T) {
+	cases := []struct {
+		name     string
+		errors   string
+		wantKind Kind
+		attempts int
+	}{
+		{"auth by code", `[{"message":"nope","extensions":{"code":"UNAUTHENTICATED"}}]`, KindAuth, 1},
+		{"github rate limit", `[{"type":"RATE_LIMITED","message":"API rate limit exceeded"}]`, KindRateLimit, 3},
+		{"shopify throttled", `[{"message":"Throttled","extensions":{"code":"THROTTLED"}}]`, KindRateLimit, 3},
+		{"invalid query", `[{"message":"Field 'foo' doesn't exist","extensions":{"code":"GRAPHQL_VALIDATION_FAILED"}}]`, KindInvalidSpec, 1},
+		{"anything else", `[{"message":"Order not found"}]`, KindInvalidData, 1},
+		{"auth wins over throttle", `[{"extensions":{"code":"THROTTLED"}},{"message":"Access denied for orders field"}]`, KindAuth, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				fmt.Fprintf(w, `{"data":null,"errors":%s}`, c.errors)
+			}))
+			defer srv.Close()
+
+			spec := mustSpec(t, map[string]any{
+				"source":  map[string]any{"url": srv.URL, "graphql": map[string]any{"query": "{ shop { name } }"}},
+				"retry":   map[string]any{"maxAttempts": 3},
+				"records": map[string]any{"path": "data.shop"},
+			})
+			var slept []time.Duration
+			_, err := fastEngine(&slept).Fetch(context.Background(), spec, Vars{}, func(map[string]any) error { return nil })
+			if KindOf(err) != c.wantKind {
+				t.Fatalf("kind: got %v (%v), want %v", KindOf(err), err, c.wantKind)
+			}
+			if calls != c.attempts {
+				t.Errorf("attempts: got %d, want %d", calls, c.attempts)
+			}
+		})
+	}
+}
+
+// #endregion
+
+// #region TestFetch_GraphQLEmptyErrorsIsSuccess
+func TestFetch_GraphQLEmptyErrorsIsSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"shop":{"name":"Acme"}},"errors":[]}`)
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source":  map[string]any{"url": srv.URL, "graphql": map[string]any{"query": "{ shop { name } }"}},
+		"records": map[string]any{"path": "data.shop"},
+	})
+	rows, _ := collect(t, fastEngine(nil), spec, Vars{})
+	if len(rows) != 1 || rows[0]["name"] != "Acme" {
+		t.Errorf("rows: %v", rows)
+	}
+}
+
+// #endregion
+
+// #region TestValidate_GraphQL
+func TestValidate_GraphQL(t *testing.T) {
+	cases := map[string]map[string]any{
+		"query required": {
+			"source": map[string]any{"url": "https://x", "graphql": map[string]any{}},
+		},
+		"body forbidden": {
+			"source": map[string]any{"url": "https://x", "body": map[string]any{"a": 1}, "graphql": map[string]any{"query": "{ a }"}},
+		},
+		"GET refused": {
+			"source": map[string]any{"url": "https://x", "method": "GET", "graphql": map[string]any{"query": "{ a }"}},
+		},
+		"relay without graphql": {
+			"source":     map[string]any{"url": "https://x"},
+			"pagination": map[string]any{"type": "relay", "pageInfoPath": "data.a.pageInfo"},
+		},
+		"relay without pageInfoPath": {
+			"source":     map[string]any{"url": "https://x", "graphql": map[string]any{"query": "{ a }"}},
+			"pagination": map[string]any{"type": "relay"},
+		},
+	}
+	for name, raw := range cases {
+		if _, err := ParseSpec(raw); KindOf(err) != KindInvalidSpec {
+			t.Errorf("%s: expected an invalid spec, got %v", name, err)
+		}
+	}
+}
+
+// #endregion
@@ -0,0 +1,190 @@
+package httpsource
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HMACAuth : signature HMAC de chaque requête (places de marché, PSP, réseaux
+// d'affiliation). Le texte signé est un template décrit dans la spec, car chaque
+// API assemble le sien (méthode, chemin, query triée, hash du corps, horodatage) dans
+// un ordre et avec des séparateurs différents.
+//
+// En plus des variables habituelles, StringToSign et Headers disposent de :
+//
+//	{{request.method}}      GET, POST…
+//	{{request.host}}        api.example.com
+//	{{request.path}}        /v1/orders (échappé)
+//	{{request.query}}       query triée par clé et encodée : a=1&b=2
+//	{{request.body}}        corps brut
+//	{{request.bodySha256}}  SHA-256 hex du corps (celui d'un corps vide sinon)
+//	{{request.timestamp}}   horodatage au TimestampFormat
+//	{{request.signature}}   la signature calculée (Headers seulement)
+//
+// Recalculée à CHAQUE tentative de chaque page : une reprise après un 429 doit
+// porter un horodatage neuf, sinon l'API la rejette comme rejouée.
+type HMACAuth struct {
+	Key          string `json:"key"`
+	StringToSign string `json:"stringToSign"`
+
+	// Algorithm : sha256 (défaut), sha1 ou sha512. Encoding : hex (défaut) ou base64.
+	Algorithm string `json:"algorithm"`
+	Encoding  string `json:"encoding"`
+
+	// SignatureHeader reçoit la signature brute. Pour un format composé
+	// (`Authorization: HMAC id:signature`), utiliser Headers avec
+	// {{request.signature}}. Défaut X-Signature si aucun des deux n'est posé.
+	SignatureHeader string `json:"signatureHeader"`
+
+	// TimestampHeader : en-tête portant l'horodatage signé, si l'API l'attend.
+	// TimestampFormat : unix (défaut), unix_ms ou rfc3339.
+	TimestampHeader string `json:"timestampHeader"`
+	TimestampFormat string `json:"timestampFormat"`
+
+	// Headers : en-têtes supplémentaires, templatés avec les mêmes variables.
+	Headers map[string]string `json:"headers"`
+}
+
+const (
+	TimestampUnix    = "unix"
+	TimestampUnixMs  = "unix_ms"
+	TimestampRFC3339 = "rfc3339"
+)
+
+// hmacAlgorithms : les algorithmes acceptés par auth.hmac.algorithm.
+var hmacAlgorithms = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// #region signHMAC
+// signHMAC signe req et pose les en-têtes. La signature est masquée comme valeur
+// récente (cf Redactor.AddRecent) : elle vaut un mot de passe pour la durée de vie
+// de l'horodatage.
+func (a *authenticator) signHMAC(req *http.Request) error {
+	cfg := a.spec.Auth.HMAC
+
+	body, err := requestBody(req)
+	if err != nil {
+		return newErr(KindInvalidSpec, 0, err, "auth.hmac: cannot read the request body to sign it")
+	}
+	bodySum := sha256.Sum256(body)
+
+	vars := a.vars
+	vars.request = map[string]any{
+		"method":     req.Method,
+		"host":       req.URL.Host,
+		"path":       req.URL.EscapedPath(),
+		"query":      req.URL.Query().Encode(),
+		"body":       string(body),
+		"bodySha256": hex.EncodeToString(bodySum[:]),
+		"timestamp":  formatTimestamp(a.now(), cfg.TimestampFormat),
+	}
+
+	key, err := Render(cfg.Key, vars)
+	if err != nil {
+		return newErr(KindInvalidSpec, 0, err, "auth.hmac.key cannot be rendered")
+	}
+	toSign, err := Render(cfg.StringToSign, vars)
+	if err != nil {
+		return newErr(KindInvalidSpec, 0, err, "auth.hmac.stringToSign cannot be rendered")
+	}
+
+	mac := hmac.New(hmacAlgorithms[cfg.Algorithm], []byte(key))
+	mac.Write([]byte(toSign))
+	var signature string
+	if cfg.Encoding == "base64" {
+		signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	} else {
+		signature = hex.EncodeToString(mac.Sum(nil))
+	}
+	a.redactor.AddRecent(signature)
+	vars.request["signature"] = signature
+
+	headers, err := RenderMap(cfg.Headers, vars)
+	if err != nil {
+		return newErr(KindInvalidSpec, 0, err, "auth.hmac.headers cannot be rendered")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if cfg.SignatureHeader != "" {
+		req.Header.Set(cfg.SignatureHeader, signature)
+	}
+	if cfg.TimestampHeader != "" {
+		req.Header.Set(cfg.TimestampHeader, vars.request["timestamp"].(string))
+	}
+	return nil
+}
+
+// #endregion
+
+// #region requestBody
+// requestBody lit le corps sans le consommer (GetBody, posé par http.NewRequest pour
+// un corps en mémoire).
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.GetBody == nil {
+		return nil, nil
+	}
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// #endregion
+
+// #region formatTimestamp
+func formatTimestamp(t time.Time, format string) string {
+	switch format {
+	case TimestampUnixMs:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	case TimestampRFC3339:
+		return t.UTC().Format(time.RFC3339)
+	default:
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+}
+
+// #endregion
+
+// #region validateHMAC
+func (s *Spec) validateHMAC() error {
+	cfg := s.Auth.HMAC
+	if cfg == nil || cfg.Key == "" || cfg.StringToSign == "" {
+		return newErr(KindInvalidSpec, 0, nil, "auth.hmac.key and auth.hmac.stringToSign are required with mode %s", AuthHMAC)
+	}
+	if _, ok := hmacAlgorithms[cfg.Algorithm]; !ok {
+		names := make([]string, 0, len(hmacAlgorithms))
+		for name := range hmacAlgorithms {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return newErr(KindInvalidSpec, 0, nil, "auth.hmac.algorithm %q is not supported (%s)", cfg.Algorithm, strings.Join(names, ", "))
+	}
+	if cfg.Encoding != "hex" && cfg.Encoding != "base64" {
+		return newErr(KindInvalidSpec, 0, nil, "auth.hmac.encoding %q is not supported (hex or base64)", cfg.Encoding)
+	}
+	switch cfg.TimestampFormat {
+	case TimestampUnix, TimestampUnixMs, TimestampRFC3339:
+	default:
+		return newErr(KindInvalidSpec, 0, nil, "auth.hmac.timestampFormat %q is not supported (unix, unix_ms or rfc3339)", cfg.TimestampFormat)
+	}
+	return nil
+}
+
+// #endregion
@@ -0,0 +1,149 @@
+package httpsource
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// #region TestFetch_HMACSignsEveryAttempt
+func TestFetch_HMACSignsEveryAttempt(t *testing.T) {
+	// Le serveur vérifie la signature comme le ferait l'API : HMAC-SHA256 hex de
+	// "METHOD\npath\nquery triée\nsha256(corps)\ntimestamp". Le premier appel de la
+	// page 2 répond 429 : sa reprise doit être signée à nouveau.
+	var pages []string
+	throttled := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		toSign := strings.Join([]string{r.Method, r.URL.EscapedPath(), r.URL.Query().Encode(),
+			hex.EncodeToString(sum[:]), r.Header.Get("X-Timestamp")}, "\n")
+		mac := hmac.New(sha256.New, []byte("shh-secret"))
+		mac.Write([]byte(toSign))
+		if want := hex.EncodeToString(mac.Sum(nil)); r.Header.Get("X-Signature") != want || r.Header.Get("X-Key") != "pub-key" {
+			t.Errorf("bad signature for %s: got %q want %q", r.URL, r.Header.Get("X-Signature"), want)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		pages = append(pages, r.URL.Query().Get("page"))
+
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"orders":[{"id":1},{"id":2}]}`)
+		case "2":
+			if !throttled {
+				throttled = true
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			fmt.Fprint(w, `{"orders":[{"id":3}]}`)
+		default:
+			fmt.Fprint(w, `{"orders":[]}`)
+		}
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source": map[string]any{
+			"url": srv.URL + "/v1/orders", "method": "POST",
+			"query": map[string]any{"status": "shipped", "from": "{{date}}"},
+			"body":  map[string]any{"shop": "{{credentials.shop}}"},
+		},
+		"auth": map[string]any{"mode": "hmac", "hmac": map[string]any{
+			"key":             "{{credentials.secret}}",
+			"stringToSign":    "{{request.method}}\n{{request.path}}\n{{request.query}}\n{{request.bodySha256}}\n{{request.timestamp}}",
+			"timestampHeader": "X-Timestamp",
+			"headers":         map[string]any{"X-Key": "{{credentials.key}}"},
+			"signatureHeader": "X-Signature",
+		}},
+		"pagination": map[string]any{"type": "page"},
+		"records":    map[string]any{"path": "orders"},
+	})
+	vars := Vars{Date: "2026-08-12", Credentials: map[string]any{"secret": "shh-secret", "key": "pub-key", "shop": "acme"}}
+
+	rows, stats := collect(t, fastEngine(nil), spec, vars)
+	if len(rows) != 3 || stats.Attempts != 4 {
+		t.Fatalf("rows=%d stats=%+v", len(rows), stats)
+	}
+	// Pages 1, 2 (429 puis reprise) et 3 : chaque tentative signée et acceptée.
+	if strings.Join(pages, ",") != "1,2,2,3" {
+		t.Errorf("signed attempts: %v", pages)
+	}
+}
+
+// #endregion
+
+// #region TestSignHMAC_HeadersEncodingAndRedaction
+func TestSignHMAC_HeadersEncodingAndRedaction(t *testing.T) {
+	spec := mustSpec(t, map[string]any{
+		"source": map[string]any{"url": "https://api.example.com/v2/report"},
+		"auth": map[string]any{"mode": "hmac", "hmac": map[string]any{
+			"key":          "{{credentials.secret}}",
+			"stringToSign": "{{request.method}} {{request.path}}?{{request.query}}|{{request.body}}",
+			"algorithm":    "SHA512",
+			"encoding":     "base64",
+			"headers":      map[string]any{"Authorization": "HMAC {{credentials.keyId}}:{{request.signature}}"},
+		}},
+	})
+	vars := Vars{Credentials: map[string]any{"secret": "shh-secret", "keyId": "kid-42"}}
+	redactor := NewRedactor(vars.Credentials)
+	a := newAuthenticator(spec, vars, http.DefaultClient, redactor)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/v2/report?z=1&a=2", nil)
+	if err := a.apply(context.Background(), req); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	mac := hmac.New(sha512.New, []byte("shh-secret"))
+	mac.Write([]byte("GET /v2/report?a=2&z=1|"))
+	want := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if got := req.Header.Get("Authorization"); got != "HMAC kid-42:"+want {
+		t.Fatalf("Authorization: got %q, want %q", got, "HMAC kid-42:"+want)
+	}
+	if req.Header.Get("X-Signature") != "" {
+		t.Errorf("no default signature header when headers are set")
+	}
+	if got := redactor.String("sent " + want); strings.Contains(got, want) {
+		t.Errorf("the signature must be redacted: %q", got)
+	}
+}
+
+// #endregion
+
+// #region TestValidate_HMAC
+func TestValidate_HMAC(t *testing.T) {
+	cases := []struct {
+		hmac map[string]any
+		want string
+	}{
+		{nil, "auth.hmac.key and auth.hmac.stringToSign are required"},
+		{map[string]any{"key": "k", "stringToSign": "s", "algorithm": "md5"}, "auth.hmac.algorithm"},
+		{map[string]any{"key": "k", "stringToSign": "s", "encoding": "base32"}, "auth.hmac.encoding"},
+		{map[string]any{"key": "k", "stringToSign": "{{request.method|lowercase}}"}, "auth.hmac.stringToSign"},
+	}
+	for _, c := range cases {
+		auth := map[string]any{"mode": "hmac"}
+		if c.hmac != nil {
+			auth["hmac"] = c.hmac
+		}
+		_, err := ParseSpec(map[string]any{"source": map[string]any{"url": "https://x.com"}, "auth": auth})
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%v: want %q, got %v", c.hmac, c.want, err)
+		}
+	}
+
+	if _, err := Render("{{request.method}}", testVars()); err == nil || !strings.Contains(err.Error(), "only available in auth.hmac") {
+		t.Errorf("request.* outside a signature: %v", err)
+	}
+}
+
+// #endregion
@@ -0,0 +1,187 @@
+package httpsource
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Job décrit un rapport ASYNCHRONE (Amazon Ads, Criteo, DV360, TikTok…) : l'API ne
+// renvoie pas les données, on demande un rapport, on attend qu'il soit prêt, puis on
+// le télécharge.
+//
+//  1. create : une requête (POST par défaut) dont la réponse JSON porte l'id du job
+//     à IDPath ;
+//  2. poll : une requête répétée toutes les IntervalSeconds jusqu'à ce que la valeur
+//     à StatusPath soit dans SuccessValues (prêt) ou FailureValues (échec). Toute
+//     autre valeur = encore en cours. Au-delà de MaxWaitSeconds d'attente cumulée,
+//     le job est abandonné ;
+//  3. download : c'est `source`, exécutée ensuite comme une spec ordinaire (records,
+//     compression, pagination). Elle dispose de {{job.id}} et, si DownloadURLPath
+//     est renseigné, de {{job.downloadUrl}} lu dans la dernière réponse du poll.
+//
+// Les trois étapes partagent auth et retry. Les statuts sont comparés sans casse.
+type Job struct {
+	Create JobCreate `json:"create"`
+	Poll   JobPoll   `json:"poll"`
+
+	// AnonymousDownload : le téléchargement part SANS l'auth de la spec. Nécessaire
+	// pour les URL présignées (S3, GCS) qui refusent une requête portant à la fois une
+	// signature et un en-tête Authorization.
+	AnonymousDownload bool `json:"anonymousDownload"`
+}
+
+// JobCreate : requête de création du rapport.
+type JobCreate struct {
+	Source
+	IDPath string `json:"idPath"`
+}
+
+// JobPoll : requête de suivi du rapport (GET par défaut).
+type JobPoll struct {
+	Source
+	IntervalSeconds int      `json:"intervalSeconds"`
+	MaxWaitSeconds  int      `json:"maxWaitSeconds"`
+	StatusPath      string   `json:"statusPath"`
+	SuccessValues   []string `json:"successValues"`
+	FailureValues   []string `json:"failureValues"`
+	DownloadURLPath string   `json:"downloadUrlPath"`
+}
+
+// #region runJob
+// runJob crée le job et attend qu'il soit prêt. Renvoie les variables du
+// téléchargement, complétées de l'id et de l'URL du rapport.
+func (e *Engine) runJob(
+	ctx context.Context,
+	client *http.Client,
+	spec *Spec,
+	vars Vars,
+	auth *authenticator,
+	redactor *Redactor,
+	stats *Stats,
+) (Vars, error) {
+	job := spec.Job
+
+	created, err := e.jobCall(ctx, client, spec, job.Create.Source, vars, auth, redactor, stats)
+	if err != nil {
+		return vars, err
+	}
+	id, ok := navigateOptional(created, job.Create.IDPath)
+	if !ok || stringify(id) == "" {
+		return vars, redactor.Err(newErr(KindInvalidData, 0, nil,
+			"job.create.idPath %q not found in the response — response body: %s", job.Create.IDPath, jsonExcerpt(created)))
+	}
+	vars.JobID = stringify(id)
+	e.logger.Infof("httpsource: report job %s created, polling every %ds (max %ds)",
+		redactor.String(vars.JobID), job.Poll.IntervalSeconds, job.Poll.MaxWaitSeconds)
+
+	interval := time.Duration(job.Poll.IntervalSeconds) * time.Second
+	maxWait := time.Duration(job.Poll.MaxWaitSeconds) * time.Second
+	var pollWaited time.Duration
+
+	for {
+		polled, err := e.jobCall(ctx, client, spec, job.Poll.Source, vars, auth, redactor, stats)
+		if err != nil {
+			return vars, err
+		}
+
+		// Statut absent : chemin erroné ou enveloppe d'erreur servie en 200. Attendre
+		// ne changerait rien, et finir en KindJobTimeout ferait rejouer le run sans fin.
+		rawStatus, ok := navigateOptional(polled, job.Poll.StatusPath)
+		if !ok {
+			return vars, redactor.Err(newErr(KindInvalidData, 0, nil,
+				"job.poll.statusPath %q not found in the poll response of job %s — response body: %s",
+				job.Poll.StatusPath, vars.JobID, jsonExcerpt(polled)))
+		}
+		status := stringify(rawStatus)
+		switch {
+		case containsFold(job.Poll.FailureValues, status):
+			return vars, redactor.Err(newErr(KindJobFailed, 0, nil,
+				"report job %s failed with status %q — response body: %s", vars.JobID, status, jsonExcerpt(polled)))
+
+		case containsFold(job.Poll.SuccessValues, status):
+			if job.Poll.DownloadURLPath != "" {
+				downloadURL, ok := navigateOptional(polled, job.Poll.DownloadURLPath)
+				if !ok || stringify(downloadURL) == "" {
+					return vars, redactor.Err(newErr(KindInvalidData, 0, nil,
+						"job.poll.downloadUrlPath %q not found in the response of a ready job — response body: %s",
+						job.Poll.DownloadURLPath, jsonExcerpt(polled)))
+				}
+				vars.JobDownloadURL = stringify(downloadURL)
+			}
+			e.logger.Infof("httpsource: report job %s ready after %s", redactor.String(vars.JobID), pollWaited)
+			return vars, nil
+		}
+
+		// Attente CUMULÉE et non horloge murale : le temps passé dans les requêtes ne
+		// compte pas, ce qui garde le délai prévisible quelle que soit la latence.
+		if pollWaited+interval > maxWait {
+			return vars, redactor.Err(newErr(KindJobTimeout, 0, nil,
+				"report job %s still not ready after %s (last status %q, job.poll.maxWaitSeconds)",
+				vars.JobID, pollWaited, status))
+		}
+		if err := e.sleep(ctx, interval); err != nil {
+			return vars, err
+		}
+		pollWaited += interval
+		stats.Waited += interval
+	}
+}
+
+// #endregion
+
+// #region jobCall
+// jobCall exécute une étape du job avec les retries de la spec : même fetchPage que
+// les pages de données, sur une spec dérivée (cette requête, une page, JSON).
+func (e *Engine) jobCall(
+	ctx context.Context,
+	client *http.Client,
+	spec *Spec,
+	source Source,
+	vars Vars,
+	auth *authenticator,
+	redactor *Redactor,
+	stats *Stats,
+) (any, error) {
+	step := &Spec{
+		Source:     source,
+		Auth:       spec.Auth,
+		Pagination: Pagination{Type: PageNone, MaxPages: 1},
+		Retry:      spec.Retry,
+		RateLimit:  spec.RateLimit,
+		Records:    Records{Format: FormatJSON, Compression: CompressionNone},
+	}
+	stepClient := *client
+	stepClient.Timeout = time.Duration(source.TimeoutSeconds) * time.Second
+	page, attempts, waited, err := e.fetchPage(ctx, &stepClient, step, vars, auth, newPaginator(&step.Pagination), redactor)
+	stats.Attempts += attempts
+	stats.Waited += waited
+	if err != nil {
+		return nil, err
+	}
+	return page.parsed, nil
+}
+
+// #endregion
+
+// #region containsFold
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// #endregion
+
+// #region jsonExcerpt
+func jsonExcerpt(v any) string {
+	encoded, _ := json.Marshal(v)
+	return truncate(string(encoded), 300)
+}
+
+// #endregion
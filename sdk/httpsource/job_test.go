@@ -0,0 +1,226 @@
+package httpsource
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jobSpec : création par POST /reports, poll de /reports/{id} toutes les 10 s (60 s
+// au plus), téléchargement anonyme de l'URL "location", comme une URL S3 présignée.
+func jobSpec(t *testing.T, baseURL string) *Spec {
+	t.Helper()
+	return mustSpec(t, map[string]any{
+		"source": map[string]any{"url": "{{job.downloadUrl}}"},
+		"auth":   map[string]any{"mode": "bearer", "value": "tok"},
+		"job": map[string]any{
+			"create": map[string]any{
+				"url":    baseURL + "/reports",
+				"body":   map[string]any{"date": "{{date}}"},
+				"idPath": "report.id",
+			},
+			"poll": map[string]any{
+				"url":             baseURL + "/reports/{{job.id}}",
+				"intervalSeconds": 10,
+				"maxWaitSeconds":  60,
+				"statusPath":      "status",
+				"successValues":   []any{"SUCCESS"},
+				"failureValues":   []any{"FAILURE", "CANCELLED"},
+				"downloadUrlPath": "location",
+			},
+			"anonymousDownload": true,
+		},
+		"records": map[string]any{"compression": "auto", "inject": map[string]any{"reportId": "{{job.id}}"}},
+	})
+}
+
+// #region TestFetch_JobCreatePollDownload
+func TestFetch_JobCreatePollDownload(t *testing.T) {
+	statuses := []string{"PENDING", "in_progress", "success"}
+	polls := 0
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/reports":
+			if r.Header.Get("Authorization") != "Bearer tok" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["date"] != "2026-08-12" {
+				t.Errorf("create body: %v", body)
+			}
+			io.WriteString(w, `{"report":{"id":"r-42"}}`)
+
+		case r.URL.Path == "/reports/r-42":
+			io.WriteString(w, `{"status":"`+statuses[polls]+`","location":"`+srv.URL+`/files/r-42?sig=abc"}`)
+			polls++
+
+		case r.URL.Path == "/files/r-42":
+			// URL présignée : un en-tête Authorization la fait refuser.
+			if r.Header.Get("Authorization") != "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			gz := gzip.NewWriter(w)
+			io.WriteString(gz, `[{"campaign":"c1","clicks":3},{"campaign":"c2","clicks":5}]`)
+			gz.Close()
+
+		default:
+			t.Errorf("unexpected %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	var slept []time.Duration
+	rows, stats := collect(t, fastEngine(&slept), jobSpec(t, srv.URL), Vars{Date: "2026-08-12"})
+
+	if polls != 3 {
+		t.Errorf("polls: got %d, want 3 (status is compared case-insensitively)", polls)
+	}
+	if len(slept) != 2 || slept[0] != 10*time.Second {
+		t.Errorf("poll interval: %v", slept)
+	}
+	if len(rows) != 2 || rows[1]["clicks"] != float64(5) || rows[1]["reportId"] != "r-42" {
+		t.Fatalf("rows: %v", rows)
+	}
+	// create + 3 polls + 1 download.
+	if stats.Attempts != 5 || stats.Pages != 1 {
+		t.Errorf("stats: %+v", stats)
+	}
+}
+
+// #endregion
+
+// #region TestFetch_JobFailedAndTimeoutAreDistinct
+func TestFetch_JobFailedAndTimeoutAreDistinct(t *testing.T) {
+	polls := 0
+	failed := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			io.WriteString(w, `{"report":{"id":"r-42"}}`)
+			return
+		}
+		polls++
+		if failed && polls > 1 {
+			io.WriteString(w, `{"status":"FAILURE"}`)
+			return
+		}
+		io.WriteString(w, `{"status":"PENDING"}`)
+	}))
+	defer srv.Close()
+
+	t.Run("failed", func(t *testing.T) {
+		polls, failed = 0, true
+		_, err := fastEngine(nil).Fetch(context.Background(), jobSpec(t, srv.URL), Vars{Date: "2026-08-12"},
+			func(map[string]any) error { return nil })
+		if KindOf(err) != KindJobFailed || !strings.Contains(err.Error(), "r-42") {
+			t.Fatalf("want job_failed, got %v", err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		polls, failed = 0, false
+		var slept []time.Duration
+		_, err := fastEngine(&slept).Fetch(context.Background(), jobSpec(t, srv.URL), Vars{Date: "2026-08-12"},
+			func(map[string]any) error { return nil })
+		if KindOf(err) != KindJobTimeout {
+			t.Fatalf("want job_timeout, got %v", err)
+		}
+		// 60 s au pas de 10 s : 6 attentes, 7 polls.
+		if len(slept) != 6 || polls != 7 {
+			t.Errorf("slept=%v polls=%d", slept, polls)
+		}
+	})
+}
+
+// #endregion
+
+// #region TestFetch_JobIDMissing
+func TestFetch_JobIDMissing(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"error":"invalid report type"}`)
+	}))
+	defer srv.Close()
+
+	_, err := fastEngine(nil).Fetch(context.Background(), jobSpec(t, srv.URL), Vars{Date: "2026-08-12"},
+		func(map[string]any) error { return nil })
+	if KindOf(err) != KindInvalidData || !strings.Contains(err.Error(), "invalid report type") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+// #endregion
+
+// #region TestFetch_JobStatusMissing
+// Une enveloppe d'erreur en 200 n'a pas de statut : échec immédiat, sans attendre
+// job.poll.maxWaitSeconds.
+func TestFetch_JobStatusMissing(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			io.WriteString(w, `{"report":{"id":"r-42"}}`)
+			return
+		}
+		io.WriteString(w, `{"error":{"message":"quota exceeded for reports"}}`)
+	}))
+	defer srv.Close()
+
+	var slept []time.Duration
+	_, err := fastEngine(&slept).Fetch(context.Background(), jobSpec(t, srv.URL), Vars{Date: "2026-08-12"},
+		func(map[string]any) error { return nil })
+	if KindOf(err) != KindInvalidData || !strings.Contains(err.Error(), `statusPath "status"`) ||
+		!strings.Contains(err.Error(), "quota exceeded for reports") {
+		t.Fatalf("got %v", err)
+	}
+	if len(slept) != 0 {
+		t.Errorf("no poll wait expected: %v", slept)
+	}
+}
+
+// #endregion
+
+// #region TestValidate_Job
+func TestValidate_Job(t *testing.T) {
+	base := func(job map[string]any, sourceURL string) map[string]any {
+		return map[string]any{"source": map[string]any{"url": sourceURL}, "job": job}
+	}
+	create := map[string]any{"url": "https://x.com/reports", "idPath": "id"}
+	poll := map[string]any{"url": "https://x.com/reports/{{job.id}}", "statusPath": "status", "successValues": []any{"DONE"}}
+
+	spec, err := ParseSpec(base(map[string]any{"create": create, "poll": poll}, "https://x.com/reports/{{job.id}}/file"))
+	if err != nil {
+		t.Fatalf("valid job rejected: %v", err)
+	}
+	if spec.Job.Create.Method != "POST" || spec.Job.Poll.Method != "GET" || spec.Job.Poll.IntervalSeconds != 30 {
+		t.Errorf("defaults: %+v", spec.Job)
+	}
+
+	cases := []struct {
+		name string
+		spec map[string]any
+		want string
+	}{
+		{"no idPath", base(map[string]any{"create": map[string]any{"url": "https://x.com"}, "poll": poll}, "https://x.com"), "job.create.idPath"},
+		{"no poll url", base(map[string]any{"create": create, "poll": map[string]any{"statusPath": "s"}}, "https://x.com"), "job.poll.url"},
+		{"no success values", base(map[string]any{"create": create, "poll": map[string]any{"url": "https://x.com", "statusPath": "s"}}, "https://x.com"), "successValues"},
+		{"downloadUrl without path", base(map[string]any{"create": create, "poll": poll}, "{{job.downloadUrl}}"), "downloadUrlPath"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ParseSpec(c.spec)
+			if err == nil || !strings.Contains(err.Error(), c.want) || KindOf(err) != KindInvalidSpec {
+				t.Fatalf("want invalid_spec containing %q, got %v", c.want, err)
+			}
+		})
+	}
+}
+
+// #endregion
@@ -0,0 +1,208 @@
+package httpsource
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JWTBearerAuth : paramètres du mode oauth2_jwt_bearer (RFC 7523), celui des
+// comptes de service Google et du flux JWT de Salesforce. Une assertion JWT signée
+// avec la clé privée du compte est échangée contre un access_token à auth.tokenUrl ;
+// le token est ensuite géré comme celui des autres modes oauth2_* (cache, 401).
+//
+// Toutes les valeurs sont des templates, la clé venant des credentials
+// ({{credentials.private_key}} pour le JSON d'un compte de service Google).
+//
+// auth.scopes et auth.audience servent ici aussi, mais dans l'assertion : claim
+// `scope` (Google) et claim `aud`, défaut auth.tokenUrl, ce qu'attend Google ;
+// Salesforce attend https://login.salesforce.com.
+type JWTBearerAuth struct {
+	// Issuer (iss) : l'email du compte de service, le client_id Salesforce.
+	Issuer string `json:"issuer"`
+	// Subject (sub) : l'utilisateur impersonné (délégation Google, utilisateur
+	// Salesforce). Optionnel.
+	Subject string `json:"subject"`
+
+	// KeyID (en-tête kid) : private_key_id d'un compte de service Google. Optionnel.
+	KeyID string `json:"keyId"`
+	// PrivateKey : clé PEM, PKCS#8, PKCS#1 (RSA) ou SEC 1 (EC).
+	PrivateKey string `json:"privateKey"`
+	// Algorithm : RS256 ou ES256. Défaut : déduit du type de la clé.
+	Algorithm string `json:"algorithm"`
+
+	// ExpiresInSeconds : durée de vie de l'assertion (défaut 180). Salesforce refuse
+	// une exp à plus de 3 minutes, Google accepte jusqu'à 3600. Elle ne sert qu'à
+	// l'échange : le token obtenu a sa propre expiration.
+	ExpiresInSeconds int `json:"expiresInSeconds"`
+}
+
+const (
+	JWTRS256 = "RS256"
+	JWTES256 = "ES256"
+
+	jwtBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+)
+
+// #region signAssertion
+// signAssertion construit et signe l'assertion JWT. Elle est ajoutée au rédacteur :
+// rejouable jusqu'à son exp, elle vaut un token. Comme une signature hmac, elle ne
+// sert qu'à UN échange : AddRecent, pour ne pas accumuler celles d'un long run.
+func (a *authenticator) signAssertion(tokenURL string) (string, error) {
+	cfg := a.spec.Auth.JWT
+
+	rendered := make(map[string]string, 4)
+	for name, tmpl := range map[string]string{
+		"issuer": cfg.Issuer, "subject": cfg.Subject, "keyId": cfg.KeyID, "privateKey": cfg.PrivateKey,
+	} {
+		if tmpl == "" {
+			continue
+		}
+		v, err := Render(tmpl, a.vars)
+		if err != nil {
+			return "", newErr(KindInvalidSpec, 0, err, "auth.jwt.%s cannot be rendered", name)
+		}
+		rendered[name] = v
+	}
+	audience := a.spec.Auth.Audience
+	if audience == "" {
+		audience = tokenURL
+	}
+
+	key, alg, err := parseSigningKey(rendered["privateKey"], cfg.Algorithm)
+	if err != nil {
+		// Jamais la clé dans le message : seulement ce qui cloche.
+		return "", newErr(KindAuth, 0, nil, "auth.jwt.privateKey: %s", err)
+	}
+
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if rendered["keyId"] != "" {
+		header["kid"] = rendered["keyId"]
+	}
+	now := a.now()
+	claims := map[string]any{
+		"iss": rendered["issuer"],
+		"aud": audience,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Duration(cfg.ExpiresInSeconds) * time.Second).Unix(),
+	}
+	if rendered["subject"] != "" {
+		claims["sub"] = rendered["subject"]
+	}
+	if scopes := a.spec.Auth.Scopes; len(scopes) > 0 {
+		claims["scope"] = strings.Join(scopes, " ")
+	}
+
+	encodedHeader, _ := json.Marshal(header)
+	encodedClaims, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(encodedHeader) + "." + base64.RawURLEncoding.EncodeToString(encodedClaims)
+
+	signature, err := signJWT(key, signingInput)
+	if err != nil {
+		return "", newErr(KindAuth, 0, err, "auth.jwt: cannot sign the assertion")
+	}
+	assertion := signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+	a.redactor.AddRecent(assertion)
+	return assertion, nil
+}
+
+// #endregion
+
+// #region parseSigningKey
+// parseSigningKey lit la clé PEM et en déduit l'algorithme, ou vérifie celui de la
+// spec. Une clé collée avec des "\n" littéraux (JSON d'un compte de service recopié
+// tel quel) est acceptée.
+func parseSigningKey(raw, algorithm string) (crypto.Signer, string, error) {
+	if !strings.Contains(raw, "\n") {
+		raw = strings.ReplaceAll(raw, `\n`, "\n")
+	}
+	block, _ := pem.Decode([]byte(raw))
+	if block == nil {
+		return nil, "", fmt.Errorf("not a PEM private key")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot parse the %s block", block.Type)
+	}
+
+	var alg string
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		alg = JWTRS256
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, "", fmt.Errorf("ES256 requires a P-256 key, got %s", k.Curve.Params().Name)
+		}
+		alg = JWTES256
+	default:
+		return nil, "", fmt.Errorf("unsupported key type %T (RSA or EC P-256)", key)
+	}
+	if algorithm != "" && algorithm != alg {
+		return nil, "", fmt.Errorf("algorithm %s does not match the key (%s)", algorithm, alg)
+	}
+	return key.(crypto.Signer), alg, nil
+}
+
+// #endregion
+
+// #region signJWT
+// signJWT signe au format JWS : PKCS#1 v1.5 pour RS256, r||s sur 64 octets (et non
+// DER) pour ES256.
+func signJWT(key crypto.Signer, signingInput string) ([]byte, error) {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		out := make([]byte, 64)
+		r.FillBytes(out[:32])
+		s.FillBytes(out[32:])
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
+
+// #endregion
+
+// #region validateJWTBearer
+func (s *Spec) validateJWTBearer() error {
+	cfg := s.Auth.JWT
+	if s.Auth.TokenURL == "" || cfg == nil || cfg.Issuer == "" || cfg.PrivateKey == "" {
+		return newErr(KindInvalidSpec, 0, nil,
+			"auth.tokenUrl, auth.jwt.issuer and auth.jwt.privateKey are required with mode %s", AuthOAuth2JWTBearer)
+	}
+	if cfg.Algorithm != "" && cfg.Algorithm != JWTRS256 && cfg.Algorithm != JWTES256 {
+		return newErr(KindInvalidSpec, 0, nil, "auth.jwt.algorithm %q is not supported (%s or %s)", cfg.Algorithm, JWTRS256, JWTES256)
+	}
+	if cfg.ExpiresInSeconds < 0 {
+		return newErr(KindInvalidSpec, 0, nil, "auth.jwt.expiresInSeconds cannot be negative")
+	}
+	return nil
+}
+
+// #endregion
@@ -0,0 +1,282 @@
+package httpsource
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jwtTestKeys : une clé RSA en PKCS#8 (format des comptes de service Google) et une
+// clé EC P-256 en SEC 1.
+func jwtTestKeys(t *testing.T) (rsaKey *rsa.PrivateKey, rsaPEM string, ecKey *ecdsa.PrivateKey, ecPEM string) {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(rsaKey)
+	rsaPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ = x509.MarshalECPrivateKey(ecKey)
+	ecPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	return rsaKey, rsaPEM, ecKey, ecPEM
+}
+
+// verifyAssertion vérifie la signature comme le provider (si public est fourni) et
+// renvoie en-tête et claims.
+func verifyAssertion(t *testing.T, assertion string, public crypto.PublicKey) (header, claims map[string]any) {
+	t.Helper()
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		t.Fatalf("assertion is not a JWS compact token: %q", assertion)
+	}
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("RS256 signature: %v", err)
+		}
+	case *ecdsa.PublicKey:
+		if len(signature) != 64 || !ecdsa.Verify(k, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])) {
+			t.Errorf("ES256 signature is invalid (%d bytes)", len(signature))
+		}
+	}
+	rawHeader, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	rawClaims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		t.Fatalf("header: %v", err)
+	}
+	if err := json.Unmarshal(rawClaims, &claims); err != nil {
+		t.Fatalf("claims: %v", err)
+	}
+	return header, claims
+}
+
+// #region TestFetch_JWTBearerExchangesSignedAssertion
+func TestFetch_JWTBearerExchangesSignedAssertion(t *testing.T) {
+	rsaKey, rsaPEM, ecKey, ecPEM := jwtTestKeys(t)
+	cases := []struct {
+		name, key string
+		public    crypto.PublicKey
+		alg       string
+	}{
+		// Clé recopiée depuis le JSON du compte de service, "\n" littéraux compris.
+		{"RS256 service account", strings.ReplaceAll(rsaPEM, "\n", `\n`), &rsaKey.PublicKey, JWTRS256},
+		{"ES256", ecPEM, &ecKey.PublicKey, JWTES256},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tokenCalls := 0
+			var srv *httptest.Server
+			srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/token" {
+					tokenCalls++
+					_ = r.ParseForm()
+					if r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+						t.Errorf("grant_type: got %q", r.Form.Get("grant_type"))
+					}
+					// Les scopes sont dans l'assertion, pas en paramètre du formulaire.
+					if r.Form.Has("scope") {
+						t.Errorf("scope must only be a claim, got form %v", r.Form)
+					}
+					header, claims := verifyAssertion(t, r.Form.Get("assertion"), c.public)
+					if header["alg"] != c.alg || header["kid"] != "key-1" {
+						t.Errorf("header: %v", header)
+					}
+					iat := time.Date(2026, 8, 12, 3, 0, 0, 0, time.UTC).Unix()
+					if claims["iss"] != "sa@project.iam.gserviceaccount.com" || claims["sub"] != "admin@acme.com" ||
+						claims["aud"] != srv.URL+"/token" || claims["scope"] != "ads.read reports.read" ||
+						claims["iat"] != float64(iat) || claims["exp"] != float64(iat+180) {
+						t.Errorf("claims: %v", claims)
+					}
+					fmt.Fprint(w, `{"access_token":"AT-jwt","expires_in":3600}`)
+					return
+				}
+				if r.Header.Get("Authorization") != "Bearer AT-jwt" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				fmt.Fprint(w, `{"data":[{"id":1},{"id":2}]}`)
+			}))
+			defer srv.Close()
+
+			spec := mustSpec(t, map[string]any{
+				"source": map[string]any{"url": srv.URL + "/data"},
+				"auth": map[string]any{
+					"mode": AuthOAuth2JWTBearer, "tokenUrl": srv.URL + "/token",
+					"scopes": []any{"ads.read", "reports.read"},
+					"jwt": map[string]any{
+						"issuer": "{{credentials.client_email}}", "subject": "admin@acme.com",
+						"keyId": "{{credentials.private_key_id}}", "privateKey": "{{credentials.private_key}}",
+					},
+				},
+				"records": map[string]any{"path": "data"},
+			})
+			vars := Vars{Date: "2026-08-12", Credentials: map[string]any{
+				"client_email": "sa@project.iam.gserviceaccount.com", "private_key_id": "key-1", "private_key": c.key,
+			}}
+
+			var slept []time.Duration
+			rows, _ := collect(t, clockEngine(&slept), spec, vars)
+			if len(rows) != 2 || tokenCalls != 1 {
+				t.Errorf("rows=%d tokenCalls=%d", len(rows), tokenCalls)
+			}
+		})
+	}
+}
+
+// #endregion
+
+// #region TestFetchToken_JWTBearerRedactsAssertionAndToken
+func TestFetchToken_JWTBearerRedactsAssertionAndToken(t *testing.T) {
+	_, rsaPEM, _, _ := jwtTestKeys(t)
+	var assertion string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		assertion = r.Form.Get("assertion")
+		fmt.Fprint(w, `{"access_token":"AT-secret-token","expires_in":3600}`)
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source": map[string]any{"url": "https://api.example.com/data"},
+		"auth": map[string]any{"mode": AuthOAuth2JWTBearer, "tokenUrl": srv.URL, "audience": "https://login.salesforce.com",
+			"jwt": map[string]any{"issuer": "client-id", "privateKey": "{{credentials.key}}"}},
+	})
+	vars := Vars{Credentials: map[string]any{"key": rsaPEM}}
+	redactor := NewRedactor(vars.Credentials)
+	a := newAuthenticator(spec, vars, srv.Client(), redactor)
+
+	token, err := a.ensureToken(context.Background())
+	if err != nil || token != "AT-secret-token" {
+		t.Fatalf("token=%q err=%v", token, err)
+	}
+	if _, claims := verifyAssertion(t, assertion, nil); claims["aud"] != "https://login.salesforce.com" || claims["sub"] != nil || claims["scope"] != nil {
+		t.Errorf("claims: %v", claims)
+	}
+	got := redactor.String("token endpoint got " + assertion + " and returned " + token)
+	if strings.Contains(got, assertion) || strings.Contains(got, token) {
+		t.Errorf("assertion and token must be redacted: %q", got)
+	}
+}
+
+// #endregion
+
+// #region TestEnsureToken_ExpiryFollowsTheClock
+// Le cache du token suit l'horloge de l'Engine, comme la signature de l'assertion.
+func TestEnsureToken_ExpiryFollowsTheClock(t *testing.T) {
+	_, rsaPEM, _, _ := jwtTestKeys(t)
+	tokenCalls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenCalls++
+		fmt.Fprintf(w, `{"access_token":"AT-%d","expires_in":3600}`, tokenCalls)
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source": map[string]any{"url": "https://api.example.com/data"},
+		"auth": map[string]any{"mode": AuthOAuth2JWTBearer, "tokenUrl": srv.URL,
+			"jwt": map[string]any{"issuer": "client-id", "privateKey": "{{credentials.key}}"}},
+	})
+	vars := Vars{Credentials: map[string]any{"key": rsaPEM}}
+	a := newAuthenticator(spec, vars, srv.Client(), NewRedactor(vars.Credentials))
+	clock := time.Date(2026, 8, 12, 3, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return clock }
+
+	for _, step := range []struct {
+		advance time.Duration
+		want    string
+	}{
+		{0, "AT-1"},
+		{3500 * time.Second, "AT-1"},
+		// Moins de 30 s avant l'expiration : renouvelé.
+		{80 * time.Second, "AT-2"},
+	} {
+		clock = clock.Add(step.advance)
+		token, err := a.ensureToken(context.Background())
+		if err != nil || token != step.want {
+			t.Fatalf("after %s: token=%q err=%v, want %s", step.advance, token, err, step.want)
+		}
+	}
+}
+
+// #endregion
+
+// #region TestSignAssertion_KeyErrors
+// Une clé illisible ou du mauvais type est une erreur d'AUTH (les credentials sont à
+// corriger), dont le message ne cite jamais la clé.
+func TestSignAssertion_KeyErrors(t *testing.T) {
+	_, rsaPEM, _, _ := jwtTestKeys(t)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	der, _ := x509.MarshalECPrivateKey(p384)
+	p384PEM := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+
+	cases := []struct {
+		key, algorithm, want string
+	}{
+		{"not a key at all", "", "not a PEM private key"},
+		{rsaPEM, "es256", "algorithm ES256 does not match the key (RS256)"},
+		{p384PEM, "", "ES256 requires a P-256 key, got P-384"},
+	}
+	for _, c := range cases {
+		spec := mustSpec(t, map[string]any{
+			"source": map[string]any{"url": "https://api.example.com/data"},
+			"auth": map[string]any{"mode": AuthOAuth2JWTBearer, "tokenUrl": "https://auth.example.com/token",
+				"jwt": map[string]any{"issuer": "iss", "privateKey": "{{credentials.key}}", "algorithm": c.algorithm}},
+		})
+		vars := Vars{Credentials: map[string]any{"key": c.key}}
+		a := newAuthenticator(spec, vars, http.DefaultClient, NewRedactor(vars.Credentials))
+
+		_, err := a.signAssertion("https://auth.example.com/token")
+		if err == nil || KindOf(err) != KindAuth || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("want an auth error %q, got %v", c.want, err)
+		}
+		if err != nil && strings.Contains(err.Error(), "PRIVATE KEY") {
+			t.Errorf("the key leaked in the error: %v", err)
+		}
+	}
+}
+
+// #endregion
+
+// #region TestValidate_JWTBearer
+func TestValidate_JWTBearer(t *testing.T) {
+	cases := []struct {
+		auth map[string]any
+		want string
+	}{
+		{map[string]any{"jwt": map[string]any{"issuer": "i", "privateKey": "k"}}, "auth.tokenUrl, auth.jwt.issuer and auth.jwt.privateKey are required"},
+		{map[string]any{"tokenUrl": "https://t"}, "are required"},
+		{map[string]any{"tokenUrl": "https://t", "jwt": map[string]any{"issuer": "i", "privateKey": "k", "algorithm": "HS256"}}, "auth.jwt.algorithm"},
+		{map[string]any{"tokenUrl": "https://t", "jwt": map[string]any{"issuer": "i", "privateKey": "{{credentials.key|nope}}"}}, "auth.jwt.privateKey"},
+	}
+	for _, c := range cases {
+		c.auth["mode"] = AuthOAuth2JWTBearer
+		_, err := ParseSpec(map[string]any{"source": map[string]any{"url": "https://x.com"}, "auth": c.auth})
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%v: want %q, got %v", c.auth, c.want, err)
+		}
+	}
+}
+
+// #endregion
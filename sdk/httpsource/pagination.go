@@ -34,6 +34,19 @@ func newPaginator(spec *Pagination) *paginator {
 
 // #endregion
 
+// #region pagerAt
+// pagerAt renvoie le paginateur positionné sur la k-ième page (0 = la première),
+// sans avoir lu les précédentes. Types page et offset seulement (cf Concurrency).
+func pagerAt(spec *Pagination, k int) *paginator {
+	return &paginator{
+		spec:   spec,
+		page:   spec.StartAt + k,
+		offset: k * spec.Size,
+	}
+}
+
+// #endregion
+
 // #region applyTo
 // applyTo pose les paramètres de pagination sur la query de la requête courante.
 // Ne touche à rien pour la première page en mode cursor/next_url : le curseur n'existe
@@ -61,6 +74,17 @@ func (p *paginator) applyTo(query map[string]string) {
 
 // #endregion
 
+// #region applyToVariables
+// applyToVariables est l'équivalent d'applyTo pour une source GraphQL : le curseur
+// Relay va dans les variables, pas dans l'URL. Rien à la première page (after: null).
+func (p *paginator) applyToVariables(variables map[string]any) {
+	if p.spec.Type == PageRelay && p.cursor != "" {
+		variables[p.spec.Param] = p.cursor
+	}
+}
+
+// #endregion
+
 // #region advance
 // advance calcule l'état de la page suivante depuis la réponse reçue et indique s'il
 // faut continuer.
@@ -126,6 +150,23 @@ func (p *paginator) advance(parsed any, rowCount int, header http.Header) bool {
 		p.cursor = next
 		return true
 
+	case PageRelay:
+		// hasNextPage fait foi : endCursor est souvent renseigné sur la dernière page.
+		hasNext, _ := navigateOptional(parsed, p.spec.PageInfoPath+".hasNextPage")
+		if !toBool(hasNext) {
+			return false
+		}
+		cursor, ok := navigateOptional(parsed, p.spec.PageInfoPath+".endCursor")
+		if !ok {
+			return false
+		}
+		next := stringify(cursor)
+		if next == "" || next == p.cursor {
+			return false
+		}
+		p.cursor = next
+		return true
+
 	case PageNextURL:
 		next, ok := navigateOptional(parsed, p.spec.NextURLPath)
 		if !ok {
@@ -0,0 +1,253 @@
+package httpsource
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit : débit maximal annoncé par l'API, appliqué AVANT d'envoyer la requête.
+// Le retry sur 429 (On429) reste le filet de sécurité ; ce bloc évite d'y tomber, ce
+// qui compte sur les API qui pénalisent un 429 par une fenêtre de blocage.
+//
+// Le seau de jetons est porté par l'Engine et partagé PAR HÔTE : deux Fetch
+// simultanés vers la même API (deux comptes, deux rapports) se partagent le quota,
+// comme côté API. Si deux specs annoncent des débits différents pour un même hôte,
+// le plus restrictif s'applique.
+type RateLimit struct {
+	// RequestsPerSecond / RequestsPerMinute : débit soutenu. Les deux peuvent être
+	// posés, le plus restrictif gagne.
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	RequestsPerMinute float64 `json:"requestsPerMinute"`
+
+	// Burst : requêtes envoyables d'affilée après une pause (défaut 1).
+	Burst int `json:"burst"`
+
+	// Adaptive : ralentit d'après le quota restant publié par l'API à chaque
+	// réponse, en étalant les requêtes restantes jusqu'à la remise à zéro. Quota
+	// épuisé = attente de la remise à zéro, plafonnée à MaxWaitSeconds.
+	Adaptive bool `json:"adaptive"`
+
+	// En-têtes lus en mode adaptatif (défauts X-RateLimit-Remaining et
+	// X-RateLimit-Reset), ou chemins dans un corps JSON, prioritaires sur les
+	// en-têtes comme retryAfterPath l'est sur Retry-After. Reset est un nombre de
+	// secondes avant la remise à zéro ou un timestamp epoch (secondes ou
+	// millisecondes, déduit de l'ordre de grandeur).
+	RemainingHeader string `json:"remainingHeader"`
+	ResetHeader     string `json:"resetHeader"`
+	RemainingPath   string `json:"remainingPath"`
+	ResetPath       string `json:"resetPath"`
+
+	// MaxWaitSeconds plafonne une attente adaptative (défaut 300) : un quota
+	// journalier épuisé ne doit pas immobiliser un worker jusqu'au lendemain, le
+	// 429 qui suivra sera traité par retry.on429.
+	MaxWaitSeconds int `json:"maxWaitSeconds"`
+}
+
+// #region rate
+// rate renvoie le débit soutenu en requêtes par seconde, 0 = pas de seau.
+func (r *RateLimit) rate() float64 {
+	rps := r.RequestsPerSecond
+	if perMinute := r.RequestsPerMinute / 60; perMinute > 0 && (rps <= 0 || perMinute < rps) {
+		rps = perMinute
+	}
+	return rps
+}
+
+// #endregion
+
+// hostLimiter est l'état de débit d'un hôte, partagé par toutes les requêtes de
+// l'Engine vers cet hôte. Les attentes sont RÉSERVÉES sous le verrou puis dormies
+// hors verrou : N requêtes parallèles obtiennent N créneaux successifs au lieu de
+// repartir toutes ensemble au réveil.
+type hostLimiter struct {
+	mu sync.Mutex
+
+	// Seau de jetons. tokens peut devenir négatif : c'est la dette des créneaux
+	// déjà réservés.
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	// Pacing adaptatif : jusqu'à paceUntil (remise à zéro annoncée), une requête au
+	// plus tous les pace, la prochaine pas avant nextSlot.
+	pace      time.Duration
+	paceUntil time.Time
+	nextSlot  time.Time
+}
+
+// #region limiterFor
+// limiterFor renvoie le limiteur de l'hôte, créé au premier appel. nil si la spec
+// n'a pas de bloc rateLimit.
+func (e *Engine) limiterFor(cfg *RateLimit, host string) *hostLimiter {
+	if cfg == nil {
+		return nil
+	}
+	e.limitersMu.Lock()
+	defer e.limitersMu.Unlock()
+
+	l, ok := e.limiters[host]
+	if !ok {
+		l = &hostLimiter{}
+		if e.limiters == nil {
+			e.limiters = make(map[string]*hostLimiter)
+		}
+		e.limiters[host] = l
+	}
+	l.tighten(cfg.rate(), float64(cfg.Burst))
+	return l
+}
+
+// #endregion
+
+// #region tighten
+// tighten applique le débit d'une spec si c'est le plus restrictif vu pour l'hôte.
+func (l *hostLimiter) tighten(rate, burst float64) {
+	if rate <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate == 0 || rate < l.rate {
+		l.rate = rate
+	}
+	if l.burst == 0 || burst < l.burst {
+		l.burst = burst
+		l.tokens = math.Min(l.tokens, burst)
+	}
+	if l.last.IsZero() {
+		l.tokens = l.burst
+	}
+}
+
+// #endregion
+
+// #region reserve
+// reserve réserve le créneau de la prochaine requête et renvoie l'attente avant de
+// l'envoyer.
+func (l *hostLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var wait time.Duration
+	if l.rate > 0 {
+		if !l.last.IsZero() && now.After(l.last) {
+			l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+		}
+		if now.After(l.last) {
+			l.last = now
+		}
+		l.tokens--
+		if l.tokens < 0 {
+			wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+		}
+	}
+
+	if now.Before(l.paceUntil) {
+		slot := l.nextSlot
+		if slot.Before(now) {
+			slot = now
+		}
+		if d := slot.Sub(now); d > wait {
+			wait = d
+		}
+		l.nextSlot = slot.Add(l.pace)
+	}
+	return wait
+}
+
+// #endregion
+
+// #region observe
+// observe met à jour le pacing d'après le quota publié dans une réponse.
+func (l *hostLimiter) observe(now time.Time, remaining int, reset time.Time, maxWait time.Duration) {
+	if reset.After(now.Add(maxWait)) {
+		reset = now.Add(maxWait)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.paceUntil = reset
+	if remaining <= 0 {
+		// Quota épuisé : plus rien avant la remise à zéro.
+		l.pace = 0
+		l.nextSlot = reset
+		return
+	}
+	l.pace = reset.Sub(now) / time.Duration(remaining)
+	if slot := now.Add(l.pace); slot.After(l.nextSlot) {
+		l.nextSlot = slot
+	}
+}
+
+// #endregion
+
+// #region quotaFromResponse
+// quotaFromResponse lit le quota restant et l'instant de remise à zéro. ok=false si
+// l'un des deux manque : sans les deux, impossible d'étaler quoi que ce soit.
+func quotaFromResponse(cfg *RateLimit, header http.Header, body []byte, now time.Time) (remaining int, reset time.Time, ok bool) {
+	var parsed any
+	if (cfg.RemainingPath != "" || cfg.ResetPath != "") && len(body) > 0 {
+		// Second décodage du corps, seulement si des chemins sont configurés : le
+		// premier (decodeForPagination) n'a lieu que pour un succès JSON.
+		_ = json.Unmarshal(body, &parsed)
+	}
+
+	rawRemaining, okRemaining := quotaValue(parsed, cfg.RemainingPath, header, cfg.RemainingHeader)
+	rawReset, okReset := quotaValue(parsed, cfg.ResetPath, header, cfg.ResetHeader)
+	if !okRemaining || !okReset {
+		return 0, time.Time{}, false
+	}
+
+	remaining = int(rawRemaining)
+	switch {
+	case rawReset > 1e12:
+		reset = time.UnixMilli(int64(rawReset))
+	case rawReset > 1e9:
+		reset = time.Unix(int64(rawReset), 0)
+	default:
+		reset = now.Add(time.Duration(rawReset * float64(time.Second)))
+	}
+	return remaining, reset, true
+}
+
+// #endregion
+
+// #region quotaValue
+func quotaValue(parsed any, path string, header http.Header, name string) (float64, bool) {
+	if path != "" && parsed != nil {
+		if v, ok := navigateOptional(parsed, path); ok {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(stringify(v)), 64); err == nil {
+				return f, true
+			}
+		}
+	}
+	if name != "" {
+		if raw := header.Get(name); raw != "" {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err == nil {
+				return f, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// #endregion
+
+// #region observeQuota
+func (e *Engine) observeQuota(l *hostLimiter, cfg *RateLimit, header http.Header, body []byte) {
+	if l == nil || !cfg.Adaptive {
+		return
+	}
+	now := e.now()
+	if remaining, reset, ok := quotaFromResponse(cfg, header, body, now); ok {
+		l.observe(now, remaining, reset, time.Duration(cfg.MaxWaitSeconds)*time.Second)
+	}
+}
+
+// #endregion
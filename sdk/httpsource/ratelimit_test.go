@@ -0,0 +1,207 @@
+package httpsource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clockEngine : fastEngine dont l'horloge avance du temps "dormi", pour que le seau
+// de jetons se remplisse entre deux requêtes sans attendre pour de vrai.
+func clockEngine(slept *[]time.Duration) *Engine {
+	clock := time.Date(2026, 8, 12, 3, 0, 0, 0, time.UTC)
+	e := New(WithSleeper(func(_ context.Context, d time.Duration) error {
+		*slept = append(*slept, d)
+		clock = clock.Add(d)
+		return nil
+	}))
+	e.now = func() time.Time { return clock }
+	return e
+}
+
+// #region TestHostLimiter_TokenBucket
+func TestHostLimiter_TokenBucket(t *testing.T) {
+	l := &hostLimiter{}
+	l.tighten(2, 2)
+	t0 := time.Date(2026, 8, 12, 0, 0, 0, 0, time.UTC)
+
+	// Burst de 2, puis un créneau toutes les 500 ms, réservés à la suite.
+	var got []time.Duration
+	for i := 0; i < 4; i++ {
+		got = append(got, l.reserve(t0))
+	}
+	want := []time.Duration{0, 0, 500 * time.Millisecond, time.Second}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("waits: got %v, want %v", got, want)
+	}
+
+	// 10 s plus tard le seau est plein, mais jamais au-delà du burst.
+	later := t0.Add(10 * time.Second)
+	if a, b, c := l.reserve(later), l.reserve(later), l.reserve(later); a != 0 || b != 0 || c != 500*time.Millisecond {
+		t.Errorf("after refill: %v %v %v", a, b, c)
+	}
+
+	// Une spec plus permissive sur le même hôte ne desserre pas la limite.
+	l.tighten(100, 50)
+	if l.rate != 2 || l.burst != 2 {
+		t.Errorf("the most restrictive limit must win: rate=%v burst=%v", l.rate, l.burst)
+	}
+}
+
+// #endregion
+
+// #region TestFetch_RateLimitSharedPerHost
+func TestFetch_RateLimitSharedPerHost(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1}]`)
+	})
+	apiA, apiB := httptest.NewServer(handler), httptest.NewServer(handler)
+	defer apiA.Close()
+	defer apiB.Close()
+
+	spec := func(url string) *Spec {
+		return mustSpec(t, map[string]any{
+			"source":    map[string]any{"url": url},
+			"rateLimit": map[string]any{"requestsPerMinute": 30},
+		})
+	}
+
+	var slept []time.Duration
+	e := clockEngine(&slept)
+	collect(t, e, spec(apiA.URL), Vars{})
+	collect(t, e, spec(apiB.URL), Vars{})
+	if len(slept) != 0 {
+		t.Fatalf("first request to each host must not wait: %v", slept)
+	}
+
+	// Second Fetch vers A : le quota de A est partagé entre les Fetch de l'Engine.
+	collect(t, e, spec(apiA.URL), Vars{})
+	if len(slept) != 1 || slept[0] != 2*time.Second {
+		t.Errorf("30 rpm = one request every 2 s: %v", slept)
+	}
+}
+
+// #endregion
+
+// #region TestFetch_RateLimitAdaptive
+func TestFetch_RateLimitAdaptive(t *testing.T) {
+	t.Run("headers", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("page") {
+			case "1":
+				// 2 requêtes restantes sur 10 s : une toutes les 5 s.
+				w.Header().Set("X-RateLimit-Remaining", "2")
+				w.Header().Set("X-RateLimit-Reset", "10")
+				fmt.Fprint(w, `[{"id":1}]`)
+			case "2":
+				// Quota épuisé, remise à zéro dans 7 s.
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", "7")
+				fmt.Fprint(w, `[{"id":2}]`)
+			default:
+				fmt.Fprint(w, `[]`)
+			}
+		}))
+		defer srv.Close()
+
+		var slept []time.Duration
+		rows, _ := collect(t, clockEngine(&slept), mustSpec(t, map[string]any{
+			"source":     map[string]any{"url": srv.URL},
+			"pagination": map[string]any{"type": "page"},
+			"rateLimit":  map[string]any{"adaptive": true},
+		}), Vars{})
+
+		if len(rows) != 2 || fmt.Sprint(slept) != "[5s 7s]" {
+			t.Errorf("rows=%d slept=%v", len(rows), slept)
+		}
+	})
+
+	t.Run("body paths and cap", func(t *testing.T) {
+		var slept []time.Duration
+		e := clockEngine(&slept)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Quota journalier épuisé : remise à zéro (epoch) dans 20 h.
+			fmt.Fprintf(w, `{"data":[{"id":1}],"meta":{"quota":{"left":0,"resetAt":%d}},"next":"%s"}`,
+				e.now().Add(20*time.Hour).Unix(), r.URL.Query().Get("cursor")+"x")
+		}))
+		defer srv.Close()
+
+		collect(t, e, mustSpec(t, map[string]any{
+			"source":     map[string]any{"url": srv.URL},
+			"pagination": map[string]any{"type": "cursor", "cursorPath": "next", "maxPages": 2},
+			"records":    map[string]any{"path": "data"},
+			"rateLimit": map[string]any{
+				"adaptive": true, "remainingPath": "meta.quota.left", "resetPath": "meta.quota.resetAt", "maxWaitSeconds": 120,
+			},
+		}), Vars{})
+
+		if fmt.Sprint(slept) != "[2m0s]" {
+			t.Errorf("the wait must be capped by rateLimit.maxWaitSeconds: %v", slept)
+		}
+	})
+}
+
+// #endregion
+
+// #region TestFetch_RateLimitWaitsBeforeSigning
+// La signature porte l'instant d'ENVOI, pas celui où la requête a été construite :
+// une attente de débit de 2 min ne doit pas faire partir une signature vieille de 2 min.
+func TestFetch_RateLimitWaitsBeforeSigning(t *testing.T) {
+	var timestamps []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timestamps = append(timestamps, r.Header.Get("X-Timestamp"))
+		if r.URL.Query().Get("page") == "3" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `[{"id":1}]`)
+	}))
+	defer srv.Close()
+
+	var slept []time.Duration
+	collect(t, clockEngine(&slept), mustSpec(t, map[string]any{
+		"source":     map[string]any{"url": srv.URL},
+		"pagination": map[string]any{"type": "page"},
+		"rateLimit":  map[string]any{"requestsPerMinute": 0.5},
+		"auth": map[string]any{"mode": "hmac", "hmac": map[string]any{
+			"key": "{{credentials.secret}}", "stringToSign": "{{request.timestamp}}", "timestampHeader": "X-Timestamp",
+		}},
+	}), Vars{Credentials: map[string]any{"secret": "shh-secret"}})
+
+	// Horloge de départ 2026-08-12T03:00:00Z, puis une requête toutes les 2 min.
+	if fmt.Sprint(slept) != "[2m0s 2m0s]" || strings.Join(timestamps, ",") != "1786503600,1786503720,1786503840" {
+		t.Errorf("slept=%v timestamps=%v", slept, timestamps)
+	}
+}
+
+// #endregion
+
+// #region TestValidate_RateLimit
+func TestValidate_RateLimit(t *testing.T) {
+	_, err := ParseSpec(map[string]any{
+		"source":    map[string]any{"url": "https://x.com"},
+		"rateLimit": map[string]any{"burst": 5},
+	})
+	if err == nil || !strings.Contains(err.Error(), "rateLimit needs") {
+		t.Errorf("a rate limit without rate nor adaptive does nothing: %v", err)
+	}
+
+	spec := mustSpec(t, map[string]any{
+		"source":    map[string]any{"url": "https://x.com"},
+		"rateLimit": map[string]any{"requestsPerSecond": 10, "requestsPerMinute": 120, "adaptive": true},
+		"child":     map[string]any{"source": map[string]any{"url": "https://x.com/{{parent.id}}"}},
+	})
+	r := spec.RateLimit
+	if r.rate() != 2 || r.Burst != 1 || r.ResetHeader != "X-RateLimit-Reset" || r.MaxWaitSeconds != 300 {
+		t.Errorf("defaults: %+v", r)
+	}
+	if spec.Child.RateLimit == nil || spec.Child.RateLimit == r {
+		t.Errorf("the child must inherit a copy of the rate limit")
+	}
+}
+
+// #endregion
@@ -1,15 +1,19 @@
 package httpsource
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"io"
 	"strings"
 )
 
 // #region extractRecords
 // extractRecords transforme un corps de réponse en lignes prêtes à l'upsert.
 //
-// Le pipeline est : parse (json/csv) → navigation vers records.path → explode →
+// Le pipeline est : parse (json/jsonl/csv/xml) → navigation vers records.path → explode →
 // inject. L'ordre compte : `inject` s'applique APRÈS l'explode pour que chaque ligne
 // produite porte bien les champs injectés (sinon une commande à 3 articles aurait la
 // date sur une seule de ses 3 lignes).
@@ -20,6 +24,10 @@ func extractRecords(body []byte, spec *Spec, vars Vars) ([]map[string]any, error
 	switch spec.Records.Format {
 	case FormatCSV:
 		rows, err = parseCSV(body, spec.Records.CSV)
+	case FormatJSONL:
+		rows, err = parseJSONLRecords(body, spec.Records.Path)
+	case FormatXML:
+		rows, err = parseXMLRecords(body, spec.Records.Path, spec.Records.XML)
 	default:
 		rows, err = parseJSONRecords(body, spec.Records.Path)
 	}
@@ -27,23 +35,42 @@ func extractRecords(body []byte, spec *Spec, vars Vars) ([]map[string]any, error
 		return nil, err
 	}
 
+	injected, err := renderInject(spec, vars)
+	if err != nil {
+		return nil, err
+	}
+	return shapeRows(rows, spec, injected), nil
+}
+
+// #endregion
+
+// #region renderInject
+// renderInject rend records.inject une fois par page (et non par ligne).
+func renderInject(spec *Spec, vars Vars) (map[string]string, error) {
+	if len(spec.Records.Inject) == 0 {
+		return nil, nil
+	}
+	injected, err := RenderMap(spec.Records.Inject, vars)
+	if err != nil {
+		return nil, newErr(KindInvalidSpec, 0, err, "records.inject cannot be rendered")
+	}
+	return injected, nil
+}
+
+// #endregion
+
+// #region shapeRows
+// shapeRows applique explode puis inject à des lignes extraites.
+func shapeRows(rows []map[string]any, spec *Spec, injected map[string]string) []map[string]any {
 	if spec.Records.Explode != "" {
 		rows = explodeRows(rows, spec.Records.Explode, *spec.Records.EmitWhenExplodeEmpty)
 	}
-
-	if len(spec.Records.Inject) > 0 {
-		injected, err := RenderMap(spec.Records.Inject, vars)
-		if err != nil {
-			return nil, newErr(KindInvalidSpec, 0, err, "records.inject cannot be rendered")
-		}
-		for _, row := range rows {
-			for k, v := range injected {
-				row[k] = v
-			}
+	for _, row := range rows {
+		for k, v := range injected {
+			row[k] = v
 		}
 	}
-
-	return rows, nil
+	return rows
 }
 
 // #endregion
@@ -73,6 +100,14 @@ func parseJSONRecords(body []byte, path string) ([]map[string]any, error) {
 			err.Error(), truncate(strings.TrimSpace(string(body)), 300))
 	}
 
+	return rowsFromTarget(target, path)
+}
+
+// #endregion
+
+// #region rowsFromTarget
+// rowsFromTarget normalise la valeur trouvée à records.path en liste de lignes.
+func rowsFromTarget(target any, path string) ([]map[string]any, error) {
 	switch t := target.(type) {
 	case nil:
 		// Chemin présent mais null : pas de donnée pour cette date, ce n'est pas une
@@ -102,6 +137,76 @@ func parseJSONRecords(body []byte, path string) ([]map[string]any, error) {
 
 // #endregion
 
+// #region parseJSONLRecords
+// parseJSONLRecords lit un corps JSON Lines : un document par ligne, lignes vides
+// ignorées. records.path est appliqué à CHAQUE ligne (un export qui enveloppe chaque
+// ligne dans {"node": {...}} se lit avec path: node) ; une ligne qui y porte un
+// tableau produit une ligne par élément.
+//
+// Une ligne illisible est fatale et citée par son numéro : sauter la ligne perdrait
+// une donnée en silence, et sans numéro impossible de la retrouver dans un export de
+// 2 Go.
+func parseJSONLRecords(body []byte, path string) ([]map[string]any, error) {
+	var rows []map[string]any
+	reader := bufio.NewReader(bytes.NewReader(body))
+
+	for lineNo := 1; ; lineNo++ {
+		// ReadBytes et non bufio.Scanner : le Scanner plafonne une ligne à 64 Ko, une
+		// commande Shopify avec ses lignes d'article les dépasse.
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, newErr(KindInvalidData, 0, readErr, "records (jsonl): cannot read line %d", lineNo)
+		}
+
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			var parsed any
+			if err := json.Unmarshal(trimmed, &parsed); err != nil {
+				return nil, newErr(KindInvalidData, 0, err, "records (jsonl): line %d is not valid JSON (first bytes: %q)",
+					lineNo, truncate(string(trimmed), 120))
+			}
+			target, err := navigatePath(parsed, path)
+			if err == nil {
+				var lineRows []map[string]any
+				if lineRows, err = rowsFromTarget(target, path); err == nil {
+					rows = append(rows, lineRows...)
+				}
+			}
+			if err != nil {
+				message := err.Error()
+				var srcErr *Error
+				if errors.As(err, &srcErr) {
+					message = srcErr.Message
+				}
+				return nil, newErr(KindOf(err), 0, nil, "records (jsonl): line %d: %s", lineNo, message)
+			}
+		}
+
+		if readErr == io.EOF {
+			return rows, nil
+		}
+	}
+}
+
+// #endregion
+
+// #region lastJSONLine
+// lastJSONLine décode la dernière ligne non vide d'un corps JSON Lines : c'est elle
+// que lit la pagination d'un jsonl (cursorPath: id pour reprendre après le dernier
+// élément reçu). Les API qui paginent par en-tête (link_header) n'en ont pas besoin.
+func lastJSONLine(body []byte) any {
+	trimmed := bytes.TrimRight(body, " \t\r\n")
+	if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+		trimmed = trimmed[i+1:]
+	}
+	var parsed any
+	if json.Unmarshal(bytes.TrimSpace(trimmed), &parsed) != nil {
+		return nil
+	}
+	return parsed
+}
+
+// #endregion
+
 // #region navigatePath
 // navigatePath descend un chemin pointé dans une structure JSON. Un path vide renvoie
 // la racine (cas d'une API qui répond directement un tableau).
@@ -0,0 +1,184 @@
+package httpsource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// #region TestFetch_JSONLWithExplodeInjectAndCursor
+// Export bulk : une commande par ligne (enveloppée dans `node`), lignes d'article
+// explosées, date injectée, curseur = id de la dernière ligne de la page.
+func TestFetch_JSONLWithExplodeInjectAndCursor(t *testing.T) {
+	var cursors []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cursors = append(cursors, r.URL.Query().Get("after"))
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		if r.URL.Query().Get("after") == "" {
+			fmt.Fprint(w, `{"node":{"id":"o1","items":[{"sku":"A"},{"sku":"B"}]}}`+"\n\n"+
+				`{"node":{"id":"o2","items":[]}}`+"\r\n")
+			return
+		}
+		fmt.Fprint(w, `{"node":{"id":"o2","items":[]}}`)
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source":     map[string]any{"url": srv.URL},
+		"pagination": map[string]any{"type": "cursor", "param": "after", "cursorPath": "node.id"},
+		"records": map[string]any{
+			"format":  "ndjson",
+			"path":    "node",
+			"explode": "items",
+			"inject":  map[string]any{"day": "{{date}}"},
+		},
+	})
+	if spec.Records.Format != FormatJSONL {
+		t.Fatalf("ndjson must be an alias of jsonl, got %q", spec.Records.Format)
+	}
+
+	rows, stats := collect(t, fastEngine(nil), spec, Vars{Date: "2026-08-12"})
+
+	// Page 2 renvoie le même curseur (o2) : arrêt, comme en json.
+	if stats.Pages != 2 || strings.Join(cursors, ",") != ",o2" {
+		t.Errorf("pages=%d cursors=%v", stats.Pages, cursors)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want 4: %v", len(rows), rows)
+	}
+	if rows[1]["items"].(map[string]any)["sku"] != "B" || rows[1]["day"] != "2026-08-12" {
+		t.Errorf("exploded row: %v", rows[1])
+	}
+	if rows[2]["id"] != "o2" {
+		t.Errorf("an order without items must be kept: %v", rows[2])
+	}
+}
+
+// #endregion
+
+// #region TestFetch_JSONLMalformedLine
+func TestFetch_JSONLMalformedLine(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "{\"id\":1}\n\n{\"id\":2\n{\"id\":3}\n")
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source":  map[string]any{"url": srv.URL},
+		"records": map[string]any{"format": "jsonl"},
+	})
+	_, err := fastEngine(nil).Fetch(context.Background(), spec, Vars{}, func(map[string]any) error { return nil })
+	if KindOf(err) != KindInvalidData || !strings.Contains(err.Error(), "line 3") {
+		t.Fatalf("expected an invalid_data error on line 3, got %v", err)
+	}
+}
+
+// #endregion
+
+// #region TestParseJSONLRecords_PathPerLine
+func TestParseJSONLRecords_PathPerLine(t *testing.T) {
+	rows, err := parseJSONLRecords([]byte(`{"data":[{"a":1},{"a":2}]}`+"\n"+`{"data":null}`), "data")
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("rows=%v err=%v", rows, err)
+	}
+
+	_, err = parseJSONLRecords([]byte(`{"data":[]}`+"\n"+`{"other":1}`), "data")
+	if KindOf(err) != KindInvalidData || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("a line without the path must be reported with its number, got %v", err)
+	}
+}
+
+// #endregion
+
+// #region TestParseXMLTree_Mapping
+func TestParseXMLTree_Mapping(t *testing.T) {
+	body := `<?xml version="1.0" encoding="ISO-8859-1"?>
+<report xmlns="urn:default" xmlns:x="urn:ext" version="2">
+  <row id="1"><name>Caf` + "\xe9" + `</name><x:tag>a</x:tag><x:tag>b</x:tag><empty/><price currency="EUR">12.5</price></row>
+  <row id="2"><name>Th</name><items><item sku="A"/></items></row>
+</report>`
+
+	tree, err := parseXMLTree([]byte(body), &XMLOptions{
+		Namespaces:    map[string]string{"ext": "urn:ext"},
+		ArrayElements: []string{"item"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := tree["report"].(map[string]any)
+	if report["@version"] != "2" {
+		t.Errorf("attribute: %v (xmlns declarations must be dropped: %v)", report["@version"], report)
+	}
+	if len(report) != 2 {
+		t.Errorf("report keys: %v", report)
+	}
+	rows := report["row"].([]any)
+	first := rows[0].(map[string]any)
+	if first["@id"] != "1" || first["name"] != "Café" || first["empty"] != nil {
+		t.Errorf("first row: %v", first)
+	}
+	if tags, ok := first["ext:tag"].([]any); !ok || len(tags) != 2 || tags[1] != "b" {
+		t.Errorf("namespaced repeated child: %v", first["ext:tag"])
+	}
+	if price := first["price"].(map[string]any); price["@currency"] != "EUR" || price["#text"] != "12.5" {
+		t.Errorf("text with attributes: %v", price)
+	}
+	// Un seul <item>, mais déclaré dans arrayElements : tableau quand même.
+	items := rows[1].(map[string]any)["items"].(map[string]any)["item"].([]any)
+	if len(items) != 1 || items[0].(map[string]any)["@sku"] != "A" {
+		t.Errorf("forced array: %v", items)
+	}
+
+	if _, err := parseXMLTree([]byte("<a><b></a>"), nil); KindOf(err) != KindInvalidData {
+		t.Errorf("malformed XML must be invalid_data, got %v", err)
+	}
+}
+
+// #endregion
+
+// #region TestFetch_XMLWithExplodeInjectAndCursor
+func TestFetch_XMLWithExplodeInjectAndCursor(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept"), "xml") {
+			t.Errorf("Accept: %q", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		if r.URL.Query().Get("page") == "" {
+			fmt.Fprint(w, `<resp><paging><next>p2</next></paging><orders>
+				<order ref="A"><line sku="1"/><line sku="2"/></order>
+				<order ref="B"><line sku="3"/></order>
+			</orders></resp>`)
+			return
+		}
+		fmt.Fprint(w, `<resp><paging/><orders><order ref="C"/></orders></resp>`)
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source":     map[string]any{"url": srv.URL},
+		"pagination": map[string]any{"type": "cursor", "param": "page", "cursorPath": "resp.paging.next"},
+		"records": map[string]any{
+			"format":  "xml",
+			"path":    "resp.orders.order",
+			"explode": "line",
+			"inject":  map[string]any{"day": "{{date}}"},
+			"xml":     map[string]any{"arrayElements": []any{"line", "order"}},
+		},
+	})
+
+	rows, stats := collect(t, fastEngine(nil), spec, Vars{Date: "2026-08-12"})
+	if stats.Pages != 2 || len(rows) != 4 {
+		t.Fatalf("pages=%d rows=%v", stats.Pages, rows)
+	}
+	if rows[2]["@ref"] != "B" || rows[2]["line"].(map[string]any)["@sku"] != "3" || rows[2]["day"] != "2026-08-12" {
+		t.Errorf("row B: %v", rows[2])
+	}
+	if rows[3]["@ref"] != "C" {
+		t.Errorf("page 2: %v", rows[3])
+	}
+}
+
+// #endregion
@@ -1,8 +1,12 @@
 package httpsource
 
 import (
+	"context"
+	"errors"
+	"net"
 	"sort"
 	"strings"
+	"sync"
 )
 
 // Redactor remplace les valeurs sensibles par un masque dans tout texte sortant du
@@ -18,10 +22,23 @@ type Redactor struct {
 	// évite qu'un secret court (ex: un id de tenant "42") ne découpe un secret long
 	// qui le contient, laissant des fragments en clair.
 	values []string
+
+	// recent : valeurs éphémères (signature d'UNE requête), en anneau borné, cf
+	// AddRecent.
+	recent     [maxRecent]string
+	recentNext int
+
+	// mu : Add est appelé en cours de collecte (token OAuth, basic encodé), y compris
+	// depuis les requêtes parallèles (cf Pagination.Concurrency).
+	mu sync.RWMutex
 }
 
 const redactionMask = "***"
 
+// maxRecent : signatures éphémères gardées, de quoi couvrir toutes les requêtes en
+// vol (maxConcurrency) et les erreurs qui les suivent.
+const maxRecent = 2 * maxConcurrency
+
 // #region NewRedactor
 // NewRedactor construit un rédacteur à partir des valeurs de credentials RÉSOLUES.
 //
@@ -79,21 +96,54 @@ func (r *Redactor) Add(value string) {
 	if r == nil || len(value) < 4 {
 		return
 	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Déjà connue : le basic encodé est ré-enregistré à chaque requête.
+	for _, v := range r.values {
+		if v == value {
+			return
+		}
+	}
 	r.values = append(r.values, value)
 	r.sortValues()
 }
 
 // #endregion
 
+// #region AddRecent
+// AddRecent enregistre une valeur qui ne vaut que pour UNE requête (signature hmac
+// ou aws_sigv4). Une collecte de milliers de pages en produit des milliers : les
+// garder toutes ferait grossir sans fin le rédacteur et le coût de chaque String.
+// Seules les maxRecent dernières sont masquées ; la clé qui les produit l'est via
+// les credentials.
+func (r *Redactor) AddRecent(value string) {
+	if r == nil || len(value) < 4 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recent[r.recentNext] = value
+	r.recentNext = (r.recentNext + 1) % maxRecent
+}
+
+// #endregion
+
 // #region String
 // String masque toutes les valeurs connues dans s.
 func (r *Redactor) String(s string) string {
 	if r == nil || s == "" {
 		return s
 	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	for _, v := range r.values {
 		s = strings.ReplaceAll(s, v, redactionMask)
 	}
+	for _, v := range r.recent {
+		if v != "" {
+			s = strings.ReplaceAll(s, v, redactionMask)
+		}
+	}
 	return s
 }
 
@@ -125,7 +175,7 @@ func (r *Redactor) Err(err error) error {
 		Status:  e.Status,
 	}
 	if e.Cause != nil {
-		out.Cause = &redactedCause{msg: r.String(e.Cause.Error())}
+		out.Cause = &redactedCause{msg: r.String(e.Cause.Error()), timeout: IsTimeout(e.Cause), canceled: errors.Is(e.Cause, context.Canceled)}
 	}
 	return out
 }
@@ -134,9 +184,52 @@ func (r *Redactor) Err(err error) error {
 
 // redactedCause porte une cause déjà masquée. Type dédié plutôt que errors.New pour
 // que l'intention reste lisible dans une stack ou un debug.
-type redactedCause struct{ msg string }
+//
+// timeout et canceled survivent au masquage : ce sont les seules informations de la
+// cause d'origine dont l'appelant a besoin pour classer l'erreur (temporaire plutôt
+// qu'API indisponible), et elles ne contiennent rien de sensible.
+type redactedCause struct {
+	msg      string
+	timeout  bool
+	canceled bool
+}
 
 // #region redactedCause.Error
 func (c *redactedCause) Error() string { return c.msg }
 
 // #endregion
+
+// #region redactedCause.Timeout
+// Timeout et Temporary font de redactedCause un net.Error : errors.As(err, &netErr)
+// le retrouve, comme la cause d'origine.
+func (c *redactedCause) Timeout() bool { return c.timeout }
+
+// #endregion
+
+// #region redactedCause.Temporary
+// Temporary : un délai dépassé est temporaire, comme pour le paquet net.
+func (c *redactedCause) Temporary() bool { return c.timeout }
+
+// #endregion
+
+// #region redactedCause.Is
+// Is garde errors.Is(err, context.DeadlineExceeded) et errors.Is(err,
+// context.Canceled) vrais après masquage.
+func (c *redactedCause) Is(target error) bool {
+	return c.timeout && target == context.DeadlineExceeded || c.canceled && target == context.Canceled
+}
+
+// #endregion
+
+// #region IsTimeout
+// IsTimeout dit si err est un dépassement de délai (contexte, réseau), y compris
+// une cause masquée par le Redactor.
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// #endregion
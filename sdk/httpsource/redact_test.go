@@ -1,7 +1,10 @@
 package httpsource
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"strings"
 	"testing"
 )
@@ -89,6 +92,25 @@ func TestRedactor_Add(t *testing.T) {
 
 // #endregion
 
+// #region TestRedactor_AddRecentIsBounded
+// Une signature par requête : seules les maxRecent dernières restent masquées, le
+// rédacteur ne grossit pas avec le nombre de pages.
+func TestRedactor_AddRecentIsBounded(t *testing.T) {
+	r := NewRedactor(map[string]any{"key": "SECRET-VALUE"})
+	for i := 0; i < 1000; i++ {
+		r.AddRecent(fmt.Sprintf("signature-%04d", i))
+	}
+	if len(r.values) != 1 {
+		t.Errorf("signatures must not pile up with the secrets: %d values", len(r.values))
+	}
+	got := r.String("SECRET-VALUE signature-0999 signature-0968 signature-0967")
+	if got != "*** *** *** signature-0967" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// #endregion
+
 // #region TestRedactor_ErrMasksMessageAndCause
 // Une erreur imbriquée (url.Error typiquement) réexpose l'URL complète — donc le token
 // — via son propre Error(). Il faut donc masquer la cause aussi.
@@ -119,6 +141,32 @@ func TestRedactor_ErrMasksMessageAndCause(t *testing.T) {
 
 // #endregion
 
+// #region TestRedactor_ErrKeepsTimeout
+// Le masquage remplace la cause par du texte, mais le caractère "timeout" doit
+// survivre : c'est ce qui fait d'un délai dépassé une erreur TEMPORAIRE côté SDK.
+func TestRedactor_ErrKeepsTimeout(t *testing.T) {
+	r := NewRedactor(map[string]any{"apikey": "SECRET-VALUE"})
+
+	timedOut := r.Err(newErr(KindUnavailable, 0, fmt.Errorf("get ?key=SECRET-VALUE: %w", context.DeadlineExceeded), "call failed"))
+	if !errors.Is(timedOut, context.DeadlineExceeded) {
+		t.Error("errors.Is(context.DeadlineExceeded) should survive redaction")
+	}
+
+	other := r.Err(newErr(KindUnavailable, 0, fmt.Errorf("connection refused"), "call failed"))
+	if errors.Is(other, context.DeadlineExceeded) {
+		t.Error("a non-timeout cause must not match context.DeadlineExceeded")
+	}
+
+	// Timeout réseau sans context.DeadlineExceeded : retrouvé comme net.Error.
+	dnsTimeout := r.Err(newErr(KindUnavailable, 0, &net.DNSError{Name: "api.example.com", IsTimeout: true}, "call failed"))
+	var netErr net.Error
+	if !errors.As(dnsTimeout, &netErr) || !netErr.Timeout() || !IsTimeout(dnsTimeout) {
+		t.Errorf("the redacted cause must stay a net.Error timeout: %v", dnsTimeout)
+	}
+}
+
+// #endregion
+
 // #region TestRedactor_NilSafety
 func TestRedactor_NilSafety(t *testing.T) {
 	var r *Redactor
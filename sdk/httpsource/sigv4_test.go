@@ -0,0 +1,195 @@
+package httpsource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Vecteurs de la suite de tests publiée par AWS (aws-sig-v4-test-suite) : mêmes
+// identifiants, même date, signatures attendues recopiées telles quelles.
+const (
+	sigV4TestKeyID  = "AKIDEXAMPLE"
+	sigV4TestSecret = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	sigV4TestToken  = "AQoDYXdzEPT//////////wEXAMPLEtc764bNrC9SAPBSM22wDOk4x4HIZ8j4FZTwdQWLWsKWHGBuFqwAeMicRXmxfpSPfIeoIYRqTflfKD8YUuwthAx7mSEI/qkPpKPi/kMcGdQrmGdeehM4IC1NtBmUpp2wUE8phUZampKsburEDy0KPkyQDYwT7WZ0wq5VSXDvp75YU9HFvlRd8Tx6q6fE8YQcHNVXAkiY9q6d+xo0rKwT38xVqr7ZD0u0iPPkUL64lIZbqBAz+scqKmlzm8FDrypNC9Yjc8fPOLn9FX9KSYvKTr4rvx3iSIlTJabIQwj2ICCR/oLxBA=="
+)
+
+func sigV4TestAuth(t *testing.T, sessionToken string) (*authenticator, *Redactor) {
+	t.Helper()
+	aws := map[string]any{
+		"accessKeyId":     "{{credentials.accessKeyId}}",
+		"secretAccessKey": "{{credentials.secretAccessKey}}",
+		"region":          "us-east-1",
+		"service":         "service",
+	}
+	if sessionToken != "" {
+		aws["sessionToken"] = "{{credentials.sessionToken}}"
+	}
+	spec := mustSpec(t, map[string]any{
+		"source": map[string]any{"url": "https://example.amazonaws.com/"},
+		"auth":   map[string]any{"mode": "aws_sigv4", "aws": aws},
+	})
+	vars := Vars{Credentials: map[string]any{
+		"accessKeyId": sigV4TestKeyID, "secretAccessKey": sigV4TestSecret, "sessionToken": sessionToken,
+	}}
+	redactor := NewRedactor(vars.Credentials)
+	a := newAuthenticator(spec, vars, http.DefaultClient, redactor)
+	a.now = func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+	return a, redactor
+}
+
+// #region TestSignSigV4_TestSuiteVectors
+func TestSignSigV4_TestSuiteVectors(t *testing.T) {
+	cases := []struct {
+		name, method, url, contentType, body, token string
+		signedHeaders, signature                    string
+	}{
+		{name: "get-vanilla", method: "GET", url: "https://example.amazonaws.com/",
+			signedHeaders: "host;x-amz-date", signature: "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{name: "get-vanilla-query-order-key-case", method: "GET", url: "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			signedHeaders: "host;x-amz-date", signature: "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+		{name: "post-vanilla", method: "POST", url: "https://example.amazonaws.com/",
+			signedHeaders: "host;x-amz-date", signature: "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b"},
+		{name: "post-x-www-form-urlencoded", method: "POST", url: "https://example.amazonaws.com/",
+			contentType: "application/x-www-form-urlencoded", body: "Param1=value1",
+			signedHeaders: "content-type;host;x-amz-date", signature: "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a"},
+		{name: "post-sts-header-before", method: "POST", url: "https://example.amazonaws.com/", token: sigV4TestToken,
+			signedHeaders: "host;x-amz-date;x-amz-security-token", signature: "85d96828115b5dc0cfc3bd16ad9e210dd772bbebba041836c64533a82be05ead"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			a, redactor := sigV4TestAuth(t, c.token)
+			var body io.Reader
+			if c.body != "" {
+				body = strings.NewReader(c.body)
+			}
+			req, _ := http.NewRequest(c.method, c.url, body)
+			if c.contentType != "" {
+				req.Header.Set("Content-Type", c.contentType)
+			}
+			if err := a.apply(context.Background(), req); err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+
+			want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=" +
+				c.signedHeaders + ", Signature=" + c.signature
+			if got := req.Header.Get("Authorization"); got != want {
+				t.Errorf("Authorization:\n got %s\nwant %s", got, want)
+			}
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("X-Amz-Date: %q", got)
+			}
+			if c.token != "" && req.Header.Get("X-Amz-Security-Token") != c.token {
+				t.Errorf("X-Amz-Security-Token not set")
+			}
+			if got := redactor.String("sent " + c.signature); strings.Contains(got, c.signature) {
+				t.Errorf("the signature must be redacted: %q", got)
+			}
+		})
+	}
+}
+
+// #endregion
+
+// #region TestSigV4_CanonicalPathAndS3
+func TestSigV4_CanonicalPathAndS3(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://bucket.s3.amazonaws.com/reports/2026 08/a+b.csv?prefix=a b", nil)
+	if got := sigV4Path(req.URL, false); got != "/reports/2026%252008/a%252Bb.csv" {
+		t.Errorf("double-encoded path: %q", got)
+	}
+	if got := sigV4Path(req.URL, true); got != "/reports/2026%2008/a%2Bb.csv" {
+		t.Errorf("S3 path: %q", got)
+	}
+	if got := sigV4Query(req.URL); got != "prefix=a%20b" {
+		t.Errorf("query: %q", got)
+	}
+
+	spec := mustSpec(t, map[string]any{
+		"source": map[string]any{"url": "https://bucket.s3.amazonaws.com/"},
+		"auth": map[string]any{"mode": "aws_sigv4", "aws": map[string]any{
+			"accessKeyId": "{{credentials.id}}", "secretAccessKey": "{{credentials.secret}}",
+			"region": "eu-west-3", "service": "s3",
+		}},
+	})
+	a := newAuthenticator(spec, Vars{Credentials: map[string]any{"id": "AKID", "secret": "s"}}, http.DefaultClient, NewRedactor(nil))
+	if err := a.apply(context.Background(), req); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	// Hash d'un corps vide, exigé en en-tête et signé par S3.
+	if got := req.Header.Get("X-Amz-Content-Sha256"); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("X-Amz-Content-Sha256: %q", got)
+	}
+	if !strings.Contains(req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-content-sha256;x-amz-date,") {
+		t.Errorf("Authorization: %q", req.Header.Get("Authorization"))
+	}
+}
+
+// #endregion
+
+// #region TestFetch_SigV4ResignsEveryAttempt
+func TestFetch_SigV4ResignsEveryAttempt(t *testing.T) {
+	var seen []string
+	throttled := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("X-Amz-Date")+" "+r.Header.Get("Authorization"))
+		if !throttled {
+			throttled = true
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"items":[{"id":1}]}`)
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source": map[string]any{"url": srv.URL + "/prod/items", "method": "POST", "body": map[string]any{"day": "{{date}}"}},
+		"auth": map[string]any{"mode": "aws_sigv4", "aws": map[string]any{
+			"accessKeyId": "{{credentials.id}}", "secretAccessKey": "{{credentials.secret}}",
+			"region": "eu-west-1", "service": "execute-api",
+		}},
+		"records": map[string]any{"path": "items"},
+	})
+	vars := Vars{Date: "2026-08-12", Credentials: map[string]any{"id": "AKID", "secret": "s"}}
+
+	// Horloge virtuelle avancée par l'attente du retry : la reprise part à une autre
+	// seconde, donc avec une autre date signée.
+	var slept []time.Duration
+	rows, stats := collect(t, clockEngine(&slept), spec, vars)
+	if len(rows) != 1 || stats.Attempts != 2 || len(seen) != 2 {
+		t.Fatalf("rows=%d stats=%+v seen=%d", len(rows), stats, len(seen))
+	}
+	if !strings.HasPrefix(seen[0], "20260812T030000Z ") || strings.HasPrefix(seen[1], "20260812T030000Z ") {
+		t.Errorf("the retry must carry a new date and signature: %v", seen)
+	}
+}
+
+// #endregion
+
+// #region TestValidate_SigV4
+func TestValidate_SigV4(t *testing.T) {
+	cases := []struct {
+		aws  map[string]any
+		want string
+	}{
+		{nil, "auth.aws.accessKeyId, auth.aws.secretAccessKey, auth.aws.region and auth.aws.service are required"},
+		{map[string]any{"accessKeyId": "k", "secretAccessKey": "s", "region": "eu-west-1"}, "are required"},
+		{map[string]any{"accessKeyId": "{{credentials.id|nope}}", "secretAccessKey": "s", "region": "r", "service": "s3"}, "auth.aws.accessKeyId"},
+	}
+	for _, c := range cases {
+		auth := map[string]any{"mode": "aws_sigv4"}
+		if c.aws != nil {
+			auth["aws"] = c.aws
+		}
+		_, err := ParseSpec(map[string]any{"source": map[string]any{"url": "https://x.com"}, "auth": auth})
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%v: want %q, got %v", c.aws, c.want, err)
+		}
+	}
+}
+
+// #endregion
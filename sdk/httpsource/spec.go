@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"path"
+	"sort"
 	"strings"
 )
 
@@ -20,6 +22,21 @@ type Spec struct {
 	Pagination Pagination `json:"pagination"`
 	Retry      Retry      `json:"retry"`
 	Records    Records    `json:"records"`
+
+	// Job : rapport asynchrone créé puis attendu avant le téléchargement décrit par
+	// Source (cf job.go). Absent = appel direct, comportement historique.
+	Job *Job `json:"job"`
+
+	// Child : requête exécutée pour chaque ligne de celle-ci (cf chain.go).
+	Child *Child `json:"child"`
+
+	// RateLimit : débit maximal envoyé à l'API (cf ratelimit.go). Absent = pas de
+	// limite a priori, seul retry.on429 réagit.
+	RateLimit *RateLimit `json:"rateLimit"`
+
+	// Window : la période du process découpée en fenêtres, une exécution par
+	// fenêtre (cf window.go). Absent = une exécution par date du plan.
+	Window *Window `json:"window"`
 }
 
 // Source décrit la requête de base. Les valeurs sont des templates (cf template.go) :
@@ -31,6 +48,9 @@ type Source struct {
 	Headers        map[string]string `json:"headers"`
 	Body           any               `json:"body"`
 	TimeoutSeconds int               `json:"timeoutSeconds"`
+
+	// GraphQL : mode GraphQL (cf graphql.go). Absent = REST, comportement historique.
+	GraphQL *GraphQL `json:"graphql"`
 }
 
 // Auth : comment authentifier l'appel.
@@ -51,6 +71,15 @@ type Auth struct {
 	RefreshToken string   `json:"refreshToken"`
 	Scopes       []string `json:"scopes"`
 	Audience     string   `json:"audience"`
+
+	// HMAC : paramètres du mode hmac (cf hmac.go).
+	HMAC *HMACAuth `json:"hmac"`
+
+	// AWS : paramètres du mode aws_sigv4 (cf sigv4.go).
+	AWS *AWSSigV4Auth `json:"aws"`
+
+	// JWT : paramètres du mode oauth2_jwt_bearer (cf jwtbearer.go).
+	JWT *JWTBearerAuth `json:"jwt"`
 }
 
 const (
@@ -61,6 +90,9 @@ const (
 	AuthBearer                  = "bearer"
 	AuthOAuth2ClientCredentials = "oauth2_client_credentials"
 	AuthOAuth2Refresh           = "oauth2_refresh"
+	AuthOAuth2JWTBearer         = "oauth2_jwt_bearer"
+	AuthHMAC                    = "hmac"
+	AuthAWSSigV4                = "aws_sigv4"
 )
 
 // Pagination : comment enchaîner les pages.
@@ -80,6 +112,15 @@ type Pagination struct {
 	HasMorePath    string `json:"hasMorePath"`
 	NextURLPath    string `json:"nextUrlPath"`
 	TotalPagesPath string `json:"totalPagesPath"`
+
+	// PageInfoPath : chemin de l'objet pageInfo Relay (type relay), ex:
+	// `data.orders.pageInfo`. Le curseur est écrit dans la variable GraphQL Param.
+	PageInfoPath string `json:"pageInfoPath"`
+
+	// Concurrency : nombre de pages demandées en parallèle (types page et offset
+	// seulement : ce sont les seuls où la page N+1 se calcule sans lire la page N).
+	// Les lignes restent émises dans l'ordre des pages. Défaut 1 = séquentiel.
+	Concurrency int `json:"concurrency"`
 }
 
 const (
@@ -89,6 +130,7 @@ const (
 	PageCursor     = "cursor"
 	PageLinkHeader = "link_header"
 	PageNextURL    = "next_url"
+	PageRelay      = "relay"
 )
 
 // Retry : politique de reprise. C'est le bloc le plus important en pratique — les
@@ -148,11 +190,32 @@ type Records struct {
 	Inject map[string]string `json:"inject"`
 
 	CSV *CSVOptions `json:"csv"`
+	XML *XMLOptions `json:"xml"`
+
+	// Compression : gzip, zip ou auto pour les téléchargements de rapports (`.csv.gz`,
+	// `.zip` de plusieurs CSV). Appliquée avant le parse ; Zip choisit les fichiers de
+	// l'archive (cf ZipOptions).
+	Compression string      `json:"compression"`
+	Zip         *ZipOptions `json:"zip"`
+
+	// Stream : décode le tableau à records.path au fil de la lecture au lieu de charger
+	// la page entière (json seulement). Pour les exports d'une seule page énorme. En
+	// contrepartie, une coupure réseau au milieu de la page est fatale : des lignes
+	// sont déjà parties, la page ne peut plus être rejouée. source.timeoutSeconds y
+	// borne l'attente des en-têtes puis chaque silence du corps, pas la page entière.
+	Stream bool `json:"stream"`
 }
 
 const (
 	FormatJSON = "json"
 	FormatCSV  = "csv"
+
+	// FormatJSONL : un objet JSON par ligne (NDJSON), forme des exports bulk. Le
+	// records.path s'applique à chaque ligne.
+	FormatJSONL = "jsonl"
+
+	// FormatXML : réponse XML convertie en objets (cf XMLOptions).
+	FormatXML = "xml"
 )
 
 // CSVOptions : options de parsing CSV. HasHeader par défaut true (le cas normal d'un
@@ -256,6 +319,9 @@ func normalizeForJSON(v any) (any, error) {
 func (s *Spec) applyDefaults() {
 	if s.Source.Method == "" {
 		s.Source.Method = "GET"
+		if s.Source.GraphQL != nil {
+			s.Source.Method = "POST"
+		}
 	}
 	s.Source.Method = strings.ToUpper(s.Source.Method)
 
@@ -266,6 +332,39 @@ func (s *Spec) applyDefaults() {
 	if s.Auth.Mode == "" {
 		s.Auth.Mode = AuthNone
 	}
+	if h := s.Auth.HMAC; h != nil {
+		h.Algorithm = strings.ToLower(h.Algorithm)
+		if h.Algorithm == "" {
+			h.Algorithm = "sha256"
+		}
+		h.Encoding = strings.ToLower(h.Encoding)
+		if h.Encoding == "" {
+			h.Encoding = "hex"
+		}
+		if h.TimestampFormat == "" {
+			h.TimestampFormat = TimestampUnix
+		}
+		if h.SignatureHeader == "" && len(h.Headers) == 0 {
+			h.SignatureHeader = "X-Signature"
+		}
+	}
+	if j := s.Auth.JWT; j != nil {
+		j.Algorithm = strings.ToUpper(j.Algorithm)
+		if j.ExpiresInSeconds == 0 {
+			j.ExpiresInSeconds = 180
+		}
+	}
+
+	if j := s.Job; j != nil {
+		j.Create.Source = jobSourceDefaults(j.Create.Source, "POST", s.Source.TimeoutSeconds)
+		j.Poll.Source = jobSourceDefaults(j.Poll.Source, "GET", s.Source.TimeoutSeconds)
+		if j.Poll.IntervalSeconds <= 0 {
+			j.Poll.IntervalSeconds = 30
+		}
+		if j.Poll.MaxWaitSeconds <= 0 {
+			j.Poll.MaxWaitSeconds = 3600
+		}
+	}
 
 	if s.Pagination.Type == "" {
 		s.Pagination.Type = PageNone
@@ -282,6 +381,10 @@ func (s *Spec) applyDefaults() {
 	if s.Pagination.Type == PageCursor && s.Pagination.Param == "" {
 		s.Pagination.Param = "cursor"
 	}
+	if s.Pagination.Type == PageRelay && s.Pagination.Param == "" {
+		// Convention Relay : `orders(first: 50, after: $after)`.
+		s.Pagination.Param = "after"
+	}
 	if s.Pagination.Type == PagePage && s.Pagination.StartAt == 0 {
 		// La grande majorité des API pagine à partir de 1. Un conf.yml qui veut
 		// vraiment démarrer à 0 doit l'écrire explicitement (startAt: 0 est
@@ -310,10 +413,43 @@ func (s *Spec) applyDefaults() {
 		s.Retry.OnNetwork = &Backoff{BackoffSeconds: 2, JitterMs: 500, Exponential: true}
 	}
 
+	if r := s.RateLimit; r != nil {
+		if r.Burst <= 0 {
+			r.Burst = 1
+		}
+		if r.Adaptive && r.RemainingHeader == "" {
+			r.RemainingHeader = "X-RateLimit-Remaining"
+		}
+		if r.Adaptive && r.ResetHeader == "" {
+			r.ResetHeader = "X-RateLimit-Reset"
+		}
+		if r.MaxWaitSeconds <= 0 {
+			r.MaxWaitSeconds = 300
+		}
+	}
+
+	if w := s.Window; w != nil {
+		if w.Size <= 0 {
+			w.Size = 1
+		}
+		w.Unit = strings.ToLower(w.Unit)
+		if w.Unit == "" {
+			w.Unit = WindowDay
+		}
+	}
+
 	if s.Records.Format == "" {
 		s.Records.Format = FormatJSON
 	}
 	s.Records.Format = strings.ToLower(s.Records.Format)
+	if s.Records.Format == "ndjson" {
+		s.Records.Format = FormatJSONL
+	}
+
+	s.Records.Compression = strings.ToLower(s.Records.Compression)
+	if s.Records.Compression == "" {
+		s.Records.Compression = CompressionNone
+	}
 
 	if s.Records.EmitWhenExplodeEmpty == nil {
 		t := true
@@ -331,6 +467,31 @@ func (s *Spec) applyDefaults() {
 			s.Records.CSV.Delimiter = ","
 		}
 	}
+
+	if c := s.Child; c != nil {
+		if c.Auth.Mode == "" {
+			c.Auth = s.Auth
+			c.inheritsAuth = true
+		}
+		if c.Retry.MaxAttempts == 0 && c.Retry.On429 == nil && c.Retry.On5xx == nil && c.Retry.OnNetwork == nil {
+			c.Retry = s.Retry
+		}
+		if c.Source.TimeoutSeconds <= 0 {
+			c.Source.TimeoutSeconds = s.Source.TimeoutSeconds
+		}
+		if c.RateLimit == nil && s.RateLimit != nil {
+			// Copie : l'applyDefaults de l'enfant ne doit pas modifier le bloc parent.
+			r := *s.RateLimit
+			c.RateLimit = &r
+		}
+		if c.ParentKey == "" {
+			c.ParentKey = "parent"
+		}
+		if c.ParentIDPath == "" {
+			c.ParentIDPath = "id"
+		}
+		c.Spec.applyDefaults()
+	}
 }
 
 // #endregion
@@ -346,8 +507,10 @@ func (s *Spec) Validate() error {
 
 	// L'URL contient des templates non encore résolus, on ne peut donc pas la parser
 	// entièrement ici. On vérifie au moins le schéma : une URL sans https:// est
-	// presque toujours un oubli de copier-coller.
-	if !strings.HasPrefix(s.Source.URL, "http://") && !strings.HasPrefix(s.Source.URL, "https://") {
+	// presque toujours un oubli de copier-coller. Seule exception : le téléchargement
+	// d'un job dont l'URL entière vient du poll (`{{job.downloadUrl}}`).
+	downloadFromJob := s.Job != nil && strings.HasPrefix(strings.TrimSpace(s.Source.URL), "{{job.downloadUrl")
+	if !downloadFromJob && !strings.HasPrefix(s.Source.URL, "http://") && !strings.HasPrefix(s.Source.URL, "https://") {
 		return newErr(KindInvalidSpec, 0, nil, "source.url must start with http:// or https:// (got %q)", truncate(s.Source.URL, 60))
 	}
 
@@ -357,28 +520,200 @@ func (s *Spec) Validate() error {
 		return newErr(KindInvalidSpec, 0, nil, "source.method %q is not supported", s.Source.Method)
 	}
 
+	if err := s.validateGraphQL(); err != nil {
+		return err
+	}
+	if err := s.validateJob(); err != nil {
+		return err
+	}
 	if err := s.validateAuth(); err != nil {
 		return err
 	}
 	if err := s.validatePagination(); err != nil {
 		return err
 	}
+	if err := s.validateConcurrency(); err != nil {
+		return err
+	}
+	if err := s.validateRateLimit(); err != nil {
+		return err
+	}
+	if err := s.validateWindow(); err != nil {
+		return err
+	}
+	if err := s.validateTemplates(); err != nil {
+		return err
+	}
 
 	switch s.Records.Format {
-	case FormatJSON, FormatCSV:
+	case FormatJSON, FormatJSONL, FormatCSV, FormatXML:
 	default:
-		return newErr(KindInvalidSpec, 0, nil, "records.format %q is not supported (json or csv)", s.Records.Format)
+		return newErr(KindInvalidSpec, 0, nil, "records.format %q is not supported (json, jsonl, csv or xml)", s.Records.Format)
+	}
+
+	if s.Records.Format == FormatXML && s.Records.XML != nil {
+		for prefix, uri := range s.Records.XML.Namespaces {
+			if prefix == "" || uri == "" || strings.ContainsAny(prefix, ":.") {
+				return newErr(KindInvalidSpec, 0, nil, "records.xml.namespaces: %q → %q is not a valid prefix → URI pair", prefix, uri)
+			}
+		}
+	}
+
+	switch s.Records.Compression {
+	case CompressionNone, CompressionGzip, CompressionZip, CompressionAuto:
+	default:
+		return newErr(KindInvalidSpec, 0, nil, "records.compression %q is not supported (none, gzip, zip or auto)", s.Records.Compression)
+	}
+	if s.Records.Stream && (s.Records.Format != FormatJSON || s.Records.Compression != CompressionNone || s.Source.GraphQL != nil) {
+		return newErr(KindInvalidSpec, 0, nil, "records.stream requires records.format json, without compression nor source.graphql")
+	}
+	if s.Records.Zip != nil && s.Records.Zip.Entries != "" {
+		if _, err := path.Match(s.Records.Zip.Entries, ""); err != nil {
+			return newErr(KindInvalidSpec, 0, nil, "records.zip.entries %q is not a valid glob pattern", s.Records.Zip.Entries)
+		}
 	}
 
 	if s.Records.Format == FormatCSV && !*s.Records.CSV.HasHeader && len(s.Records.CSV.Columns) == 0 {
 		return newErr(KindInvalidSpec, 0, nil, "records.csv: columns is required when hasHeader is false, otherwise columns would be unnamed")
 	}
 
+	if s.Child != nil {
+		if err := s.Child.Spec.Validate(); err != nil {
+			// Le chemin complet (child.source.url) dit à l'admin QUEL bloc corriger.
+			if e, ok := err.(*Error); ok {
+				return newErr(e.Kind, 0, e.Cause, "child.%s", e.Message)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// #endregion
+
+// #region validateGraphQL
+func (s *Spec) validateGraphQL() error {
+	g := s.Source.GraphQL
+	if g == nil {
+		return nil
+	}
+	if strings.TrimSpace(g.Query) == "" {
+		return newErr(KindInvalidSpec, 0, nil, "source.graphql.query is required")
+	}
+	if s.Source.Body != nil {
+		return newErr(KindInvalidSpec, 0, nil, "source.body must be empty with source.graphql (the body is built from query and variables)")
+	}
+	if s.Source.Method != "POST" {
+		return newErr(KindInvalidSpec, 0, nil, "source.method must be POST with source.graphql (got %s)", s.Source.Method)
+	}
+	if s.Records.Format != FormatJSON {
+		return newErr(KindInvalidSpec, 0, nil, "records.format must be json with source.graphql")
+	}
+	return nil
+}
+
+// #endregion
+
+// maxConcurrency borne les requêtes parallèles d'une spec : au-delà, c'est l'API
+// tierce qui tombe (429 en cascade), pas la collecte qui accélère.
+const maxConcurrency = 16
+
+// #region validateConcurrency
+func (s *Spec) validateConcurrency() error {
+	if n := s.Pagination.Concurrency; n > 1 {
+		if s.Pagination.Type != PagePage && s.Pagination.Type != PageOffset {
+			return newErr(KindInvalidSpec, 0, nil, "pagination.concurrency requires pagination.type page or offset (got %s)", s.Pagination.Type)
+		}
+		if s.Records.Stream {
+			return newErr(KindInvalidSpec, 0, nil, "pagination.concurrency cannot be combined with records.stream")
+		}
+	}
+	if s.Pagination.Concurrency < 0 || s.Pagination.Concurrency > maxConcurrency {
+		return newErr(KindInvalidSpec, 0, nil, "pagination.concurrency must be between 1 and %d", maxConcurrency)
+	}
+	if s.Child != nil && (s.Child.Concurrency < 0 || s.Child.Concurrency > maxConcurrency) {
+		return newErr(KindInvalidSpec, 0, nil, "child.concurrency must be between 1 and %d", maxConcurrency)
+	}
+	return nil
+}
+
+// #endregion
+
+// #region validateRateLimit
+func (s *Spec) validateRateLimit() error {
+	r := s.RateLimit
+	if r == nil {
+		return nil
+	}
+	if r.RequestsPerSecond < 0 || r.RequestsPerMinute < 0 {
+		return newErr(KindInvalidSpec, 0, nil, "rateLimit.requestsPerSecond and rateLimit.requestsPerMinute cannot be negative")
+	}
+	if r.rate() == 0 && !r.Adaptive {
+		return newErr(KindInvalidSpec, 0, nil, "rateLimit needs requestsPerSecond, requestsPerMinute or adaptive: true")
+	}
 	return nil
 }
 
 // #endregion
 
+// #region validateJob
+func (s *Spec) validateJob() error {
+	j := s.Job
+	if j == nil {
+		return nil
+	}
+	steps := []struct {
+		name   string
+		source Source
+	}{{"job.create", j.Create.Source}, {"job.poll", j.Poll.Source}}
+	for _, step := range steps {
+		if step.source.URL == "" {
+			return newErr(KindInvalidSpec, 0, nil, "%s.url is required", step.name)
+		}
+		if !strings.HasPrefix(step.source.URL, "http://") && !strings.HasPrefix(step.source.URL, "https://") {
+			return newErr(KindInvalidSpec, 0, nil, "%s.url must start with http:// or https:// (got %q)", step.name, truncate(step.source.URL, 60))
+		}
+		switch step.source.Method {
+		case "GET", "POST", "PUT", "PATCH":
+		default:
+			return newErr(KindInvalidSpec, 0, nil, "%s.method %q is not supported", step.name, step.source.Method)
+		}
+		if step.source.GraphQL != nil {
+			return newErr(KindInvalidSpec, 0, nil, "%s.graphql is not supported", step.name)
+		}
+	}
+	if j.Create.IDPath == "" {
+		return newErr(KindInvalidSpec, 0, nil, "job.create.idPath is required (where the job id is in the creation response)")
+	}
+	if j.Poll.StatusPath == "" || len(j.Poll.SuccessValues) == 0 {
+		return newErr(KindInvalidSpec, 0, nil, "job.poll.statusPath and job.poll.successValues are required")
+	}
+	if strings.Contains(s.Source.URL, "job.downloadUrl") && j.Poll.DownloadURLPath == "" {
+		return newErr(KindInvalidSpec, 0, nil, "job.poll.downloadUrlPath is required when source.url uses {{job.downloadUrl}}")
+	}
+	if s.Source.GraphQL != nil {
+		return newErr(KindInvalidSpec, 0, nil, "source.graphql cannot be combined with job")
+	}
+	return nil
+}
+
+// #endregion
+
+// #region jobSourceDefaults
+func jobSourceDefaults(src Source, method string, timeoutSeconds int) Source {
+	if src.Method == "" {
+		src.Method = method
+	}
+	src.Method = strings.ToUpper(src.Method)
+	if src.TimeoutSeconds <= 0 {
+		src.TimeoutSeconds = timeoutSeconds
+	}
+	return src
+}
+
+// #endregion
+
 // #region validateAuth
 func (s *Spec) validateAuth() error {
 	switch s.Auth.Mode {
@@ -418,10 +753,19 @@ func (s *Spec) validateAuth() error {
 		}
 		return nil
 
+	case AuthOAuth2JWTBearer:
+		return s.validateJWTBearer()
+
+	case AuthHMAC:
+		return s.validateHMAC()
+
+	case AuthAWSSigV4:
+		return s.validateSigV4()
+
 	default:
 		return newErr(KindInvalidSpec, 0, nil,
-			"auth.mode %q is not supported (none, header, query, basic, bearer, %s, %s)",
-			s.Auth.Mode, AuthOAuth2ClientCredentials, AuthOAuth2Refresh)
+			"auth.mode %q is not supported (none, header, query, basic, bearer, %s, %s, %s, %s, %s)",
+			s.Auth.Mode, AuthOAuth2ClientCredentials, AuthOAuth2Refresh, AuthOAuth2JWTBearer, AuthHMAC, AuthAWSSigV4)
 	}
 }
 
@@ -462,14 +806,114 @@ func (s *Spec) validatePagination() error {
 	case PageLinkHeader:
 		return nil
 
+	case PageRelay:
+		if s.Source.GraphQL == nil {
+			return newErr(KindInvalidSpec, 0, nil, "pagination.type relay requires source.graphql (the cursor is written into the GraphQL variables)")
+		}
+		if s.Pagination.PageInfoPath == "" {
+			return newErr(KindInvalidSpec, 0, nil, "pagination.pageInfoPath is required with type relay (e.g. data.orders.pageInfo)")
+		}
+		return nil
+
 	default:
 		return newErr(KindInvalidSpec, 0, nil,
-			"pagination.type %q is not supported (none, page, offset, cursor, link_header, next_url)", s.Pagination.Type)
+			"pagination.type %q is not supported (none, page, offset, cursor, link_header, next_url, relay)", s.Pagination.Type)
 	}
 }
 
 // #endregion
 
+// #region validateTemplates
+// validateTemplates vérifie les filtres de chaque template ({{date|addDays:x}},
+// filtre inconnu…) au Save plutôt qu'au premier appel. Les noms de variables ne sont
+// pas contrôlés ici : leur disponibilité dépend du contexte d'exécution.
+func (s *Spec) validateTemplates() error {
+	var fields [][2]string
+	add := func(where, tmpl string) {
+		if strings.Contains(tmpl, "{{") {
+			fields = append(fields, [2]string{where, tmpl})
+		}
+	}
+	var addAny func(where string, v any)
+	addAny = func(where string, v any) {
+		switch t := v.(type) {
+		case string:
+			add(where, t)
+		case map[string]any:
+			for _, k := range sortedMapKeys(t) {
+				addAny(where+"."+k, t[k])
+			}
+		case []any:
+			for i, item := range t {
+				addAny(fmt.Sprintf("%s[%d]", where, i), item)
+			}
+		}
+	}
+	addMap := func(where string, m map[string]string) {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			add(where+"."+k, m[k])
+		}
+	}
+	addSource := func(where string, src Source) {
+		add(where+".url", src.URL)
+		addMap(where+".query", src.Query)
+		addMap(where+".headers", src.Headers)
+		addAny(where+".body", src.Body)
+		if g := src.GraphQL; g != nil {
+			add(where+".graphql.query", g.Query)
+			addAny(where+".graphql.variables", map[string]any(g.Variables))
+		}
+	}
+
+	addSource("source", s.Source)
+	if j := s.Job; j != nil {
+		addSource("job.create", j.Create.Source)
+		addSource("job.poll", j.Poll.Source)
+	}
+	add("auth.value", s.Auth.Value)
+	add("auth.username", s.Auth.Username)
+	add("auth.password", s.Auth.Password)
+	add("auth.tokenUrl", s.Auth.TokenURL)
+	add("auth.clientId", s.Auth.ClientID)
+	add("auth.clientSecret", s.Auth.ClientSecret)
+	add("auth.refreshToken", s.Auth.RefreshToken)
+	if h := s.Auth.HMAC; h != nil {
+		add("auth.hmac.key", h.Key)
+		add("auth.hmac.stringToSign", h.StringToSign)
+		addMap("auth.hmac.headers", h.Headers)
+	}
+	if aws := s.Auth.AWS; aws != nil {
+		add("auth.aws.accessKeyId", aws.AccessKeyID)
+		add("auth.aws.secretAccessKey", aws.SecretAccessKey)
+		add("auth.aws.sessionToken", aws.SessionToken)
+		add("auth.aws.region", aws.Region)
+		add("auth.aws.service", aws.Service)
+	}
+	if j := s.Auth.JWT; j != nil {
+		add("auth.jwt.issuer", j.Issuer)
+		add("auth.jwt.subject", j.Subject)
+		add("auth.jwt.keyId", j.KeyID)
+		add("auth.jwt.privateKey", j.PrivateKey)
+	}
+	addMap("records.inject", s.Records.Inject)
+
+	for _, f := range fields {
+		for _, groups := range varPattern.FindAllStringSubmatch(f[1], -1) {
+			if _, err := parsePipeline(groups[2]); err != nil {
+				return newErr(KindInvalidSpec, 0, nil, "%s: variable %q: %s", f[0], groups[1], err)
+			}
+		}
+	}
+	return nil
+}
+
+// #endregion
+
 // #region SecretTemplates
 // SecretTemplates retourne toutes les valeurs de la spec qui contiennent une
 // référence à une credential. Le moteur s'en sert pour construire le rédacteur : tout
@@ -489,12 +933,48 @@ func (s *Spec) SecretTemplates() []string {
 	for _, v := range s.Source.Headers {
 		add(v)
 	}
+	if g := s.Source.GraphQL; g != nil {
+		add(g.Query)
+		for _, v := range g.Variables {
+			if str, ok := v.(string); ok {
+				add(str)
+			}
+		}
+	}
+	if j := s.Job; j != nil {
+		for _, src := range []Source{j.Create.Source, j.Poll.Source} {
+			add(src.URL)
+			for _, v := range src.Query {
+				add(v)
+			}
+			for _, v := range src.Headers {
+				add(v)
+			}
+		}
+	}
 	add(s.Auth.Value)
 	add(s.Auth.Username)
 	add(s.Auth.Password)
 	add(s.Auth.ClientID)
 	add(s.Auth.ClientSecret)
 	add(s.Auth.RefreshToken)
+	if h := s.Auth.HMAC; h != nil {
+		add(h.Key)
+		for _, v := range h.Headers {
+			add(v)
+		}
+	}
+	if aws := s.Auth.AWS; aws != nil {
+		add(aws.AccessKeyID)
+		add(aws.SecretAccessKey)
+		add(aws.SessionToken)
+	}
+	if j := s.Auth.JWT; j != nil {
+		add(j.PrivateKey)
+	}
+	if s.Child != nil {
+		out = append(out, s.Child.Spec.SecretTemplates()...)
+	}
 	return out
 }
 
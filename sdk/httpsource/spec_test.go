@@ -193,7 +193,7 @@ func TestValidate_Rejections(t *testing.T) {
 			name: "unknown record format",
 			spec: map[string]any{
 				"source":  map[string]any{"url": "https://x.com"},
-				"records": map[string]any{"format": "xml"},
+				"records": map[string]any{"format": "parquet"},
 			},
 			want: "records.format",
 		},
@@ -268,3 +268,36 @@ func TestRedactURL(t *testing.T) {
 }
 
 // #endregion
+
+// #region TestValidate_TemplateFilters
+// Une faute dans un filtre est refusée au Save, avec le champ fautif, et non au
+// premier appel du cron.
+func TestValidate_TemplateFilters(t *testing.T) {
+	_, err := ParseSpec(map[string]any{
+		"source": map[string]any{
+			"url":  "https://x.com/sales",
+			"body": map[string]any{"range": map[string]any{"from": "{{date|addDays:-7|formt:20060102}}"}},
+		},
+	})
+	if err == nil || KindOf(err) != KindInvalidSpec || !strings.Contains(err.Error(), "source.body.range.from") ||
+		!strings.Contains(err.Error(), `unknown filter "formt"`) {
+		t.Fatalf("got %v", err)
+	}
+
+	_, err = ParseSpec(map[string]any{
+		"source":  map[string]any{"url": "https://x.com/sales"},
+		"records": map[string]any{"inject": map[string]any{"month": "{{date|startOfMonth:1}}"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "records.inject.month") {
+		t.Fatalf("got %v", err)
+	}
+
+	// Variables inconnues tolérées ici : elles dépendent du contexte d'exécution.
+	if _, err := ParseSpec(map[string]any{
+		"source": map[string]any{"url": "https://x.com/{{extra.shop|urlencode}}", "query": map[string]any{"sig": "{{date|hmac_sha256:credentials.secret}}"}},
+	}); err != nil {
+		t.Fatalf("valid filters rejected: %v", err)
+	}
+}
+
+// #endregion
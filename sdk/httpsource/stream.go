@@ -0,0 +1,324 @@
+package httpsource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// #region streamPage
+// streamPage décode une page en mode records.stream et émet chaque ligne dès que son
+// élément est lu. La mémoire reste bornée par la taille d'UN élément, pas de la page :
+// un export de 800 Mo sur une seule page passe dans un container worker.
+//
+// Renvoie le squelette du document pour la pagination, le nombre de lignes émises,
+// et sépare l'erreur du callback emit (rendue telle quelle, comme hors streaming) des
+// erreurs de décodage.
+func streamPage(body io.Reader, spec *Spec, vars Vars, emit EmitFunc) (parsed any, rowCount int, emitErr error, err error) {
+	injected, err := renderInject(spec, vars)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	parsed, err = streamJSONRecords(body, spec.Records.Path, func(record map[string]any) error {
+		for _, row := range shapeRows([]map[string]any{record}, spec, injected) {
+			if emitErr = emit(row); emitErr != nil {
+				return emitErr
+			}
+			rowCount++
+		}
+		return nil
+	})
+	if emitErr != nil {
+		return nil, rowCount, emitErr, nil
+	}
+	return parsed, rowCount, nil, err
+}
+
+// #endregion
+
+// #region streamJSONRecords
+// streamJSONRecords lit un document JSON token par token et passe à onRecord chaque
+// élément du tableau situé à records.path, sans jamais matérialiser le tableau.
+//
+// Le reste du document est décodé normalement et renvoyé comme squelette, la valeur
+// à records.path remplacée par nil : c'est là que la pagination lit curseur et
+// has_more, qu'ils soient avant ou après le tableau. Un gros tableau HORS du chemin
+// serait donc chargé en entier — records.path doit viser le plus gros.
+//
+// Mêmes règles que parseJSONRecords : un objet unique donne une ligne, null aucune,
+// un chemin absent est une erreur qui montre un extrait du document.
+func streamJSONRecords(r io.Reader, path string, onRecord func(map[string]any) error) (any, error) {
+	var segments []string
+	if strings.TrimSpace(path) != "" {
+		segments = strings.Split(path, ".")
+	}
+	s := &jsonStreamer{dec: json.NewDecoder(r), path: path, segments: segments, onRecord: onRecord}
+
+	skeleton, err := s.value(0)
+	if err != nil {
+		return nil, s.wrap(err)
+	}
+	if _, err := s.dec.Token(); err != io.EOF {
+		return nil, newErr(KindInvalidData, 0, nil, "response is not valid JSON: unexpected data after the document (offset %d)", s.dec.InputOffset())
+	}
+
+	if !s.found {
+		_, navErr := navigatePath(skeleton, path)
+		if navErr == nil {
+			navErr = newErr(KindInvalidData, 0, nil, "records.path %q not found in the response", path)
+		}
+		excerpt, _ := json.Marshal(skeleton)
+		return nil, newErr(KindOf(navErr), 0, nil, "%s — response body: %s", navErr.Error(), truncate(string(excerpt), 300))
+	}
+	return skeleton, nil
+}
+
+// #endregion
+
+type jsonStreamer struct {
+	dec      *json.Decoder
+	path     string
+	segments []string
+	onRecord func(map[string]any) error
+	found    bool
+
+	// callbackErr distingue une erreur du callback (à rendre telle quelle) d'une
+	// erreur du décodeur.
+	callbackErr error
+}
+
+// #region value
+// value lit la valeur qui commence ici, sachant qu'elle se trouve à
+// segments[:depth] du chemin.
+func (s *jsonStreamer) value(depth int) (any, error) {
+	if depth == len(s.segments) {
+		s.found = true
+		return nil, s.target()
+	}
+
+	tok, err := s.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		// Pas un objet : le chemin s'arrête là, navigatePath dira pourquoi.
+		return s.rest(tok)
+	}
+
+	obj := map[string]any{}
+	for s.dec.More() {
+		keyTok, err := s.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := keyTok.(string)
+		var v any
+		if key == s.segments[depth] && !s.found {
+			v, err = s.value(depth + 1)
+		} else {
+			err = s.dec.Decode(&v)
+		}
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = v
+	}
+	if _, err := s.dec.Token(); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// #endregion
+
+// #region target
+// target parcourt la valeur à records.path élément par élément.
+func (s *jsonStreamer) target() error {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case nil:
+		return nil
+
+	case json.Delim('['):
+		for i := 0; s.dec.More(); i++ {
+			var item any
+			if err := s.dec.Decode(&item); err != nil {
+				return err
+			}
+			m, ok := item.(map[string]any)
+			if !ok {
+				return newErr(KindInvalidData, 0, nil,
+					"records.path %q: element %d is a %T, expected an object", s.path, i, item)
+			}
+			if err := s.onRecord(m); err != nil {
+				s.callbackErr = err
+				return err
+			}
+		}
+		_, err := s.dec.Token()
+		return err
+
+	case json.Delim('{'):
+		obj, err := s.rest(tok)
+		if err != nil {
+			return err
+		}
+		if err := s.onRecord(obj.(map[string]any)); err != nil {
+			s.callbackErr = err
+			return err
+		}
+		return nil
+
+	default:
+		return newErr(KindInvalidData, 0, nil,
+			"records.path %q points to a %T, expected an array or an object", s.path, tok)
+	}
+}
+
+// #endregion
+
+// #region rest
+// rest termine le décodage d'une valeur dont le premier token est déjà lu.
+func (s *jsonStreamer) rest(tok json.Token) (any, error) {
+	switch tok {
+	case json.Delim('{'):
+		obj := map[string]any{}
+		for s.dec.More() {
+			keyTok, err := s.dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			var v any
+			if err := s.dec.Decode(&v); err != nil {
+				return nil, err
+			}
+			key, _ := keyTok.(string)
+			obj[key] = v
+		}
+		_, err := s.dec.Token()
+		return obj, err
+
+	case json.Delim('['):
+		arr := []any{}
+		for s.dec.More() {
+			var v any
+			if err := s.dec.Decode(&v); err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := s.dec.Token()
+		return arr, err
+
+	default:
+		return tok, nil
+	}
+}
+
+// #endregion
+
+// #region wrap
+// wrap classe une erreur de décodage : JSON illisible → invalid_data ; coupure de la
+// connexion en cours de lecture → unavailable. Les erreurs du moteur et du callback
+// passent telles quelles.
+func (s *jsonStreamer) wrap(err error) error {
+	if s.callbackErr != nil && err == s.callbackErr {
+		return err
+	}
+	var engineErr *Error
+	if errors.As(err, &engineErr) {
+		return err
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || err == io.ErrUnexpectedEOF || err == io.EOF {
+		return newErr(KindInvalidData, 0, err, "response is not valid JSON (offset %d)", s.dec.InputOffset())
+	}
+	return newErr(KindUnavailable, 0, err, "response body read failed after %d byte(s)", s.dec.InputOffset())
+}
+
+// #endregion
+
+// idleTimeout remplace http.Client.Timeout en mode records.stream : ce dernier
+// couvre aussi la lecture du corps, et couperait un export de 800 Mo au bout de
+// source.timeoutSeconds, lignes déjà émises. Ici le délai borne l'attente des
+// en-têtes puis CHAQUE silence entre deux lectures : un corps lent mais vivant passe,
+// un corps bloqué est coupé.
+type idleTimeout struct {
+	d      time.Duration
+	cancel context.CancelFunc
+	timer  *time.Timer
+	fired  atomic.Bool
+}
+
+// #region startIdleTimeout
+// startIdleTimeout arme le délai et renvoie le contexte à poser sur la requête.
+func startIdleTimeout(ctx context.Context, d time.Duration) (context.Context, *idleTimeout) {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &idleTimeout{d: d, cancel: cancel}
+	t.timer = time.AfterFunc(d, func() {
+		t.fired.Store(true)
+		cancel()
+	})
+	return ctx, t
+}
+
+// #endregion
+
+// #region stop
+func (t *idleTimeout) stop() {
+	t.timer.Stop()
+	t.cancel()
+}
+
+// #endregion
+
+// #region pause
+// pause suspend le délai pendant emit : un callback lent (Upsert) n'est pas une
+// inactivité du serveur, et le corps n'est pas lu pendant ce temps.
+func (t *idleTimeout) pause(emit EmitFunc) EmitFunc {
+	return func(row map[string]any) error {
+		t.timer.Stop()
+		defer t.timer.Reset(t.d)
+		return emit(row)
+	}
+}
+
+// #endregion
+
+// idleBody : corps streamé dont chaque lecture réarme le délai.
+type idleBody struct {
+	io.ReadCloser
+	idle *idleTimeout
+}
+
+// #region Read
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.idle.timer.Reset(b.idle.d)
+	}
+	if err != nil && err != io.EOF && b.idle.fired.Load() {
+		return n, newErr(KindUnavailable, 0, err, "no data received for %s while streaming the response (source.timeoutSeconds)", b.idle.d)
+	}
+	return n, err
+}
+
+// #endregion
+
+// #region Close
+func (b *idleBody) Close() error {
+	b.idle.stop()
+	return b.ReadCloser.Close()
+}
+
+// #endregion
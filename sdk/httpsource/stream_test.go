@@ -0,0 +1,216 @@
+package httpsource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// endlessArray produit `{"meta":{…},"data":[{"id":0},{"id":1},…` sans fin : un corps
+// qu'aucun io.ReadAll ne peut terminer.
+type endlessArray struct {
+	buf  []byte
+	next int
+}
+
+func (e *endlessArray) Read(p []byte) (int, error) {
+	if e.buf == nil {
+		e.buf = []byte(`{"meta":{"next":"x"},"data":[`)
+	}
+	for len(e.buf) < len(p) {
+		if e.next > 0 {
+			e.buf = append(e.buf, ',')
+		}
+		e.buf = append(e.buf, fmt.Sprintf(`{"id":%d,"pad":"%s"}`, e.next, strings.Repeat("x", 64))...)
+		e.next++
+	}
+	n := copy(p, e.buf)
+	e.buf = e.buf[n:]
+	return n, nil
+}
+
+// #region TestStreamJSONRecords_EmitsBeforeTheEndOfTheBody
+// Preuve du streaming : les lignes sortent alors que le corps n'a pas de fin.
+func TestStreamJSONRecords_EmitsBeforeTheEndOfTheBody(t *testing.T) {
+	seen := 0
+	_, err := streamJSONRecords(&endlessArray{}, "data", func(row map[string]any) error {
+		if row["id"] != float64(seen) {
+			t.Fatalf("row %d: %v", seen, row)
+		}
+		if seen++; seen == 50000 {
+			return ErrStop
+		}
+		return nil
+	})
+	if KindOf(err) != KindStopped || seen != 50000 {
+		t.Fatalf("seen=%d err=%v", seen, err)
+	}
+}
+
+// #endregion
+
+// #region TestStreamJSONRecords_Shapes
+func TestStreamJSONRecords_Shapes(t *testing.T) {
+	collectStream := func(body, path string) ([]map[string]any, any, error) {
+		var rows []map[string]any
+		skeleton, err := streamJSONRecords(strings.NewReader(body), path, func(row map[string]any) error {
+			rows = append(rows, row)
+			return nil
+		})
+		return rows, skeleton, err
+	}
+
+	rows, skeleton, err := collectStream(`{"result":{"items":[{"a":1},{"a":2}],"paging":{"next":"c2"}},"has_more":true}`, "result.items")
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("rows=%v err=%v", rows, err)
+	}
+	if next, _ := navigateOptional(skeleton, "result.paging.next"); next != "c2" {
+		t.Errorf("cursor after the array must be in the skeleton: %v", skeleton)
+	}
+	if more, _ := navigateOptional(skeleton, "has_more"); more != true {
+		t.Errorf("has_more: %v", skeleton)
+	}
+
+	if rows, _, err := collectStream(`[{"a":1}]`, ""); err != nil || len(rows) != 1 {
+		t.Errorf("root array: rows=%v err=%v", rows, err)
+	}
+	if rows, _, err := collectStream(`{"data":{"id":7}}`, "data"); err != nil || len(rows) != 1 || rows[0]["id"] != float64(7) {
+		t.Errorf("single object: rows=%v err=%v", rows, err)
+	}
+	if rows, _, err := collectStream(`{"data":null}`, "data"); err != nil || len(rows) != 0 {
+		t.Errorf("null: rows=%v err=%v", rows, err)
+	}
+
+	errorCases := []struct {
+		name, body, want string
+	}{
+		{"missing path shows an excerpt", `{"error":"quota exceeded"}`, "quota exceeded"},
+		{"scalar element", `{"data":[{"a":1},2]}`, "element 1"},
+		{"truncated body", `{"data":[{"a":1},{"a"`, "not valid JSON"},
+		{"trailing garbage", `{"data":[]} {}`, "after the document"},
+	}
+	for _, c := range errorCases {
+		t.Run(c.name, func(t *testing.T) {
+			_, _, err := collectStream(c.body, "data")
+			if KindOf(err) != KindInvalidData || !strings.Contains(err.Error(), c.want) {
+				t.Fatalf("want invalid_data containing %q, got %v", c.want, err)
+			}
+		})
+	}
+}
+
+// #endregion
+
+// #region TestFetch_StreamWithCursorExplodeAndInject
+func TestFetch_StreamWithCursorExplodeAndInject(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "" {
+			io.WriteString(w, `{"orders":[{"id":"A","lines":[{"sku":1},{"sku":2}]},{"id":"B","lines":[]}],"next":"p2"}`)
+			return
+		}
+		io.WriteString(w, `{"next":null,"orders":[{"id":"C","lines":[{"sku":3}]}]}`)
+	}))
+	defer srv.Close()
+
+	spec := mustSpec(t, map[string]any{
+		"source":     map[string]any{"url": srv.URL},
+		"pagination": map[string]any{"type": "cursor", "param": "cursor", "cursorPath": "next"},
+		"records": map[string]any{
+			"path": "orders", "stream": true, "explode": "lines",
+			"inject": map[string]any{"day": "{{date}}"},
+		},
+	})
+
+	rows, stats := collect(t, fastEngine(nil), spec, Vars{Date: "2026-08-12"})
+	if stats.Pages != 2 || stats.Rows != 4 || len(rows) != 4 {
+		t.Fatalf("stats=%+v rows=%v", stats, rows)
+	}
+	if rows[1]["lines"].(map[string]any)["sku"] != float64(2) || rows[2]["id"] != "B" || rows[3]["day"] != "2026-08-12" {
+		t.Errorf("rows: %v", rows)
+	}
+}
+
+// #endregion
+
+// #region TestFetch_StreamTimeoutIsIdleNotTotal
+// source.timeoutSeconds borne le silence entre deux lectures, pas la page : un export
+// lent mais régulier dépasse le délai au total sans être coupé ; un corps qui se tait
+// plus longtemps l'est, en KindUnavailable.
+func TestFetch_StreamTimeoutIsIdleNotTotal(t *testing.T) {
+	slowServer := func(gap time.Duration) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `{"data":[{"id":0}`)
+			w.(http.Flusher).Flush()
+			for i := 1; i <= 3; i++ {
+				select {
+				case <-time.After(gap):
+				case <-r.Context().Done():
+					return
+				}
+				fmt.Fprintf(w, `,{"id":%d}`, i)
+				w.(http.Flusher).Flush()
+			}
+			io.WriteString(w, `]}`)
+		}))
+	}
+	spec := func(url string) *Spec {
+		return mustSpec(t, map[string]any{
+			"source":  map[string]any{"url": url, "timeoutSeconds": 1},
+			"records": map[string]any{"path": "data", "stream": true},
+		})
+	}
+
+	// 3 × 400 ms : 1,2 s au total pour un délai d'1 s.
+	slow := slowServer(400 * time.Millisecond)
+	defer slow.Close()
+	rows, stats := collect(t, fastEngine(nil), spec(slow.URL), Vars{})
+	if len(rows) != 4 || stats.Rows != 4 {
+		t.Fatalf("a slow but steady body must be read to the end: rows=%d stats=%+v", len(rows), stats)
+	}
+
+	// Serveur régulier, callback lent : 1,2 s dans emit ne compte pas comme une
+	// inactivité.
+	steady := slowServer(50 * time.Millisecond)
+	defer steady.Close()
+	var emitted int
+	_, err := fastEngine(nil).Fetch(context.Background(), spec(steady.URL), Vars{}, func(map[string]any) error {
+		if emitted++; emitted == 1 {
+			time.Sleep(1200 * time.Millisecond)
+		}
+		return nil
+	})
+	if err != nil || emitted != 4 {
+		t.Fatalf("a slow emit must not trip the idle timeout: err=%v rows=%d", err, emitted)
+	}
+
+	stalled := slowServer(1500 * time.Millisecond)
+	defer stalled.Close()
+	var got []map[string]any
+	_, err = fastEngine(nil).Fetch(context.Background(), spec(stalled.URL), Vars{}, func(row map[string]any) error {
+		got = append(got, row)
+		return nil
+	})
+	if err == nil || KindOf(err) != KindUnavailable || !strings.Contains(err.Error(), "no data received for 1s") || len(got) != 1 {
+		t.Fatalf("a stalled body must be cut: err=%v rows=%d", err, len(got))
+	}
+}
+
+// #endregion
+
+// #region TestValidate_StreamIsJSONOnly
+func TestValidate_StreamIsJSONOnly(t *testing.T) {
+	_, err := ParseSpec(map[string]any{
+		"source":  map[string]any{"url": "https://x.com"},
+		"records": map[string]any{"format": "csv", "stream": true},
+	})
+	if err == nil || !strings.Contains(err.Error(), "records.stream") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+// #endregion
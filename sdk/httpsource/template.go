@@ -6,7 +6,6 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"time"
 )
 
 const credentialsPrefix = "credentials."
@@ -31,12 +30,36 @@ type Vars struct {
 	// Extra permet à un connecteur d'exposer ses propres variables sans modifier le
 	// moteur (accessibles via {{extra.<clé>}}).
 	Extra map[string]any
+
+	// Entry est le nom du fichier en cours de lecture dans une réponse compressée
+	// (entrée zip, nom d'origine d'un gzip) : {{entry}}, pour records.inject. Posé
+	// par le moteur, vide partout ailleurs.
+	Entry string
+
+	// JobID / JobDownloadURL : {{job.id}} et {{job.downloadUrl}} d'un rapport
+	// asynchrone (cf Job). Posés par le moteur après la création et le poll.
+	JobID          string
+	JobDownloadURL string
+
+	// Parent est la ligne parente d'une requête enfant : {{parent.<chemin>}} (cf
+	// Child). Nil hors chaîne.
+	Parent map[string]any
+
+	// WindowStart / WindowEnd : {{window.start}} et {{window.end}}, bornes incluses
+	// de la fenêtre en cours (cf Window). Posés par le moteur.
+	WindowStart string
+	WindowEnd   string
+
+	// request : {{request.*}}, la requête en cours de signature (cf HMACAuth). Posé
+	// par le moteur le temps de la signature seulement.
+	request map[string]any
 }
 
-// varPattern capture {{ nom }} et {{ nom|filtre:arg }}. Volontairement restrictif sur
-// les caractères autorisés : un `{{` non fermé ou une expression exotique doit
-// remonter comme variable inconnue, pas être ignoré silencieusement.
-var varPattern = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_.\-]+)\s*(?:\|\s*([a-zA-Z0-9_]+)\s*:\s*([^}]*?)\s*)?\}\}`)
+// varPattern capture {{ nom }} et {{ nom|filtre|filtre:arg }} (cf filters.go).
+// Volontairement restrictif sur les caractères autorisés : un `{{` non fermé ou une
+// expression exotique doit remonter comme variable inconnue, pas être ignoré
+// silencieusement.
+var varPattern = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_.\-]+)\s*((?:\|[^|{}]*)*)\}\}`)
 
 // #region Render
 // Render substitue les variables de tmpl.
@@ -44,7 +67,8 @@ var varPattern = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_.\-]+)\s*(?:\|\s*([a-zA-
 // STRICT par construction : une variable inconnue ou vide est une ERREUR, pas une
 // chaîne vide. Une URL qui devient `?date=` au lieu de `?date=2026-08-12` renvoie
 // souvent un 200 avec un jeu de données faux — c'est le pire scénario possible
-// (données silencieusement erronées en base). Mieux vaut échouer bruyamment.
+// (données silencieusement erronées en base). Mieux vaut échouer bruyamment. Seul
+// le filtre `default` assume explicitement une valeur de repli.
 func Render(tmpl string, vars Vars) (string, error) {
 	if tmpl == "" {
 		return "", nil
@@ -53,23 +77,13 @@ func Render(tmpl string, vars Vars) (string, error) {
 	var firstErr error
 	out := varPattern.ReplaceAllStringFunc(tmpl, func(match string) string {
 		groups := varPattern.FindStringSubmatch(match)
-		name, filter, arg := groups[1], groups[2], groups[3]
-
-		value, err := vars.lookup(name)
+		rendered, err := renderExpression(groups[1], groups[2], vars)
 		if err != nil {
 			if firstErr == nil {
 				firstErr = err
 			}
 			return match
 		}
-
-		rendered, err := applyFilter(value, filter, arg)
-		if err != nil {
-			if firstErr == nil {
-				firstErr = fmt.Errorf("variable %q: %w", name, err)
-			}
-			return match
-		}
 		return rendered
 	})
 
@@ -90,6 +104,32 @@ func Render(tmpl string, vars Vars) (string, error) {
 
 // #endregion
 
+// #region renderExpression
+func renderExpression(name, pipeline string, vars Vars) (string, error) {
+	calls, err := parsePipeline(pipeline)
+	if err != nil {
+		return "", fmt.Errorf("variable %q: %w", name, err)
+	}
+
+	value, err := vars.lookup(name)
+	if err != nil {
+		// `default` ne rattrape qu'une variable connue mais absente ou vide : une
+		// faute de frappe dans le nom reste une erreur.
+		if len(calls) == 0 || calls[0].name != "default" || !knownVariable(name) {
+			return "", err
+		}
+		value = calls[0].arg
+	}
+
+	rendered, err := applyFilters(value, calls, filterCtx{vars: vars, secret: strings.HasPrefix(name, credentialsPrefix)})
+	if err != nil {
+		return "", fmt.Errorf("variable %q: %w", name, err)
+	}
+	return rendered, nil
+}
+
+// #endregion
+
 // #region RenderMap
 // RenderMap applique Render à toutes les valeurs d'une map. Les clés ne sont PAS
 // templatées : un nom de header ou de paramètre dynamique rendrait le conf.yml
@@ -120,6 +160,14 @@ func RenderMap(in map[string]string, vars Vars) (map[string]string, error) {
 
 // #endregion
 
+// scalarVariables / nestedVariables : les noms reconnus par lookup, pour les
+// messages d'erreur et la validation statique (cf knownVariable).
+var (
+	scalarVariables = []string{"date", "startDate", "endDate", "adAccount.id", "adAccount.name",
+		"entry", "job.id", "job.downloadUrl", "window.start", "window.end"}
+	nestedVariables = []string{"credentials", "connectorConf", "extra", "parent", "request"}
+)
+
 // #region lookup
 func (v Vars) lookup(name string) (any, error) {
 	switch {
@@ -133,6 +181,16 @@ func (v Vars) lookup(name string) (any, error) {
 		return v.requireNonEmpty("adAccount.id", v.AdAccountID)
 	case name == "adAccount.name":
 		return v.requireNonEmpty("adAccount.name", v.AdAccountName)
+	case name == "entry":
+		return v.requireNonEmpty("entry", v.Entry)
+	case name == "job.id":
+		return v.requireNonEmpty("job.id", v.JobID)
+	case name == "job.downloadUrl":
+		return v.requireNonEmpty("job.downloadUrl", v.JobDownloadURL)
+	case name == "window.start":
+		return v.requireNonEmpty("window.start", v.WindowStart)
+	case name == "window.end":
+		return v.requireNonEmpty("window.end", v.WindowEnd)
 
 	case strings.HasPrefix(name, credentialsPrefix):
 		return lookupNested(v.Credentials, strings.TrimPrefix(name, credentialsPrefix), "credentials")
@@ -140,11 +198,42 @@ func (v Vars) lookup(name string) (any, error) {
 		return lookupNested(v.ConnectorConf, strings.TrimPrefix(name, "connectorConf."), "connectorConf")
 	case strings.HasPrefix(name, "extra."):
 		return lookupNested(v.Extra, strings.TrimPrefix(name, "extra."), "extra")
+	case strings.HasPrefix(name, "parent."):
+		return lookupNested(v.Parent, strings.TrimPrefix(name, "parent."), "parent")
+	case strings.HasPrefix(name, "request."):
+		if v.request == nil {
+			return nil, newErr(KindInvalidSpec, 0, nil, "{{%s}} is only available in auth.hmac templates", name)
+		}
+		// Pas de requireNonEmpty : une query ou un corps vide se signe aussi.
+		value, ok := v.request[strings.TrimPrefix(name, "request.")]
+		if !ok {
+			return nil, newErr(KindInvalidSpec, 0, nil,
+				"{{%s}}: unknown request field (available: %s)", name, strings.Join(sortedMapKeys(v.request), ", "))
+		}
+		return value, nil
 
 	default:
 		return nil, newErr(KindInvalidSpec, 0, nil,
-			"unknown template variable {{%s}} (available: date, startDate, endDate, adAccount.id, adAccount.name, credentials.*, connectorConf.*, extra.*)", name)
+			"unknown template variable {{%s}} (available: %s, %s.*)", name,
+			strings.Join(scalarVariables, ", "), strings.Join(nestedVariables, ".*, "))
+	}
+}
+
+// #endregion
+
+// #region knownVariable
+func knownVariable(name string) bool {
+	for _, v := range scalarVariables {
+		if name == v {
+			return true
+		}
+	}
+	for _, root := range nestedVariables {
+		if strings.HasPrefix(name, root+".") && len(name) > len(root)+1 {
+			return true
+		}
 	}
+	return false
 }
 
 // #endregion
@@ -193,41 +282,6 @@ func lookupNested(src map[string]any, path, root string) (any, error) {
 
 // #endregion
 
-// #region applyFilter
-// applyFilter gère les filtres de template. Un seul pour l'instant : `format` sur une
-// date. On garde le mécanisme extensible mais on n'ajoute un filtre que quand une API
-// réelle l'exige — chaque filtre est une syntaxe de plus à connaître pour relire un
-// conf.yml.
-func applyFilter(value any, filter, arg string) (string, error) {
-	str := stringify(value)
-
-	switch filter {
-	case "":
-		return str, nil
-
-	case "format":
-		if arg == "" {
-			return "", fmt.Errorf("filter format requires a layout, e.g. |format:2006-01-02")
-		}
-		// La valeur d'entrée est une date au format Quanti (2006-01-02) ; on la
-		// reformate au layout Go demandé. `epoch` est traité à part car ce n'est pas
-		// un layout Go.
-		parsed, err := time.Parse("2006-01-02", str)
-		if err != nil {
-			return "", fmt.Errorf("value %q is not a YYYY-MM-DD date, cannot apply format", str)
-		}
-		if arg == "epoch" {
-			return strconv.FormatInt(parsed.Unix(), 10), nil
-		}
-		return parsed.Format(arg), nil
-
-	default:
-		return "", fmt.Errorf("unknown filter %q (available: format)", filter)
-	}
-}
-
-// #endregion
-
 // #region stringify
 // stringify convertit une valeur de credential/conf en chaîne. Les entiers JSON
 // arrivent en float64 : les rendre via %v produirait "1.234567e+06" pour un gros ID.
@@ -80,6 +80,70 @@ func TestRender_FormatFilter(t *testing.T) {
 
 // #endregion
 
+// #region TestRender_FilterPipelines
+func TestRender_FilterPipelines(t *testing.T) {
+	vars := testVars()
+	vars.Credentials["hmackey"] = "key"
+	vars.Extra["message"] = "The quick brown fox jumps over the lazy dog"
+	vars.Extra["quoted"] = `say "hi"`
+
+	cases := []struct{ tmpl, want string }{
+		{"{{date|addDays:-1|format:20060102}}", "20260811"},
+		{"{{date | addDays:20 | startOfMonth}}", "2026-09-01"},
+		{"{{startDate|endOfMonth}}", "2026-08-31"},
+		{"{{date|startOfMonth|addDays:-1|format:02/01/2006}}", "31/07/2026"},
+		{"{{adAccount.name|lower}}", "account one"},
+		{"{{credentials.tenant|upper}}", "ACME"},
+		{"{{adAccount.name|urlencode}}", "Account+One"},
+		{"{{credentials.tenant|base64}}", "YWNtZQ=="},
+		{"{{extra.foo|sha256}}", "fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"},
+		{"{{extra.message|hmac_sha256:credentials.hmackey}}", "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{"{{extra.quoted|json}}", `"say \"hi\""`},
+		{"{{credentials.nested|json}}", `{"token":"NESTED-TOKEN"}`},
+		{"{{entry|default:all}}", "all"},
+		{"{{credentials.absent|default:eu|upper}}", "EU"},
+		{"{{date|default:2020-01-01}}", "2026-08-12"},
+		{"{{date|format:2006-01-02T15:04:05}}", "2026-08-12T00:00:00"},
+	}
+	for _, c := range cases {
+		got, err := Render(c.tmpl, vars)
+		if err != nil || got != c.want {
+			t.Errorf("Render(%q) = %q (%v), want %q", c.tmpl, got, err, c.want)
+		}
+	}
+}
+
+// #endregion
+
+// #region TestRender_FilterErrors
+func TestRender_FilterErrors(t *testing.T) {
+	cases := []struct{ tmpl, want string }{
+		{"{{date|lower:x}}", "filter lower takes no argument"},
+		{"{{date|addDays}}", "filter addDays requires a number of days"},
+		{"{{date|addDays:one}}", "not a whole number of days"},
+		{"{{date|upper|default:x}}", "filter default must come first"},
+		{"{{extra.foo|hmac_sha256:secret}}", "is not a template variable"},
+		{"{{date|format:2006|}}", "invalid filter expression"},
+		// `default` rattrape une valeur absente, pas une faute de frappe.
+		{"{{dat|default:2026-01-01}}", "unknown template variable"},
+		{"{{extra.foo|addDays:1}}", `value "bar" is not a YYYY-MM-DD date`},
+	}
+	for _, c := range cases {
+		_, err := Render(c.tmpl, testVars())
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("Render(%q): want an error containing %q, got %v", c.tmpl, c.want, err)
+		}
+	}
+
+	// Un filtre de date sur une credential ne cite jamais la valeur.
+	_, err := Render("{{credentials.apikey|lower|addDays:1}}", testVars())
+	if err == nil || strings.Contains(strings.ToLower(err.Error()), "secret-key") {
+		t.Fatalf("the error leaks a credential value: %v", err)
+	}
+}
+
+// #endregion
+
 // #region TestRender_StrictOnUnknownAndEmpty
 // LE test le plus important du fichier. Une variable inconnue ou vide qui se
 // substituerait en chaîne vide produirait une URL du type `?date=` — que beaucoup
@@ -97,7 +161,7 @@ func TestRender_StrictOnUnknownAndEmpty(t *testing.T) {
 		{"empty date", "{{date}}", Vars{}, "is empty for this request"},
 		{"missing credential key", "{{credentials.absent}}", testVars(), "not found"},
 		{"no credentials at all", "{{credentials.apikey}}", Vars{Date: "2026-08-12"}, "no credentials available"},
-		{"unknown filter", "{{date|upper:x}}", testVars(), "unknown filter"},
+		{"unknown filter", "{{date|shout:x}}", testVars(), "unknown filter"},
 		{"format without layout", "{{date|format:}}", testVars(), "requires a layout"},
 		{"unclosed braces", "{{date", testVars(), "unresolved template expression"},
 		{"space inside name", "{{ad Account.id}}", testVars(), "unresolved template expression"},
@@ -0,0 +1,180 @@
+package httpsource
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window découpe la période du process (StartDate..EndDate) en fenêtres exécutées
+// l'une après l'autre, pour les API qui acceptent un intervalle : un appel par
+// fenêtre au lieu d'un par jour, et jamais plus que la portée maximale de l'API.
+//
+// Chaque fenêtre dispose de {{window.start}} et {{window.end}} (YYYY-MM-DD, bornes
+// incluses), utilisables partout, y compris dans records.inject pour dater les
+// lignes d'un total par fenêtre.
+type Window struct {
+	// Size : taille d'une fenêtre en Unit (défaut 1).
+	Size int `json:"size"`
+
+	// Unit : `day` (défaut) ou `month`. Les fenêtres mensuelles sont alignées sur le
+	// calendrier : une période du 15/01 au 10/03 donne 15/01–31/01, 01/02–28/02,
+	// 01/03–10/03, et non des tranches de 30 jours qui chevauchent deux mois.
+	Unit string `json:"unit"`
+
+	// MaxSpanDays : portée maximale acceptée par l'API (ex: 31). Une fenêtre
+	// mensuelle plus longue est recoupée ; en jours, size ne peut pas la dépasser.
+	MaxSpanDays int `json:"maxSpanDays"`
+
+	// DatePath : chemin, dans chaque ligne, du jour auquel elle appartient
+	// (YYYY-MM-DD, ou un datetime qui commence ainsi). Le plan du SDK reste journalier :
+	// c'est ce jour qui rattache la ligne à son élément du plan (cf restrunner).
+	DatePath string `json:"datePath"`
+}
+
+const (
+	WindowDay   = "day"
+	WindowMonth = "month"
+)
+
+// dateLayout : format des dates Quanti.
+const dateLayout = "2006-01-02"
+
+// #region split
+// split renvoie les fenêtres [début, fin] (bornes incluses) couvrant start..end.
+func (w *Window) split(start, end string) ([][2]string, error) {
+	from, err := time.Parse(dateLayout, start)
+	if err != nil {
+		return nil, newErr(KindInvalidSpec, 0, nil, "window: startDate %q is not a YYYY-MM-DD date", start)
+	}
+	to, err := time.Parse(dateLayout, end)
+	if err != nil {
+		return nil, newErr(KindInvalidSpec, 0, nil, "window: endDate %q is not a YYYY-MM-DD date", end)
+	}
+	if to.Before(from) {
+		return nil, newErr(KindInvalidSpec, 0, nil, "window: endDate %s is before startDate %s", end, start)
+	}
+
+	var windows [][2]string
+	for cur := from; !cur.After(to); {
+		var last time.Time
+		if w.Unit == WindowMonth {
+			firstOfMonth := time.Date(cur.Year(), cur.Month(), 1, 0, 0, 0, 0, time.UTC)
+			last = firstOfMonth.AddDate(0, w.Size, -1)
+		} else {
+			last = cur.AddDate(0, 0, w.Size-1)
+		}
+		if w.MaxSpanDays > 0 {
+			if capped := cur.AddDate(0, 0, w.MaxSpanDays-1); capped.Before(last) {
+				last = capped
+			}
+		}
+		if last.After(to) {
+			last = to
+		}
+		windows = append(windows, [2]string{cur.Format(dateLayout), last.Format(dateLayout)})
+		cur = last.AddDate(0, 0, 1)
+	}
+	return windows, nil
+}
+
+// #endregion
+
+// #region RowDate
+// RowDate lit le jour d'une ligne à DatePath. ok=false si le champ manque ou ne
+// commence pas par une date YYYY-MM-DD.
+func (w *Window) RowDate(row map[string]any) (string, bool) {
+	v, ok := navigateOptional(row, w.DatePath)
+	if !ok {
+		return "", false
+	}
+	s := stringify(v)
+	if len(s) < len(dateLayout) {
+		return "", false
+	}
+	if _, err := time.Parse(dateLayout, s[:len(dateLayout)]); err != nil {
+		return "", false
+	}
+	return s[:len(dateLayout)], true
+}
+
+// #endregion
+
+// #region fetchWindows
+// fetchWindows exécute la spec une fois par fenêtre, dans l'ordre. Les statistiques
+// cumulent toutes les fenêtres ; une erreur arrête la collecte et nomme sa fenêtre.
+func (e *Engine) fetchWindows(ctx context.Context, spec *Spec, vars Vars, emit EmitFunc, auth *authenticator, redactor *Redactor) (Stats, error) {
+	var total Stats
+
+	if vars.StartDate == "" || vars.EndDate == "" {
+		return total, newErr(KindInvalidSpec, 0, nil, "window requires startDate and endDate to be set for this request")
+	}
+	windows, err := spec.Window.split(vars.StartDate, vars.EndDate)
+	if err != nil {
+		return total, err
+	}
+	e.logger.Infof("httpsource: %s..%s split into %d window(s)", vars.StartDate, vars.EndDate, len(windows))
+
+	stopped := false
+	windowEmit := func(row map[string]any) error {
+		err := emit(row)
+		if err != nil && KindOf(err) == KindStopped {
+			stopped = true
+		}
+		return err
+	}
+
+	for _, w := range windows {
+		windowVars := vars
+		windowVars.WindowStart, windowVars.WindowEnd = w[0], w[1]
+
+		stats, err := e.fetchSpec(ctx, spec, windowVars, windowEmit, auth, redactor)
+		total.Pages += stats.Pages
+		total.Rows += stats.Rows
+		total.Attempts += stats.Attempts
+		total.Waited += stats.Waited
+
+		if err != nil {
+			if werr, ok := err.(*Error); ok {
+				return total, &Error{Kind: werr.Kind, Status: werr.Status, Cause: werr.Cause,
+					Message: fmt.Sprintf("window %s..%s: %s", w[0], w[1], werr.Message)}
+			}
+			// Erreur du callback emit : remontée telle quelle.
+			return total, err
+		}
+		if stopped {
+			break
+		}
+	}
+	return total, nil
+}
+
+// #endregion
+
+// #region validateWindow
+func (s *Spec) validateWindow() error {
+	if s.Child != nil && s.Child.Window != nil {
+		return newErr(KindInvalidSpec, 0, nil, "child.window is not supported: the window applies to the whole chain")
+	}
+	w := s.Window
+	if w == nil {
+		return nil
+	}
+	if w.Unit != WindowDay && w.Unit != WindowMonth {
+		return newErr(KindInvalidSpec, 0, nil, "window.unit %q is not supported (day or month)", w.Unit)
+	}
+	if w.Size < 1 || w.MaxSpanDays < 0 {
+		return newErr(KindInvalidSpec, 0, nil, "window.size must be at least 1 and window.maxSpanDays cannot be negative")
+	}
+	if w.Unit == WindowDay && w.MaxSpanDays > 0 && w.Size > w.MaxSpanDays {
+		return newErr(KindInvalidSpec, 0, nil, "window.size (%d days) exceeds window.maxSpanDays (%d)", w.Size, w.MaxSpanDays)
+	}
+	if strings.TrimSpace(w.DatePath) == "" {
+		return newErr(KindInvalidSpec, 0, nil,
+			"window.datePath is required: each row must name its day (for per-window totals, inject {{window.start}} with records.inject)")
+	}
+	return nil
+}
+
+// #endregion
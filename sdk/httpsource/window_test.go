@@ -0,0 +1,128 @@
+package httpsource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// #region TestWindow_Split
+func TestWindow_Split(t *testing.T) {
+	cases := []struct {
+		name   string
+		window Window
+		start  string
+		end    string
+		want   string
+	}{
+		{"days", Window{Size: 7, Unit: WindowDay}, "2026-08-01", "2026-08-16",
+			"[[2026-08-01 2026-08-07] [2026-08-08 2026-08-14] [2026-08-15 2026-08-16]]"},
+		{"single day", Window{Size: 31, Unit: WindowDay}, "2026-08-12", "2026-08-12",
+			"[[2026-08-12 2026-08-12]]"},
+		{"calendar months", Window{Size: 1, Unit: WindowMonth}, "2026-01-15", "2026-03-10",
+			"[[2026-01-15 2026-01-31] [2026-02-01 2026-02-28] [2026-03-01 2026-03-10]]"},
+		{"quarter capped by maxSpanDays", Window{Size: 3, Unit: WindowMonth, MaxSpanDays: 31}, "2026-01-01", "2026-03-31",
+			"[[2026-01-01 2026-01-31] [2026-02-01 2026-03-03] [2026-03-04 2026-03-31]]"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := c.window.split(c.start, c.end)
+			if err != nil || fmt.Sprint(got) != c.want {
+				t.Errorf("got %v (%v), want %s", got, err, c.want)
+			}
+		})
+	}
+
+	if _, err := (&Window{Size: 1, Unit: WindowDay}).split("2026-08-12", "2026-08-01"); KindOf(err) != KindInvalidSpec {
+		t.Errorf("reversed range: %v", err)
+	}
+}
+
+// #endregion
+
+// #region TestFetch_Windows
+func TestFetch_Windows(t *testing.T) {
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+		calls = append(calls, from+".."+to)
+		if from == "2026-08-15" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"range too large"}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":[{"clicks":3}]}`)
+	}))
+	defer srv.Close()
+
+	spec := func(size int) *Spec {
+		return mustSpec(t, map[string]any{
+			"source":  map[string]any{"url": srv.URL, "query": map[string]any{"from": "{{window.start}}", "to": "{{window.end}}"}},
+			"records": map[string]any{"path": "data", "inject": map[string]any{"day": "{{window.start}}", "until": "{{window.end}}"}},
+			"window":  map[string]any{"size": size, "datePath": "day"},
+		})
+	}
+	vars := Vars{StartDate: "2026-08-01", EndDate: "2026-08-14"}
+
+	rows, stats := collect(t, fastEngine(nil), spec(7), vars)
+	if strings.Join(calls, ",") != "2026-08-01..2026-08-07,2026-08-08..2026-08-14" || stats.Pages != 2 {
+		t.Fatalf("calls=%v stats=%+v", calls, stats)
+	}
+	if len(rows) != 2 || rows[1]["day"] != "2026-08-08" || rows[1]["until"] != "2026-08-14" {
+		t.Errorf("the window must be injected into each row: %v", rows)
+	}
+	if d, ok := spec(7).Window.RowDate(rows[1]); !ok || d != "2026-08-08" {
+		t.Errorf("RowDate: %q %v", d, ok)
+	}
+
+	// Une erreur nomme sa fenêtre ; ErrStop arrête toutes les fenêtres.
+	_, err := fastEngine(nil).Fetch(context.Background(), spec(7), Vars{StartDate: "2026-08-01", EndDate: "2026-08-21"},
+		func(map[string]any) error { return nil })
+	if KindOf(err) != KindInvalidSpec || !strings.Contains(err.Error(), "window 2026-08-15..2026-08-21") {
+		t.Errorf("got %v", err)
+	}
+
+	calls = nil
+	stats, err = fastEngine(nil).Fetch(context.Background(), spec(1), vars, func(map[string]any) error { return ErrStop })
+	if err != nil || len(calls) != 1 {
+		t.Errorf("ErrStop: err=%v calls=%v", err, calls)
+	}
+
+	_, err = fastEngine(nil).Fetch(context.Background(), spec(1), Vars{Date: "2026-08-12"}, func(map[string]any) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "startDate and endDate") {
+		t.Errorf("a window needs the process range: %v", err)
+	}
+}
+
+// #endregion
+
+// #region TestValidate_Window
+func TestValidate_Window(t *testing.T) {
+	cases := []struct {
+		window map[string]any
+		want   string
+	}{
+		{map[string]any{"unit": "week", "datePath": "day"}, "window.unit"},
+		{map[string]any{"size": 60, "maxSpanDays": 31, "datePath": "day"}, "exceeds window.maxSpanDays"},
+		{map[string]any{"size": 7}, "window.datePath is required"},
+	}
+	for _, c := range cases {
+		_, err := ParseSpec(map[string]any{"source": map[string]any{"url": "https://x.com"}, "window": c.window})
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%v: want %q, got %v", c.window, c.want, err)
+		}
+	}
+
+	_, err := ParseSpec(map[string]any{
+		"source": map[string]any{"url": "https://x.com"},
+		"child":  map[string]any{"source": map[string]any{"url": "https://x.com/{{parent.id}}"}, "window": map[string]any{"datePath": "d"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "child.window") {
+		t.Errorf("got %v", err)
+	}
+}
+
+// #endregion
@@ -0,0 +1,221 @@
+package httpsource
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// XMLOptions : lecture d'une réponse XML (records.format: xml).
+//
+// Le document est converti en map[string]any selon une règle FIXE, pour que les
+// fieldPath du schéma restent les mêmes d'une réponse à l'autre :
+//   - un élément sans attribut ni enfant devient sa valeur texte (nil s'il est vide) ;
+//   - sinon un objet : attributs préfixés (`@id`), enfants par nom, texte éventuel
+//     sous `#text` ;
+//   - un enfant répété devient un tableau. Un enfant qui n'apparaît qu'UNE fois reste
+//     un objet, sauf s'il est listé dans ArrayElements : à déclarer pour tout élément
+//     à exploser, sinon une commande à un seul article ne serait pas explosée.
+//
+// records.path est un chemin pointé d'éléments depuis la racine INCLUSE, jusqu'à
+// l'élément répété : `report.rows.row`.
+type XMLOptions struct {
+	AttributePrefix string `json:"attributePrefix"`
+	TextKey         string `json:"textKey"`
+
+	// Namespaces : préfixe → URI. Un élément dont l'espace de noms est déclaré ici est
+	// nommé `préfixe:nom`, quel que soit le préfixe choisi par l'API dans le document
+	// (il peut changer d'une réponse à l'autre). Tout autre élément est nommé par son
+	// seul nom local.
+	Namespaces map[string]string `json:"namespaces"`
+
+	ArrayElements []string `json:"arrayElements"`
+}
+
+// #region parseXMLRecords
+func parseXMLRecords(body []byte, path string, opts *XMLOptions) ([]map[string]any, error) {
+	tree, err := parseXMLTree(body, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := navigatePath(tree, path)
+	if err != nil {
+		// Même logique que le JSON : un 200 qui porte une enveloppe d'erreur SOAP
+		// (<Fault>) se diagnostique par son contenu.
+		return nil, newErr(KindOf(err), 0, nil, "%s — response body: %s",
+			err.Error(), truncate(strings.TrimSpace(string(body)), 300))
+	}
+	return rowsFromTarget(target, path)
+}
+
+// #endregion
+
+// #region parseXMLTree
+// parseXMLTree convertit le document en {racine: valeur}. C'est aussi ce que lit la
+// pagination (cursorPath: `response.paging.next`).
+func parseXMLTree(body []byte, opts *XMLOptions) (map[string]any, error) {
+	if opts == nil {
+		opts = &XMLOptions{}
+	}
+	attrPrefix, textKey := opts.AttributePrefix, opts.TextKey
+	if attrPrefix == "" {
+		attrPrefix = "@"
+	}
+	if textKey == "" {
+		textKey = "#text"
+	}
+	prefixes := make(map[string]string, len(opts.Namespaces))
+	for prefix, uri := range opts.Namespaces {
+		prefixes[uri] = prefix
+	}
+	arrays := make(map[string]bool, len(opts.ArrayElements))
+	for _, name := range opts.ArrayElements {
+		arrays[name] = true
+	}
+	keyFor := func(name xml.Name) string {
+		if prefix, ok := prefixes[name.Space]; ok && name.Space != "" {
+			return prefix + ":" + name.Local
+		}
+		return name.Local
+	}
+
+	type node struct {
+		key    string
+		fields map[string]any
+		text   strings.Builder
+	}
+	var stack []*node
+	var root map[string]any
+
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	dec.CharsetReader = xmlCharsetReader
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, newErr(KindInvalidData, 0, err, "response is not valid XML (first bytes: %q)", truncate(string(body), 120))
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{key: keyFor(t.Name), fields: map[string]any{}}
+			for _, attr := range t.Attr {
+				// Les déclarations xmlns ne sont pas des données.
+				if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+					continue
+				}
+				n.fields[attrPrefix+keyFor(attr.Name)] = attr.Value
+			}
+			stack = append(stack, n)
+
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+
+		case xml.EndElement:
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			var value any
+			text := strings.TrimSpace(n.text.String())
+			switch {
+			case len(n.fields) > 0:
+				if text != "" {
+					n.fields[textKey] = text
+				}
+				value = n.fields
+			case text != "":
+				value = text
+			}
+
+			if len(stack) == 0 {
+				root = map[string]any{n.key: value}
+				continue
+			}
+			parent := stack[len(stack)-1].fields
+			switch existing := parent[n.key].(type) {
+			case []any:
+				parent[n.key] = append(existing, value)
+			case nil:
+				if _, exists := parent[n.key]; exists {
+					// Premier exemplaire vide (<item/>) : c'est bien une répétition.
+					parent[n.key] = []any{nil, value}
+				} else if arrays[n.key] {
+					parent[n.key] = []any{value}
+				} else {
+					parent[n.key] = value
+				}
+			default:
+				parent[n.key] = []any{existing, value}
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, newErr(KindInvalidData, 0, nil, "response is not valid XML: no root element (first bytes: %q)", truncate(string(body), 120))
+	}
+	return root, nil
+}
+
+// #endregion
+
+// #region xmlCharsetReader
+// xmlCharsetReader accepte les encodages latins des API historiques
+// (`<?xml version="1.0" encoding="ISO-8859-1"?>`), que encoding/xml refuse seul.
+// windows-1252 est lu comme latin-1 : seuls 0x80-0x9F diffèrent (€, guillemets
+// typographiques), un écart acceptable face à un rejet du document entier.
+func xmlCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1", "windows-1252", "cp1252":
+		return &latin1Reader{r: bufio.NewReader(input)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported XML encoding %q", charset)
+	}
+}
+
+// latin1Reader transcode octet par octet en UTF-8.
+type latin1Reader struct {
+	r       *bufio.Reader
+	pending []byte
+}
+
+func (l *latin1Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(l.pending) > 0 {
+			c := copy(p[n:], l.pending)
+			l.pending = l.pending[c:]
+			n += c
+			continue
+		}
+		b, err := l.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if b < utf8.RuneSelf {
+			p[n] = b
+			n++
+			continue
+		}
+		var buf [utf8.UTFMax]byte
+		size := utf8.EncodeRune(buf[:], rune(b))
+		l.pending = append(l.pending[:0], buf[:size]...)
+	}
+	return n, nil
+}
+
+// #endregion
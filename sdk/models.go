@@ -72,6 +72,7 @@ type ConfigFile struct {
 	AdAccounts           []AdAccount            `json:"adAccounts"`
 	RequestParams        RequestParams          `json:"requestParams"`
 	ProcessId            string                 `json:"processId"`
+	Locale               string                 `json:"locale,omitempty"` // Langue des messages client (fr, en), cf SetLocale
 }
 
 // #region Requests
@@ -98,7 +99,7 @@ type DatabaseMetaData struct {
 	// Enrichissements IA (optionnels, rétro-compatibles)
 	Purpose      string `json:"purpose,omitempty"`       // À quoi ça sert
 	BusinessName string `json:"business_name,omitempty"` // Nom métier (ex: "Dépenses")
-	SemanticType string `json:"semantic_type,omitempty"` // id, dimension, metric, date, currency_micro
+	SemanticType string `json:"semantic_type,omitempty"` // id, dimension, metric, date, currency_micro, email, phone
 	FormatHint   string `json:"format_hint,omitempty"`   // divide_1000000, percentage
 	IsPII        bool   `json:"is_pii,omitempty"`        // Donnée personnelle identifiable
 }
@@ -0,0 +1,344 @@
+package sdk
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Politiques appliquées aux champs IsPII. `keep` est le défaut et reproduit le
+// comportement historique (donnée envoyée en clair).
+const (
+	PIIKeep = "keep"
+	PIIHash = "hash"
+	PIIMask = "mask"
+	PIIDrop = "drop"
+)
+
+// PIIPolicy est la politique d'un connecteur pour ses données personnelles.
+//
+// Salt est OBLIGATOIRE en mode hash : un SHA-256 nu d'un e-mail se renverse par
+// dictionnaire en quelques minutes. Il vient des credentials du compte (cf
+// PIIPolicyFromConfig), jamais de la conf, pour que deux clients n'aient pas la
+// même empreinte pour le même e-mail.
+type PIIPolicy struct {
+	Mode string `json:"mode"`
+	Salt string `json:"-"`
+
+	// DefaultCountryCode est l'indicatif (sans "+", ex: "33") utilisé pour mettre en
+	// E.164 un numéro national ("06 12 34 56 78" → "+33612345678").
+	DefaultCountryCode string `json:"defaultCountryCode,omitempty"`
+}
+
+// PIIAudit résume les valeurs protégées sur le run, par champ.
+type PIIAudit struct {
+	Mode      string         `json:"mode"`
+	Protected int            `json:"protected"`
+	Fields    map[string]int `json:"fields,omitempty"`
+}
+
+// PIIProtector applique une politique aux champs IsPII des schémas des requêtes.
+type PIIProtector struct {
+	policy PIIPolicy
+	fields map[string][]OrderedField // requestId → champs IsPII
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// #region NewPIIProtector
+func NewPIIProtector(policy PIIPolicy, requests []Request) (*PIIProtector, error) {
+	policy.Mode = strings.ToLower(strings.TrimSpace(policy.Mode))
+	if policy.Mode == "" {
+		policy.Mode = PIIKeep
+	}
+	switch policy.Mode {
+	case PIIKeep, PIIMask, PIIDrop:
+	case PIIHash:
+		if policy.Salt == "" {
+			return nil, fmt.Errorf("pii: mode hash requires a salt from the credentials")
+		}
+	default:
+		return nil, fmt.Errorf("pii: mode %q is not supported (keep, hash, mask, drop)", policy.Mode)
+	}
+
+	p := &PIIProtector{policy: policy, fields: map[string][]OrderedField{}, counts: map[string]int{}}
+	for _, req := range requests {
+		id := req.ConnectorsAccountRequest.ID
+		if _, done := p.fields[id]; done {
+			continue
+		}
+		var pii []OrderedField
+		for _, f := range req.ConnectorsAccountRequest.Schema.OrderedFields {
+			if f.DatabaseMetaData.IsPII && f.FieldPath != "" {
+				pii = append(pii, f)
+			}
+		}
+		p.fields[id] = pii
+	}
+	return p, nil
+}
+
+// #endregion
+
+// #region Apply
+// Apply protège sur place les champs IsPII de la ligne (enveloppe d'Upsert
+// comprise). Une valeur nulle ou absente n'est pas comptée.
+func (p *PIIProtector) Apply(data map[string]interface{}) {
+	if p == nil || p.policy.Mode == PIIKeep {
+		return
+	}
+	requestID, _ := data["requestId"].(string)
+
+	for _, f := range p.fields[requestID] {
+		value, ok := getPath(data, f.FieldPath)
+		if !ok || value == nil {
+			continue
+		}
+		setPath(data, f.FieldPath, p.protect(value, f.DatabaseMetaData.SemanticType))
+
+		p.mu.Lock()
+		p.counts[f.FieldPath]++
+		p.mu.Unlock()
+	}
+}
+
+// #endregion
+
+// #region protect
+func (p *PIIProtector) protect(value interface{}, semanticType string) interface{} {
+	switch p.policy.Mode {
+	case PIIDrop:
+		return nil
+	case PIIMask:
+		return MaskPII(stringifyValue(value), semanticType)
+	case PIIHash:
+		normalized := NormalizePII(stringifyValue(value), semanticType, p.policy.DefaultCountryCode)
+		mac := hmac.New(sha256.New, []byte(p.policy.Salt))
+		mac.Write([]byte(normalized))
+		return hex.EncodeToString(mac.Sum(nil))
+	default:
+		return value
+	}
+}
+
+// #endregion
+
+// #region Audit
+func (p *PIIProtector) Audit() PIIAudit {
+	if p == nil {
+		return PIIAudit{Mode: PIIKeep}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	audit := PIIAudit{Mode: p.policy.Mode}
+	if len(p.counts) > 0 {
+		audit.Fields = make(map[string]int, len(p.counts))
+	}
+	for path, n := range p.counts {
+		audit.Fields[path] = n
+		audit.Protected += n
+	}
+	return audit
+}
+
+// #endregion
+
+// #region NormalizePII
+// NormalizePII met une valeur sous sa forme canonique avant hachage, pour qu'un même
+// e-mail ou numéro donne la même empreinte d'une source à l'autre : e-mail en
+// minuscules sans espaces, téléphone en E.164. Le type vient de SemanticType
+// ("email", "phone") ou, à défaut, de la forme de la valeur.
+func NormalizePII(value, semanticType, defaultCountryCode string) string {
+	value = strings.TrimSpace(value)
+	switch piiKind(value, semanticType) {
+	case "email":
+		return strings.ToLower(value)
+	case "phone":
+		return normalizePhone(value, defaultCountryCode)
+	default:
+		return value
+	}
+}
+
+// #endregion
+
+// #region MaskPII
+// MaskPII garde juste de quoi reconnaître la valeur : "j***@example.com",
+// "********78", "J***".
+func MaskPII(value, semanticType string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return value
+	}
+	// Premier caractère et non premier octet : "élise" commence sur deux octets, en
+	// couper un écrirait de l'UTF-8 invalide dans l'entrepôt.
+	first, _ := utf8.DecodeRuneInString(value)
+	switch piiKind(value, semanticType) {
+	case "email":
+		at := strings.LastIndex(value, "@")
+		if at > 0 {
+			return string(first) + "***" + value[at:]
+		}
+	case "phone":
+		digits := onlyDigits(value)
+		if len(digits) > 2 {
+			return strings.Repeat("*", len(digits)-2) + digits[len(digits)-2:]
+		}
+	}
+	return string(first) + "***"
+}
+
+// #endregion
+
+// #region piiKind
+func piiKind(value, semanticType string) string {
+	switch strings.ToLower(semanticType) {
+	case "email":
+		return "email"
+	case "phone":
+		return "phone"
+	}
+	if strings.Contains(value, "@") {
+		return "email"
+	}
+	if looksLikePhone(value) {
+		return "phone"
+	}
+	return ""
+}
+
+// #endregion
+
+// #region looksLikePhone
+// looksLikePhone : chiffres et séparateurs usuels uniquement, au moins 6 chiffres.
+func looksLikePhone(value string) bool {
+	digits := 0
+	for i, r := range value {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case r == '+' && i == 0:
+		case r == ' ' || r == '.' || r == '-' || r == '(' || r == ')':
+		default:
+			return false
+		}
+	}
+	return digits >= 6
+}
+
+// #endregion
+
+// #region normalizePhone
+// normalizePhone met un numéro en E.164. "00" international devient "+" ; un numéro
+// national (0 initial) prend l'indicatif par défaut. Sans indicatif connu, on garde
+// les chiffres seuls : mieux qu'un "+" inventé.
+func normalizePhone(value, defaultCountryCode string) string {
+	digits := onlyDigits(value)
+	switch {
+	case strings.HasPrefix(value, "+"):
+		return "+" + digits
+	case strings.HasPrefix(digits, "00"):
+		return "+" + digits[2:]
+	case strings.HasPrefix(digits, "0") && defaultCountryCode != "":
+		return "+" + strings.TrimPrefix(defaultCountryCode, "+") + digits[1:]
+	default:
+		return digits
+	}
+}
+
+// #endregion
+
+// #region onlyDigits
+func onlyDigits(value string) string {
+	var sb strings.Builder
+	for _, r := range value {
+		if unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// #endregion
+
+// #region PIIPolicyFromConfig
+// PIIPolicyFromConfig lit la politique dans le bloc `pii` de la conf connecteur :
+//
+//	pii:
+//	  mode: hash               # keep (défaut), hash, mask, drop
+//	  saltCredential: pii_salt # clé des credentials qui porte le sel
+//	  defaultCountryCode: "33"
+//
+// Le sel est cherché dans les credentials du fichier, du compte puis du connecteur.
+func PIIPolicyFromConfig(config ConfigFile, credentials map[string]interface{}) (PIIPolicy, error) {
+	var decoded struct {
+		PII struct {
+			Mode               string `json:"mode"`
+			SaltCredential     string `json:"saltCredential"`
+			DefaultCountryCode string `json:"defaultCountryCode"`
+		} `json:"pii"`
+	}
+	if config.ConnectorConf != nil {
+		b, err := json.Marshal(config.ConnectorConf)
+		if err != nil {
+			return PIIPolicy{}, fmt.Errorf("pii: marshal ConnectorConf: %w", err)
+		}
+		if err := json.Unmarshal(b, &decoded); err != nil {
+			return PIIPolicy{}, fmt.Errorf("pii: invalid pii block: %w", err)
+		}
+	}
+
+	policy := PIIPolicy{Mode: decoded.PII.Mode, DefaultCountryCode: decoded.PII.DefaultCountryCode}
+	if key := decoded.PII.SaltCredential; key != "" {
+		for _, src := range []map[string]interface{}{credentials, config.PersonnalCredentials, config.ConnectorCredentials} {
+			if s, ok := src[key].(string); ok && s != "" {
+				policy.Salt = s
+				break
+			}
+		}
+		if policy.Salt == "" {
+			return PIIPolicy{}, fmt.Errorf("pii: credential %q not found or empty", key)
+		}
+	}
+	return policy, nil
+}
+
+// #endregion
+
+// ============================================================================
+// Trace d'audit du run
+// ============================================================================
+
+// #region ReportPIIProtection
+// ReportPIIProtection logue le décompte des valeurs protégées par p (trace d'audit)
+// et le renvoie.
+func ReportPIIProtection(p *PIIProtector) PIIAudit {
+	audit := p.Audit()
+	if p == nil || p.policy.Mode == PIIKeep {
+		return audit
+	}
+
+	paths := make([]string, 0, len(audit.Fields))
+	for path := range audit.Fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	Log("info", fmt.Sprintf("PII: %d valeur(s) protégée(s) (mode %s) sur %d champ(s)", audit.Protected, audit.Mode, len(paths)),
+		map[string]interface{}{
+			"mode":      audit.Mode,
+			"protected": audit.Protected,
+			"fields":    audit.Fields,
+		})
+	return audit
+}
+
+// #endregion
@@ -0,0 +1,161 @@
+package sdk
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func piiRequest() Request {
+	return Request{ConnectorsAccountRequest: ConnectorsAccountRequest{
+		ID: "leads",
+		Schema: Schema{OrderedFields: []OrderedField{
+			{FieldPath: "data.id", DatabaseMetaData: DatabaseMetaData{Type: "STRING"}},
+			{FieldPath: "data.email", DatabaseMetaData: DatabaseMetaData{Type: "STRING", IsPII: true, SemanticType: "email"}},
+			{FieldPath: "data.phone", DatabaseMetaData: DatabaseMetaData{Type: "STRING", IsPII: true}},
+			{FieldPath: "data.name", DatabaseMetaData: DatabaseMetaData{Type: "STRING", IsPII: true}},
+		}},
+	}}
+}
+
+func piiRow() map[string]interface{} {
+	return map[string]interface{}{
+		"requestId": "leads",
+		"data": map[string]interface{}{
+			"id":    "42",
+			"email": "  Jane.Doe@Example.com ",
+			"phone": "06 12 34 56 78",
+			"name":  nil,
+		},
+	}
+}
+
+func TestNormalizePII(t *testing.T) {
+	cases := []struct {
+		value, semanticType, country, want string
+	}{
+		{" Jane.Doe@Example.COM ", "", "", "jane.doe@example.com"},
+		{"06 12 34 56 78", "", "33", "+33612345678"},
+		{"+33 6-12-34-56-78", "phone", "", "+33612345678"},
+		{"0033 6 12 34 56 78", "", "", "+33612345678"},
+		{"06.12.34.56.78", "", "", "0612345678"},
+		{"Jane", "", "", "Jane"},
+	}
+	for _, c := range cases {
+		if got := NormalizePII(c.value, c.semanticType, c.country); got != c.want {
+			t.Errorf("NormalizePII(%q): got %q, want %q", c.value, got, c.want)
+		}
+	}
+}
+
+func TestMaskPII(t *testing.T) {
+	cases := map[string]string{
+		"jane@example.com": "j***@example.com",
+		"06 12 34 56 78":   "********78",
+		"Jane":             "J***",
+		"élise@x.fr":       "é***@x.fr",
+		"Ørjan":            "Ø***",
+	}
+	for value, want := range cases {
+		if got := MaskPII(value, ""); got != want {
+			t.Errorf("MaskPII(%q): got %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestPIIProtectorHash(t *testing.T) {
+	p, err := NewPIIProtector(PIIPolicy{Mode: "hash", Salt: "s3cr3t", DefaultCountryCode: "33"}, []Request{piiRequest()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := piiRow()
+	p.Apply(row)
+	other := piiRow()
+	other["data"].(map[string]interface{})["email"] = "jane.doe@example.com"
+	other["data"].(map[string]interface{})["phone"] = "+33 6 12 34 56 78"
+	p.Apply(other)
+
+	data := row["data"].(map[string]interface{})
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	mac.Write([]byte("jane.doe@example.com"))
+	if want := hex.EncodeToString(mac.Sum(nil)); data["email"] != want {
+		t.Errorf("email: got %v, want %v", data["email"], want)
+	}
+	// Normalisation : deux écritures du même e-mail / numéro, même empreinte.
+	if data["email"] != other["data"].(map[string]interface{})["email"] || data["phone"] != other["data"].(map[string]interface{})["phone"] {
+		t.Errorf("normalized values must hash the same: %#v / %#v", data, other["data"])
+	}
+	if data["id"] != "42" || data["name"] != nil {
+		t.Errorf("non-PII and null values must be left alone: %#v", data)
+	}
+
+	audit := p.Audit()
+	want := PIIAudit{Mode: PIIHash, Protected: 4, Fields: map[string]int{"data.email": 2, "data.phone": 2}}
+	if !reflect.DeepEqual(audit, want) {
+		t.Errorf("audit: got %#v, want %#v", audit, want)
+	}
+}
+
+func TestPIIProtectorMaskAndDrop(t *testing.T) {
+	mask, _ := NewPIIProtector(PIIPolicy{Mode: "mask"}, []Request{piiRequest()})
+	row := piiRow()
+	mask.Apply(row)
+	if got := row["data"].(map[string]interface{})["email"]; got != "J***@Example.com" {
+		t.Errorf("mask email: got %v", got)
+	}
+
+	drop, _ := NewPIIProtector(PIIPolicy{Mode: "drop"}, []Request{piiRequest()})
+	row = piiRow()
+	drop.Apply(row)
+	data := row["data"].(map[string]interface{})
+	if data["email"] != nil || data["phone"] != nil || data["id"] != "42" {
+		t.Errorf("drop: %#v", data)
+	}
+
+	keep, _ := NewPIIProtector(PIIPolicy{}, []Request{piiRequest()})
+	row = piiRow()
+	keep.Apply(row)
+	if !reflect.DeepEqual(row, piiRow()) {
+		t.Errorf("keep must not touch the row: %#v", row)
+	}
+}
+
+func TestNewPIIProtectorErrors(t *testing.T) {
+	if _, err := NewPIIProtector(PIIPolicy{Mode: "hash"}, nil); err == nil {
+		t.Error("hash without salt must be refused")
+	}
+	if _, err := NewPIIProtector(PIIPolicy{Mode: "encrypt"}, nil); err == nil {
+		t.Error("unknown mode must be refused")
+	}
+}
+
+func TestPIIPolicyFromConfig(t *testing.T) {
+	config := ConfigFile{
+		ConnectorConf:        map[string]interface{}{"pii": map[string]interface{}{"mode": "hash", "saltCredential": "pii_salt", "defaultCountryCode": "33"}},
+		PersonnalCredentials: map[string]interface{}{"pii_salt": "from-account"},
+	}
+	policy, err := PIIPolicyFromConfig(config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Mode != PIIHash || policy.Salt != "from-account" || policy.DefaultCountryCode != "33" {
+		t.Errorf("policy: %#v", policy)
+	}
+
+	policy, _ = PIIPolicyFromConfig(config, map[string]interface{}{"pii_salt": "from-file"})
+	if policy.Salt != "from-file" {
+		t.Errorf("credentials file must win, got %q", policy.Salt)
+	}
+
+	config.PersonnalCredentials = nil
+	if _, err := PIIPolicyFromConfig(config, nil); err == nil {
+		t.Error("a missing salt credential must be an error")
+	}
+
+	if policy, err := PIIPolicyFromConfig(ConfigFile{}, nil); err != nil || policy.Mode != "" {
+		t.Errorf("no pii block: %#v, %v", policy, err)
+	}
+}
@@ -0,0 +1,176 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Valeurs autorisées pour les enrichissements IA. Quanti AI s'appuie dessus pour
+// choisir agrégations et filtres : une faute de frappe ("metrics") fait silencieusement
+// disparaître le champ de ses réponses.
+var (
+	AllowedSemanticTypes = []string{"id", "dimension", "metric", "date", "currency_micro", "email", "phone"}
+	AllowedGrains        = []string{"daily", "event", "snapshot"}
+)
+
+// Sévérités des constats de Lint.
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// PrebuildFinding est un constat de Lint sur prebuilds.json. RequestID et FieldID
+// situent le constat ; Rule est un identifiant stable, exploitable en CI.
+type PrebuildFinding struct {
+	Severity  string `json:"severity"`
+	Rule      string `json:"rule"`
+	RequestID string `json:"requestId,omitempty"`
+	FieldID   string `json:"fieldId,omitempty"`
+	Message   string `json:"message"`
+}
+
+// #region ParsePrebuilds
+// ParsePrebuilds lit les deux formes de prebuilds.json : l'ancien tableau nu de
+// requêtes, ou l'objet { "connector": {...}, "prebuilds": [...] }.
+func ParsePrebuilds(data []byte) (*EnrichedPrebuildsFile, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("prebuilds: empty file")
+	}
+
+	if trimmed[0] == '[' {
+		var prebuilds []Request
+		if err := json.Unmarshal(trimmed, &prebuilds); err != nil {
+			return nil, fmt.Errorf("prebuilds: invalid array: %w", err)
+		}
+		return &EnrichedPrebuildsFile{Prebuilds: prebuilds}, nil
+	}
+
+	var file EnrichedPrebuildsFile
+	if err := json.Unmarshal(trimmed, &file); err != nil {
+		return nil, fmt.Errorf("prebuilds: invalid file: %w", err)
+	}
+	return &file, nil
+}
+
+// #endregion
+
+// #region LoadPrebuilds
+func LoadPrebuilds(path string) (*EnrichedPrebuildsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("prebuilds: %w", err)
+	}
+	return ParsePrebuilds(data)
+}
+
+// #endregion
+
+// #region Lint
+// Lint vérifie la cohérence des prebuilds : IDs de requête uniques, fieldIds uniques
+// dans une requête, exactement un champ IsQuantiDate par requête (au plus un pour
+// une requête IsDimension, qui n'est pas datée), SemanticType et Grain dans les
+// valeurs autorisées. Aucun constat = fichier valide.
+func Lint(file *EnrichedPrebuildsFile) []PrebuildFinding {
+	var findings []PrebuildFinding
+	add := func(severity, rule, requestID, fieldID, format string, args ...interface{}) {
+		findings = append(findings, PrebuildFinding{
+			Severity:  severity,
+			Rule:      rule,
+			RequestID: requestID,
+			FieldID:   fieldID,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+
+	if file == nil || len(file.Prebuilds) == 0 {
+		add(LintError, "no_prebuilds", "", "", "the file contains no prebuild")
+		return findings
+	}
+	if file.Connector != nil && strings.TrimSpace(file.Connector.SKU) == "" {
+		add(LintWarning, "missing_connector_sku", "", "", "connector.sku is empty")
+	}
+
+	requestIDs := map[string]bool{}
+	for i, req := range file.Prebuilds {
+		car := req.ConnectorsAccountRequest
+		id := car.ID
+		if strings.TrimSpace(id) == "" {
+			id = fmt.Sprintf("#%d", i)
+			add(LintError, "missing_request_id", id, "", "prebuild %d has no id", i)
+		} else if requestIDs[id] {
+			add(LintError, "duplicate_request_id", id, "", "request id %q is used more than once", id)
+		}
+		requestIDs[id] = true
+
+		if car.Grain != "" && !contains(AllowedGrains, car.Grain) {
+			add(LintError, "invalid_grain", id, "", "grain %q is not allowed (%s)", car.Grain, strings.Join(AllowedGrains, ", "))
+		}
+		if len(car.Schema.OrderedFields) == 0 {
+			add(LintError, "empty_schema", id, "", "schema has no field")
+			continue
+		}
+
+		fieldIDs := map[string]bool{}
+		var dates []string
+		for j, f := range car.Schema.OrderedFields {
+			fieldID := f.FieldId
+			if strings.TrimSpace(fieldID) == "" {
+				fieldID = fmt.Sprintf("#%d", j)
+				add(LintError, "missing_field_id", id, fieldID, "field %d has no fieldId", j)
+			} else if fieldIDs[fieldID] {
+				add(LintError, "duplicate_field_id", id, fieldID, "fieldId %q is used more than once", fieldID)
+			}
+			fieldIDs[fieldID] = true
+
+			meta := f.DatabaseMetaData
+			if meta.IsQuantiDate {
+				dates = append(dates, fieldID)
+			}
+			if meta.SemanticType != "" && !contains(AllowedSemanticTypes, meta.SemanticType) {
+				add(LintError, "invalid_semantic_type", id, fieldID, "semantic_type %q is not allowed (%s)", meta.SemanticType, strings.Join(AllowedSemanticTypes, ", "))
+			}
+		}
+
+		switch len(dates) {
+		case 1:
+		case 0:
+			if car.IsDimension {
+				break
+			}
+			add(LintError, "missing_quanti_date", id, "", "no field has isQuantiDate, exactly one is required")
+		default:
+			add(LintError, "multiple_quanti_dates", id, "", "%d fields have isQuantiDate (%s), exactly one is required", len(dates), strings.Join(dates, ", "))
+		}
+	}
+	return findings
+}
+
+// #endregion
+
+// #region HasLintErrors
+func HasLintErrors(findings []PrebuildFinding) bool {
+	for _, f := range findings {
+		if f.Severity == LintError {
+			return true
+		}
+	}
+	return false
+}
+
+// #endregion
+
+// #region contains
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// #endregion
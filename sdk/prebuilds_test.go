@@ -0,0 +1,92 @@
+package sdk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePrebuilds(t *testing.T) {
+	bare := `[{"connectorsaccountrequest": {"id": "ads", "schema": {"orderedFields": [{"fieldId": "date"}]}}}]`
+	enriched := `{
+		"connector": {"sku": "google_ads", "name": "Google Ads", "category": "marketing"},
+		"prebuilds": [{"connectorsaccountrequest": {"id": "ads"}}, {"connectorsaccountrequest": {"id": "campaigns"}}]
+	}`
+
+	file, err := ParsePrebuilds([]byte("\n  " + bare))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Connector != nil || len(file.Prebuilds) != 1 || file.Prebuilds[0].ConnectorsAccountRequest.ID != "ads" {
+		t.Errorf("bare array: %#v", file)
+	}
+
+	file, err = ParsePrebuilds([]byte(enriched))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Connector == nil || file.Connector.SKU != "google_ads" || len(file.Prebuilds) != 2 {
+		t.Errorf("enriched file: %#v", file)
+	}
+
+	for _, bad := range []string{"", "  ", "[{", `"prebuilds"`} {
+		if _, err := ParsePrebuilds([]byte(bad)); err == nil {
+			t.Errorf("ParsePrebuilds(%q): expected an error", bad)
+		}
+	}
+}
+
+func lintRequest(id string, fields ...OrderedField) Request {
+	return Request{ConnectorsAccountRequest: ConnectorsAccountRequest{ID: id, Schema: Schema{OrderedFields: fields}}}
+}
+
+func TestLint(t *testing.T) {
+	date := OrderedField{FieldId: "date", DatabaseMetaData: DatabaseMetaData{IsQuantiDate: true, SemanticType: "date"}}
+	clicks := OrderedField{FieldId: "clicks", DatabaseMetaData: DatabaseMetaData{IsMetric: true, SemanticType: "metric"}}
+
+	valid := &EnrichedPrebuildsFile{Prebuilds: []Request{lintRequest("ads", date, clicks)}}
+	if findings := Lint(valid); findings != nil {
+		t.Fatalf("valid file: %#v", findings)
+	}
+
+	bad := lintRequest("ads", date, clicks, OrderedField{FieldId: "clicks", DatabaseMetaData: DatabaseMetaData{SemanticType: "metrics"}})
+	bad.ConnectorsAccountRequest.Grain = "hourly"
+	file := &EnrichedPrebuildsFile{
+		Connector: &ConnectorInfo{Name: "Google Ads"},
+		Prebuilds: []Request{
+			bad,
+			lintRequest("ads", clicks),
+			lintRequest("twice", date, OrderedField{FieldId: "day", DatabaseMetaData: DatabaseMetaData{IsQuantiDate: true}}),
+		},
+	}
+
+	var rules []string
+	for _, f := range Lint(file) {
+		rules = append(rules, f.Severity+":"+f.Rule+":"+f.RequestID+":"+f.FieldID)
+	}
+	want := []string{
+		"warning:missing_connector_sku::",
+		"error:invalid_grain:ads:",
+		"error:duplicate_field_id:ads:clicks",
+		"error:invalid_semantic_type:ads:clicks",
+		"error:duplicate_request_id:ads:",
+		"error:missing_quanti_date:ads:",
+		"error:multiple_quanti_dates:twice:",
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("findings:\n got %v\nwant %v", rules, want)
+	}
+	if !HasLintErrors(Lint(file)) {
+		t.Error("HasLintErrors must be true")
+	}
+
+	// Une dimension n'est pas datée : pas de champ IsQuantiDate requis.
+	dimension := lintRequest("campaigns", OrderedField{FieldId: "name", DatabaseMetaData: DatabaseMetaData{SemanticType: "dimension"}})
+	dimension.ConnectorsAccountRequest.IsDimension = true
+	if findings := Lint(&EnrichedPrebuildsFile{Prebuilds: []Request{dimension}}); findings != nil {
+		t.Errorf("dimension without date: %#v", findings)
+	}
+
+	if findings := Lint(&EnrichedPrebuildsFile{}); len(findings) != 1 || findings[0].Rule != "no_prebuilds" {
+		t.Errorf("empty file: %#v", findings)
+	}
+}
@@ -0,0 +1,389 @@
+// Package restrunner est le connecteur api-rest-v2 prêt à l'emploi : il déroule le
+// plan du SDK (requête × date × compte), exécute pour chaque élément la Spec
+// httpsource portée par le bloc `request` du report, et upserte les lignes. Une
+// nouvelle source REST se résume alors à un conf.yml.
+package restrunner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/quantiio/quanti-sdk/sdk"
+	"github.com/quantiio/quanti-sdk/sdk/httpsource"
+)
+
+// Runner porte les dépendances d'une exécution. Upsert et Checkpoint valent
+// sdk.Upsert et sdk.Checkpoint par défaut ; les remplacer sert aux tests, qui
+// veulent observer les lignes sans parser la sortie standard.
+type Runner struct {
+	Engine     *httpsource.Engine
+	Upsert     func(data map[string]interface{}, state map[string]string) error
+	Checkpoint func(state map[string]string, err *sdk.QError)
+
+	// Coerce active la conversion des valeurs au type du schéma avant Upsert (cf
+	// sdk.NewCoercers). Process le lit dans `coerceValues` de la conf connecteur.
+	Coerce bool
+}
+
+// #region New
+// New construit un Runner. Un engine nil donne un moteur par défaut branché sur les
+// logs du SDK.
+func New(engine *httpsource.Engine) *Runner {
+	if engine == nil {
+		engine = httpsource.New(httpsource.WithLogger(sdkLogger{}))
+	}
+	return &Runner{
+		Engine:     engine,
+		Upsert:     sdk.Upsert,
+		Checkpoint: sdk.Checkpoint,
+	}
+}
+
+// #endregion
+
+// #region Process
+// Process a la signature attendue par sdk.Process : c'est tout le main d'un binaire
+// api-rest-v2.
+func Process(config sdk.ConfigFile, state map[string]string, credentials map[string]interface{}) {
+	r := New(nil)
+	r.Coerce, _ = connectorConfMap(config.ConnectorConf)["coerceValues"].(bool)
+	r.Run(context.Background(), config, state, credentials)
+}
+
+// #endregion
+
+// #region Run
+// Run exécute le plan complet. Chaque élément terminé est suivi d'un checkpoint sans
+// erreur ; la première erreur est traduite en QError, checkpointée avec le state de
+// l'élément en échec (date, requestId, adAccount), puis renvoyée. Le run suivant
+// reprend à cet élément précis.
+func (r *Runner) Run(ctx context.Context, config sdk.ConfigFile, state map[string]string, credentials map[string]interface{}) (httpsource.Stats, *sdk.QError) {
+	var total httpsource.Stats
+
+	if state == nil {
+		state = map[string]string{}
+	}
+
+	items, err := sdk.GetRequestsByDateAndAdAccounts(config, state)
+	if err != nil {
+		qerr := sdk.DefError(sdk.ERR_DEF_INVALID_REQUESTS, err)
+		r.Checkpoint(state, qerr)
+		return total, qerr
+	}
+	items = resumeAdAccount(items, state["adAccount"])
+
+	// Traitements des lignes du run. Dérive de schéma : chaque ligne est comparée au
+	// schéma de sa requête, et un seul message récapitulatif part en fin de run,
+	// erreur ou pas.
+	requests := uniqueRequests(items)
+	hooks := &sdk.RowHooks{Drift: sdk.NewDriftTracker(requests)}
+	if r.Coerce {
+		hooks.Coerce = sdk.NewCoercers(requests)
+	}
+
+	// Données personnelles : politique du bloc `pii` de la conf connecteur, sel lu
+	// dans les credentials. Une politique invalide arrête le run avant tout appel :
+	// mieux vaut ne rien remonter que remonter des e-mails en clair.
+	policy, err := sdk.PIIPolicyFromConfig(config, credentials)
+	if err == nil {
+		hooks.PII, err = sdk.NewPIIProtector(policy, requests)
+	}
+	if err != nil {
+		qerr := sdk.DefError(sdk.ERR_DEF_INVALID_REQUESTS, err)
+		r.Checkpoint(state, qerr)
+		return total, qerr
+	}
+	defer hooks.Report()
+
+	base := httpsource.Vars{
+		StartDate:     config.RequestParams.StartDate,
+		EndDate:       config.RequestParams.EndDate,
+		Credentials:   mergeCredentials(config, credentials),
+		ConnectorConf: connectorConfMap(config.ConnectorConf),
+	}
+
+	// Une spec par requête et non par élément : la parser une fois évite de refaire
+	// la validation pour chacune des 365 dates d'un historique.
+	specs := map[string]*httpsource.Spec{}
+
+	// grouped : éléments déjà couverts par le Fetch fenêtré d'un élément précédent.
+	grouped := map[int]bool{}
+
+	for i, item := range items {
+		if grouped[i] {
+			continue
+		}
+		requestID := item.Request.ConnectorsAccountRequest.ID
+		date := ""
+		if item.Date != nil {
+			date = item.Date.Format("2006-01-02")
+		}
+
+		state["requestId"] = requestID
+		state["date"] = date
+		state["adAccount"] = item.AdAccountID
+
+		spec, ok := specs[requestID]
+		if !ok {
+			spec, err = httpsource.ParseSpec(item.Request.Request)
+			if err != nil {
+				qerr := sdk.QErrorFromHTTPSource(err)
+				r.Checkpoint(state, qerr)
+				return total, qerr
+			}
+			specs[requestID] = spec
+		}
+
+		vars := base
+		vars.Date = date
+		vars.AdAccountID = item.AdAccountID
+		if item.AdAccount != nil {
+			vars.AdAccountName = item.AdAccount.Name
+		}
+
+		// Requête fenêtrée (request.window) : tous les jours du plan de ce compte en un
+		// seul Fetch, que le moteur découpe en fenêtres. Chaque ligne est rattachée au
+		// jour lu à window.datePath.
+		days := []string{date}
+		windowed := spec.Window != nil && date != ""
+		if windowed {
+			days = windowDays(items, i, grouped)
+			vars.Date = ""
+			vars.StartDate, vars.EndDate = days[0], days[len(days)-1]
+		}
+		label := displayDate(date)
+		if len(days) > 1 {
+			label = days[0] + ".." + days[len(days)-1]
+		}
+
+		// upsertErr distingue un échec d'Upsert (notre sortie) d'un échec de l'API :
+		// Fetch renvoie l'erreur d'emit telle quelle, sans Kind. rowDateErr : une ligne
+		// fenêtrée sans jour exploitable.
+		var upsertErr, rowDateErr error
+		stats, err := r.Engine.Fetch(ctx, spec, vars, func(row map[string]any) error {
+			rowDate, rowState := date, state
+			if windowed {
+				d, ok := spec.Window.RowDate(row)
+				if !ok || !containsDay(days, d) {
+					rowDateErr = fmt.Errorf("row day at window.datePath %q is missing or outside %s", spec.Window.DatePath, label)
+					return rowDateErr
+				}
+				// Le jour de la ligne part avec elle, pas dans le state du run : celui-ci
+				// ne doit avancer qu'au checkpoint.
+				rowDate = d
+				rowState = map[string]string{"requestId": requestID, "date": d, "adAccount": item.AdAccountID}
+			}
+			upsertErr = r.Upsert(hooks.Apply(map[string]interface{}{
+				"requestId": requestID,
+				"adAccount": item.AdAccountID,
+				"date":      rowDate,
+				"data":      row,
+			}), rowState)
+			return upsertErr
+		})
+		addStats(&total, stats)
+
+		if err != nil {
+			qerr := sdk.QErrorFromHTTPSource(err)
+			switch {
+			case upsertErr != nil:
+				qerr = sdk.DefError(sdk.ERR_DEF_INVALID_UPSERT, upsertErr)
+			case rowDateErr != nil:
+				qerr = sdk.DefError(sdk.ERR_DEF_INVALID_DATA, rowDateErr)
+			}
+			// Un groupe fenêtré reprend à son premier jour : les fenêtres déjà
+			// chargées seront rechargées, l'upsert est idempotent.
+			state["date"] = days[0]
+			r.Checkpoint(state, qerr)
+			return total, qerr
+		}
+
+		sdk.Infof("api-rest-v2: request %s, date %s, account %s — %d row(s), %d page(s), %d attempt(s), %s waited",
+			requestID, label, displayAccount(item.AdAccountID), stats.Rows, stats.Pages, stats.Attempts, stats.Waited)
+
+		state["date"] = days[len(days)-1]
+		if windowed {
+			// Le plan est ordonné date → compte : ce groupe couvre les jours d'UN compte,
+			// les comptes suivants n'ont encore rien chargé. Le checkpoint pointe le
+			// premier élément restant de la requête, pour qu'une reprise ne les saute pas.
+			if next, ok := nextPending(items, i, grouped); ok && next.Date != nil &&
+				next.Request.ConnectorsAccountRequest.ID == requestID {
+				state["date"] = next.Date.Format("2006-01-02")
+				state["adAccount"] = next.AdAccountID
+			}
+		}
+		r.Checkpoint(state, nil)
+	}
+
+	sdk.Infof("api-rest-v2: done — %d item(s), %d row(s) over %d page(s), %d HTTP attempt(s), %s waited",
+		len(items), total.Rows, total.Pages, total.Attempts, total.Waited)
+
+	return total, nil
+}
+
+// #endregion
+
+// #region windowDays
+// windowDays renvoie les jours du plan couverts par le Fetch fenêtré de items[i] :
+// le sien et ceux des éléments suivants de même requête et même compte, marqués
+// dans grouped pour ne pas être rejoués. Le groupe s'arrête au premier jour
+// manquant : la fenêtre couvrirait le trou, et ses lignes seraient rejetées. Les
+// jours après le trou forment le groupe suivant.
+func windowDays(items []sdk.RequestByDateAndAdAccount, i int, grouped map[int]bool) []string {
+	first := items[i]
+	last := *first.Date
+	days := []string{last.Format("2006-01-02")}
+	for j := i + 1; j < len(items); j++ {
+		it := items[j]
+		if it.Date == nil || it.AdAccountID != first.AdAccountID ||
+			it.Request.ConnectorsAccountRequest.ID != first.Request.ConnectorsAccountRequest.ID {
+			continue
+		}
+		if !it.Date.Equal(last.AddDate(0, 0, 1)) {
+			break
+		}
+		last = *it.Date
+		grouped[j] = true
+		days = append(days, last.Format("2006-01-02"))
+	}
+	return days
+}
+
+// #endregion
+
+// #region nextPending
+// nextPending renvoie le premier élément après items[i] qui n'est pas couvert par un
+// groupe fenêtré déjà chargé.
+func nextPending(items []sdk.RequestByDateAndAdAccount, i int, grouped map[int]bool) (sdk.RequestByDateAndAdAccount, bool) {
+	for j := i + 1; j < len(items); j++ {
+		if !grouped[j] {
+			return items[j], true
+		}
+	}
+	return sdk.RequestByDateAndAdAccount{}, false
+}
+
+// #endregion
+
+// #region containsDay
+func containsDay(days []string, day string) bool {
+	i := sort.SearchStrings(days, day)
+	return i < len(days) && days[i] == day
+}
+
+// #endregion
+
+// #region resumeAdAccount
+// resumeAdAccount complète la reprise faite par GetRequestsByDate, qui ne connaît
+// que la date et la requête : si le state nomme un compte, on saute les comptes qui
+// le précèdent pour ce premier couple (requête, date), déjà chargés au run
+// précédent. Compte introuvable ⇒ on ne saute rien, mieux vaut recharger que perdre.
+func resumeAdAccount(items []sdk.RequestByDateAndAdAccount, adAccount string) []sdk.RequestByDateAndAdAccount {
+	if adAccount == "" || len(items) == 0 {
+		return items
+	}
+	first := items[0]
+	for i, item := range items {
+		if !sameSlot(item, first) {
+			return items
+		}
+		if item.AdAccountID == adAccount {
+			return items[i:]
+		}
+	}
+	return items
+}
+
+// #endregion
+
+// #region sameSlot
+func sameSlot(a, b sdk.RequestByDateAndAdAccount) bool {
+	if a.Request.ConnectorsAccountRequest.ID != b.Request.ConnectorsAccountRequest.ID {
+		return false
+	}
+	if a.Date == nil || b.Date == nil {
+		return a.Date == nil && b.Date == nil
+	}
+	return a.Date.Equal(*b.Date)
+}
+
+// #endregion
+
+// #region uniqueRequests
+func uniqueRequests(items []sdk.RequestByDateAndAdAccount) []sdk.Request {
+	seen := map[string]bool{}
+	var out []sdk.Request
+	for _, item := range items {
+		id := item.Request.ConnectorsAccountRequest.ID
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, item.Request)
+		}
+	}
+	return out
+}
+
+// #endregion
+
+// #region mergeCredentials
+// mergeCredentials expose sous {{credentials.*}} l'union des credentials du
+// connecteur, du compte, puis du fichier credentials.json. Le fichier passe en
+// dernier : c'est lui qui porte un token rafraîchi par UpdateCredentials.
+func mergeCredentials(config sdk.ConfigFile, credentials map[string]interface{}) map[string]any {
+	out := map[string]any{}
+	for _, src := range []map[string]interface{}{config.ConnectorCredentials, config.PersonnalCredentials, credentials} {
+		for k, v := range src {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// #endregion
+
+// #region connectorConfMap
+func connectorConfMap(conf interface{}) map[string]any {
+	if m, ok := conf.(map[string]interface{}); ok {
+		return m
+	}
+	return nil
+}
+
+// #endregion
+
+// #region addStats
+func addStats(total *httpsource.Stats, s httpsource.Stats) {
+	total.Pages += s.Pages
+	total.Rows += s.Rows
+	total.Attempts += s.Attempts
+	total.Waited += s.Waited
+}
+
+// #endregion
+
+// #region displayDate
+func displayDate(date string) string {
+	if date == "" {
+		return "dimension"
+	}
+	return date
+}
+
+// #endregion
+
+// #region displayAccount
+func displayAccount(id string) string {
+	if id == "" {
+		return "-"
+	}
+	return id
+}
+
+// #endregion
+
+// sdkLogger branche les logs du moteur sur ceux du SDK.
+type sdkLogger struct{}
+
+func (sdkLogger) Infof(format string, args ...any) { sdk.Infof(format, args...) }
+func (sdkLogger) Warnf(format string, args ...any) { sdk.Warnf(format, args...) }
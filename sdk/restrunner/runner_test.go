@@ -0,0 +1,277 @@
+package restrunner
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quantiio/quanti-sdk/sdk"
+	"github.com/quantiio/quanti-sdk/sdk/httpsource"
+)
+
+type checkpoint struct {
+	state map[string]string
+	err   *sdk.QError
+}
+
+// testRunner : moteur sans attente réelle, sorties capturées au lieu d'imprimées.
+func testRunner(rows *[]map[string]interface{}, checkpoints *[]checkpoint) *Runner {
+	r := New(httpsource.New(httpsource.WithSleeper(func(context.Context, time.Duration) error { return nil })))
+	r.Upsert = func(data map[string]interface{}, _ map[string]string) error {
+		*rows = append(*rows, data)
+		return nil
+	}
+	r.Checkpoint = func(state map[string]string, err *sdk.QError) {
+		copied := make(map[string]string, len(state))
+		for k, v := range state {
+			copied[k] = v
+		}
+		*checkpoints = append(*checkpoints, checkpoint{state: copied, err: err})
+	}
+	return r
+}
+
+func testConfig(url string) sdk.ConfigFile {
+	return sdk.ConfigFile{
+		ConnectorCredentials: map[string]interface{}{"apikey": "KEY"},
+		RequestParams:        sdk.RequestParams{StartDate: "2026-08-11", EndDate: "2026-08-12"},
+		ConnectorConf: map[string]interface{}{
+			"adaccounts": []interface{}{
+				map[string]interface{}{"id": "A1", "name": "Shop A"},
+				map[string]interface{}{"id": "A2", "name": "Shop B"},
+			},
+			"requests": []interface{}{
+				map[string]interface{}{
+					"connectorsaccountrequest": map[string]interface{}{"id": "sales", "status": 200},
+					"request": map[string]interface{}{
+						"source": map[string]interface{}{
+							"url":   url,
+							"query": map[string]interface{}{"date": "{{date}}", "account": "{{adAccount.id}}"},
+						},
+						"auth":    map[string]interface{}{"mode": "header", "name": "X-Key", "value": "{{credentials.apikey}}"},
+						"records": map[string]interface{}{"path": "data"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRun_UpsertsEveryItem(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Key") != "KEY" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"data":[{"account":%q,"date":%q}]}`, r.URL.Query().Get("account"), r.URL.Query().Get("date"))
+	}))
+	defer srv.Close()
+
+	var rows []map[string]interface{}
+	var checkpoints []checkpoint
+	stats, qerr := testRunner(&rows, &checkpoints).Run(context.Background(), testConfig(srv.URL), map[string]string{}, nil)
+	if qerr != nil {
+		t.Fatalf("Run: %v", qerr)
+	}
+
+	// 2 dates × 2 comptes.
+	if len(rows) != 4 || stats.Rows != 4 || stats.Pages != 4 {
+		t.Fatalf("got %d rows, stats %+v", len(rows), stats)
+	}
+	first := rows[0]
+	if first["requestId"] != "sales" || first["adAccount"] != "A1" || first["date"] != "2026-08-11" {
+		t.Errorf("unexpected envelope: %#v", first)
+	}
+	data, _ := first["data"].(map[string]any)
+	if data["account"] != "A1" || data["date"] != "2026-08-11" {
+		t.Errorf("unexpected data: %#v", first["data"])
+	}
+
+	if len(checkpoints) != 4 {
+		t.Fatalf("got %d checkpoints, want one per item", len(checkpoints))
+	}
+	for _, c := range checkpoints {
+		if c.err != nil {
+			t.Errorf("unexpected checkpoint error: %v", c.err)
+		}
+	}
+}
+
+// L'erreur est traduite en QError et checkpointée avec le state de l'élément en
+// échec ; la reprise repart de ce compte précis.
+func TestRun_CheckpointsTheFailingItemAndResumes(t *testing.T) {
+	failing := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing && r.URL.Query().Get("account") == "A2" && r.URL.Query().Get("date") == "2026-08-11" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"data":[{"x":1}]}`)
+	}))
+	defer srv.Close()
+
+	var rows []map[string]interface{}
+	var checkpoints []checkpoint
+	runner := testRunner(&rows, &checkpoints)
+
+	_, qerr := runner.Run(context.Background(), testConfig(srv.URL), map[string]string{}, nil)
+	if qerr == nil || qerr.Code != sdk.ERR_DEF_PERMISSION_DENIED {
+		t.Fatalf("got %v, want ERR_DEF_PERMISSION_DENIED", qerr)
+	}
+	last := checkpoints[len(checkpoints)-1]
+	if last.err == nil || last.state["adAccount"] != "A2" || last.state["date"] != "2026-08-11" || last.state["requestId"] != "sales" {
+		t.Fatalf("unexpected failing checkpoint: %#v", last)
+	}
+
+	failing = false
+	rows = nil
+	if _, qerr := runner.Run(context.Background(), testConfig(srv.URL), last.state, nil); qerr != nil {
+		t.Fatalf("resume: %v", qerr)
+	}
+	// Reprise : A2 du 11, puis A1 et A2 du 12. A1 du 11 est déjà chargé.
+	if len(rows) != 3 || rows[0]["adAccount"] != "A2" || rows[0]["date"] != "2026-08-11" {
+		t.Errorf("unexpected resumed rows: %#v", rows)
+	}
+}
+
+func TestRun_UpsertErrorIsInvalidUpsert(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"data":[{"x":1}]}`)
+	}))
+	defer srv.Close()
+
+	var rows []map[string]interface{}
+	var checkpoints []checkpoint
+	runner := testRunner(&rows, &checkpoints)
+	runner.Upsert = func(map[string]interface{}, map[string]string) error {
+		return fmt.Errorf("date invalide")
+	}
+
+	_, qerr := runner.Run(context.Background(), testConfig(srv.URL), map[string]string{}, nil)
+	if qerr == nil || qerr.Code != sdk.ERR_DEF_INVALID_UPSERT {
+		t.Fatalf("got %v, want ERR_DEF_INVALID_UPSERT", qerr)
+	}
+}
+
+func TestRun_InvalidSpecIsInvalidRequest(t *testing.T) {
+	config := testConfig("ftp://nope")
+
+	var rows []map[string]interface{}
+	var checkpoints []checkpoint
+	_, qerr := testRunner(&rows, &checkpoints).Run(context.Background(), config, map[string]string{}, nil)
+	if qerr == nil || qerr.Code != sdk.ERR_DEF_INVALID_REQUEST {
+		t.Fatalf("got %v, want ERR_DEF_INVALID_REQUEST", qerr)
+	}
+	if len(checkpoints) != 1 || checkpoints[0].err == nil {
+		t.Errorf("the spec error must be checkpointed: %#v", checkpoints)
+	}
+}
+
+// Requête fenêtrée : un Fetch par compte couvre tous les jours du plan, et chaque
+// ligne est rattachée à son jour (window.datePath), state compris.
+func TestRun_WindowedRequestGroupsDays(t *testing.T) {
+	var calls []string
+	badDay := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		calls = append(calls, q.Get("account")+":"+q.Get("from")+".."+q.Get("to"))
+		day := q.Get("to")
+		if badDay {
+			day = "2026-07-01"
+		}
+		fmt.Fprintf(w, `{"data":[{"day":%q,"clicks":1}]}`, day)
+	}))
+	defer srv.Close()
+
+	config := testConfig(srv.URL)
+	config.RequestParams = sdk.RequestParams{StartDate: "2026-08-01", EndDate: "2026-08-10"}
+	request := config.ConnectorConf.(map[string]interface{})["requests"].([]interface{})[0].(map[string]interface{})
+	request["request"].(map[string]interface{})["source"] = map[string]interface{}{
+		"url":   srv.URL,
+		"query": map[string]interface{}{"from": "{{window.start}}", "to": "{{window.end}}", "account": "{{adAccount.id}}"},
+	}
+	request["request"].(map[string]interface{})["window"] = map[string]interface{}{"size": 7, "datePath": "day"}
+
+	var rows []map[string]interface{}
+	var checkpoints []checkpoint
+	var upsertDates []string
+	runner := testRunner(&rows, &checkpoints)
+	runner.Upsert = func(data map[string]interface{}, state map[string]string) error {
+		rows = append(rows, data)
+		upsertDates = append(upsertDates, state["date"])
+		return nil
+	}
+
+	if _, qerr := runner.Run(context.Background(), config, map[string]string{}, nil); qerr != nil {
+		t.Fatalf("Run: %v", qerr)
+	}
+	want := "A1:2026-08-01..2026-08-07,A1:2026-08-08..2026-08-10,A2:2026-08-01..2026-08-07,A2:2026-08-08..2026-08-10"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("calls:\n got %s\nwant %s", got, want)
+	}
+	if len(rows) != 4 || rows[1]["date"] != "2026-08-10" || upsertDates[1] != "2026-08-10" {
+		t.Errorf("rows must carry their own day: %v %v", rows, upsertDates)
+	}
+	if len(checkpoints) != 2 || checkpoints[1].state["date"] != "2026-08-10" {
+		t.Errorf("one checkpoint per account, the last one at the last day: %#v", checkpoints)
+	}
+
+	// Worker arrêté juste après le groupe de A1 : la reprise depuis ce checkpoint
+	// doit encore charger les jours de A2, que le plan place avant les derniers jours
+	// de A1.
+	resumeFrom := checkpoints[0].state
+	if resumeFrom["date"] != "2026-08-01" || resumeFrom["adAccount"] != "A2" {
+		t.Errorf("the checkpoint after A1 must point at A2's first day: %#v", resumeFrom)
+	}
+	calls, checkpoints = nil, nil
+	if _, qerr := runner.Run(context.Background(), config, resumeFrom, nil); qerr != nil {
+		t.Fatalf("resumed Run: %v", qerr)
+	}
+	if len(calls) == 0 || calls[0] != "A2:2026-08-01..2026-08-07" {
+		t.Errorf("the resumed run must start with A2's first days, got %v", calls)
+	}
+
+	// Un jour hors de la période est une donnée invalide ; la reprise repart du
+	// premier jour du groupe.
+	badDay, checkpoints = true, nil
+	_, qerr := runner.Run(context.Background(), config, map[string]string{}, nil)
+	if qerr == nil || qerr.Code != sdk.ERR_DEF_INVALID_DATA {
+		t.Fatalf("got %v, want ERR_DEF_INVALID_DATA", qerr)
+	}
+	if last := checkpoints[len(checkpoints)-1]; last.state["date"] != "2026-08-01" || last.state["adAccount"] != "A1" {
+		t.Errorf("unexpected failing checkpoint: %#v", last.state)
+	}
+}
+
+// Un trou dans le plan coupe le groupe fenêtré : sinon la fenêtre couvrirait des
+// jours absents du plan et leurs lignes arrêteraient le run.
+func TestWindowDays_StopsAtGaps(t *testing.T) {
+	item := func(day, account string) sdk.RequestByDateAndAdAccount {
+		d, _ := time.Parse("2006-01-02", day)
+		return sdk.RequestByDateAndAdAccount{
+			Date:        &d,
+			Request:     sdk.Request{ConnectorsAccountRequest: sdk.ConnectorsAccountRequest{ID: "sales"}},
+			AdAccountID: account,
+		}
+	}
+	items := []sdk.RequestByDateAndAdAccount{
+		item("2026-08-01", "A1"), item("2026-08-01", "A2"),
+		item("2026-08-02", "A1"), item("2026-08-02", "A2"),
+		item("2026-08-05", "A1"), item("2026-08-06", "A1"),
+	}
+
+	grouped := map[int]bool{}
+	if got := strings.Join(windowDays(items, 0, grouped), ","); got != "2026-08-01,2026-08-02" {
+		t.Errorf("first group: got %s", got)
+	}
+	if grouped[4] || grouped[5] {
+		t.Errorf("days after the gap must start a new group: %v", grouped)
+	}
+	if got := strings.Join(windowDays(items, 4, grouped), ","); got != "2026-08-05,2026-08-06" {
+		t.Errorf("group after the gap: got %s", got)
+	}
+}
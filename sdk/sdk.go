@@ -54,6 +54,10 @@ func Process(processFunc func(ConfigFile, map[string]string, map[string]interfac
 		return fmt.Errorf("erreur lors du chargement de %s : %v", *statePath, err)
 	}
 
+	if config.Locale != "" {
+		SetLocale(config.Locale)
+	}
+
 	// Charger les credentials depuis le fichier spécifié, optionnel, peut être absent
 	credentials, _ := loadCredentialsFromFile(*credentialsPath)
 
@@ -69,6 +73,87 @@ func Process(processFunc func(ConfigFile, map[string]string, map[string]interfac
 	return nil
 }
 
+// #region RowHooks
+// RowHooks : traitements appliqués à chaque ligne avant Upsert. Chaque champ est
+// optionnel ; le connecteur les construit pour son run (NewDriftTracker,
+// NewCoercers, NewPIIProtector) et passe la valeur à UpsertWith, puis appelle
+// Report en fin de run.
+type RowHooks struct {
+	Drift  *DriftTracker
+	Coerce Coercers
+	PII    *PIIProtector
+}
+
+// #endregion
+
+// #region RowHooks.Apply
+// Apply renvoie la ligne à émettre. La dérive est observée sur la ligne telle que
+// reçue de l'API ; la conversion puis la protection PII travaillent sur une copie,
+// data n'est jamais modifiée.
+func (h *RowHooks) Apply(data map[string]interface{}) map[string]interface{} {
+	if h == nil {
+		return data
+	}
+	h.Drift.Observe(data)
+	if h.Coerce == nil && h.PII == nil {
+		return data
+	}
+	row := copyValue(data).(map[string]interface{})
+	h.Coerce.Apply(row)
+	h.PII.Apply(row)
+	return row
+}
+
+// #endregion
+
+// #region RowHooks.Report
+// Report émet les rapports de fin de run : dérive de schéma, échecs de conversion,
+// audit PII.
+func (h *RowHooks) Report() {
+	if h == nil {
+		return
+	}
+	ReportSchemaDrift(h.Drift)
+	if h.Coerce != nil {
+		ReportCoercion(h.Coerce)
+	}
+	ReportPIIProtection(h.PII)
+}
+
+// #endregion
+
+// #region copyValue
+// copyValue copie en profondeur les maps et tableaux JSON : setPath écrit dans les
+// objets imbriqués, qui appartiennent encore à l'appelant.
+func copyValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			out[k] = copyValue(val)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, val := range t {
+			out[i] = copyValue(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// #endregion
+
+// #region UpsertWith
+// UpsertWith émet la ligne après les traitements de hooks (cf RowHooks.Apply).
+func UpsertWith(hooks *RowHooks, data map[string]interface{}, state map[string]string) error {
+	return Upsert(hooks.Apply(data), state)
+}
+
+// #endregion
+
 // #region Upsert
 func Upsert(data map[string]interface{}, state map[string]string) error {
 
@@ -0,0 +1,51 @@
+package sdk
+
+import (
+	"reflect"
+	"testing"
+)
+
+// La dérive voit la ligne brute ; conversion et protection PII travaillent sur une
+// copie, la ligne de l'appelant reste intacte.
+func TestRowHooksApply(t *testing.T) {
+	requests := []Request{driftRequest()}
+	pii, err := NewPIIProtector(PIIPolicy{Mode: "drop"}, requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks := &RowHooks{Drift: NewDriftTracker(requests), Coerce: NewCoercers(requests), PII: pii}
+
+	data := map[string]interface{}{
+		"requestId": "sales",
+		"data": map[string]interface{}{
+			"id": "1", "amount": 12.5, "quantity": "3", "email": "a@b.c", "coupon": "X", "phone": "0601",
+		},
+	}
+	row := hooks.Apply(data)
+
+	want := map[string]interface{}{
+		"id": "1", "amount": 12.5, "quantity": int64(3), "email": nil, "coupon": "X", "phone": nil,
+	}
+	if got := row["data"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("row: got %#v, want %#v", got, want)
+	}
+	original := map[string]interface{}{
+		"id": "1", "amount": 12.5, "quantity": "3", "email": "a@b.c", "coupon": "X", "phone": "0601",
+	}
+	if got := data["data"]; !reflect.DeepEqual(got, original) {
+		t.Errorf("the caller's row was modified: %#v", got)
+	}
+	// Observée après le drop, email et phone seraient des champs jamais vus.
+	if drifts := hooks.Drift.Report(); drifts != nil {
+		t.Errorf("drift must observe the row before the hooks, got %#v", drifts)
+	}
+}
+
+func TestRowHooksNil(t *testing.T) {
+	var hooks *RowHooks
+	data := map[string]interface{}{"requestId": "sales"}
+	if row := hooks.Apply(data); !reflect.DeepEqual(row, data) {
+		t.Errorf("got %#v", row)
+	}
+	hooks.Report()
+}
@@ -0,0 +1,411 @@
+package sdk
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SemanticModel est la couche sémantique d'un connecteur, dérivée des prebuilds
+// enrichis : une entité par requête, avec ses dimensions, mesures et dimension de
+// temps. Elle s'exporte en YAML (WriteSemanticYAML) ou en catalogue Markdown
+// (WriteDataCatalog).
+type SemanticModel struct {
+	Connector *ConnectorInfo
+	Entities  []SemanticEntity
+}
+
+type SemanticEntity struct {
+	Name            string // TableName, à défaut ID de la requête
+	RequestID       string
+	Label           string
+	Description     string
+	Purpose         string
+	Domain          string
+	Grain           string
+	TimeDimension   *SemanticTimeDimension
+	Dimensions      []SemanticDimension
+	Measures        []SemanticMeasure
+	SampleQuestions []string
+}
+
+type SemanticTimeDimension struct {
+	Name        string
+	Label       string
+	Type        string
+	Granularity string // day, second
+	Description string
+}
+
+type SemanticDimension struct {
+	Name         string
+	Label        string
+	Type         string
+	SemanticType string
+	Description  string
+	PrimaryKey   bool
+	PII          bool
+}
+
+type SemanticMeasure struct {
+	Name        string
+	Label       string
+	Type        string
+	Aggregation string // sum, avg, count_distinct
+	Format      string // FormatHint
+	Description string
+}
+
+// #region BuildSemanticModel
+// BuildSemanticModel dérive la couche sémantique des prebuilds :
+//   - IsMetric → mesure, agrégée en sum ; avg pour un pourcentage (un taux ne
+//     s'additionne pas) ; count_distinct pour une mesure non numérique ;
+//   - IsQuantiDate → dimension de temps (granularité day pour un DATE, second sinon) ;
+//   - tout le reste → dimension, QuantiId en clé primaire.
+//
+// Le nom d'une colonne suit la table générée (DatabaseMetaData.Name, à défaut
+// FieldId) ; le libellé est BusinessName, à défaut le nom.
+func BuildSemanticModel(file *EnrichedPrebuildsFile) (*SemanticModel, error) {
+	if file == nil || len(file.Prebuilds) == 0 {
+		return nil, fmt.Errorf("semantic: no prebuild")
+	}
+
+	model := &SemanticModel{Connector: file.Connector}
+	for _, req := range file.Prebuilds {
+		car := req.ConnectorsAccountRequest
+		entity := SemanticEntity{
+			Name:            car.Schema.TableName,
+			RequestID:       car.ID,
+			Label:           car.Name,
+			Description:     car.Description,
+			Purpose:         car.Purpose,
+			Domain:          car.BusinessDomain,
+			Grain:           car.Grain,
+			SampleQuestions: car.SampleQuestions,
+		}
+		if entity.Name == "" {
+			entity.Name = car.ID
+		}
+		if entity.Name == "" {
+			return nil, fmt.Errorf("semantic: a prebuild has neither tableName nor id")
+		}
+
+		for _, f := range car.Schema.OrderedFields {
+			meta := f.DatabaseMetaData
+			name := meta.Name
+			if name == "" {
+				name = f.FieldId
+			}
+			if name == "" {
+				continue
+			}
+			label := meta.BusinessName
+			if label == "" {
+				label = name
+			}
+			description := meta.Description
+			if description == "" {
+				description = meta.Purpose
+			}
+			typ := strings.ToUpper(meta.Type)
+
+			switch {
+			case meta.IsQuantiDate && entity.TimeDimension == nil:
+				granularity := "second"
+				if typ == "DATE" || typ == "" {
+					granularity = "day"
+				}
+				entity.TimeDimension = &SemanticTimeDimension{Name: name, Label: label, Type: typ, Granularity: granularity, Description: description}
+			case meta.IsMetric:
+				entity.Measures = append(entity.Measures, SemanticMeasure{
+					Name: name, Label: label, Type: typ, Aggregation: measureAggregation(meta), Format: meta.FormatHint, Description: description,
+				})
+			default:
+				entity.Dimensions = append(entity.Dimensions, SemanticDimension{
+					Name: name, Label: label, Type: typ, SemanticType: meta.SemanticType, Description: description,
+					PrimaryKey: meta.QuantiId, PII: meta.IsPII,
+				})
+			}
+		}
+		model.Entities = append(model.Entities, entity)
+	}
+	return model, nil
+}
+
+// #endregion
+
+// #region measureAggregation
+func measureAggregation(meta DatabaseMetaData) string {
+	switch strings.ToUpper(meta.Type) {
+	case "INTEGER", "INT64", "FLOAT", "FLOAT64", "NUMERIC", "":
+	default:
+		return "count_distinct"
+	}
+	if meta.FormatHint == "percentage" {
+		return "avg"
+	}
+	return "sum"
+}
+
+// #endregion
+
+// #region WriteSemanticYAML
+// WriteSemanticYAML écrit le modèle au format YAML suivant (clés vides omises) :
+//
+//	version: 1
+//	connector:
+//	  sku: "google_ads"
+//	  name: "Google Ads"
+//	  category: "marketing"
+//	  description: "..."
+//	entities:
+//	  - name: "ads_daily"            # table
+//	    request_id: "ads"
+//	    label: "Performances"
+//	    description: "..."
+//	    purpose: "..."
+//	    domain: "performance"        # BusinessDomain
+//	    grain: "daily"
+//	    time_dimension:
+//	      name: "date"
+//	      label: "Jour"
+//	      type: "DATE"
+//	      granularity: "day"         # day, second
+//	    dimensions:
+//	      - name: "campaign_id"
+//	        label: "Campagne"
+//	        type: "STRING"
+//	        semantic_type: "id"
+//	        primary_key: true
+//	        pii: true
+//	    measures:
+//	      - name: "cost"
+//	        label: "Dépenses"
+//	        type: "FLOAT"
+//	        aggregation: "sum"       # sum, avg, count_distinct
+//	        format: "divide_1000000"
+//	    sample_questions:
+//	      - "Quelle campagne a le meilleur CPA ?"
+//
+// Toutes les chaînes sont entre guillemets doubles : pas de surprise YAML 1.1
+// ("no", "on", "2026-08-12" lus comme booléens ou dates).
+func WriteSemanticYAML(w io.Writer, model *SemanticModel) error {
+	y := &lineWriter{w: w}
+	y.line(0, "version: 1")
+	if c := model.Connector; c != nil {
+		y.line(0, "connector:")
+		y.field(1, "sku", c.SKU)
+		y.field(1, "name", c.Name)
+		y.field(1, "category", c.Category)
+		y.field(1, "description", c.Description)
+		y.field(1, "purpose", c.Purpose)
+	}
+
+	y.line(0, "entities:")
+	for _, e := range model.Entities {
+		y.line(1, "- name: "+yamlQuote(e.Name))
+		y.field(2, "request_id", e.RequestID)
+		y.field(2, "label", e.Label)
+		y.field(2, "description", e.Description)
+		y.field(2, "purpose", e.Purpose)
+		y.field(2, "domain", e.Domain)
+		y.field(2, "grain", e.Grain)
+		if t := e.TimeDimension; t != nil {
+			y.line(2, "time_dimension:")
+			y.field(3, "name", t.Name)
+			y.field(3, "label", t.Label)
+			y.field(3, "type", t.Type)
+			y.field(3, "granularity", t.Granularity)
+			y.field(3, "description", t.Description)
+		}
+		if len(e.Dimensions) > 0 {
+			y.line(2, "dimensions:")
+			for _, d := range e.Dimensions {
+				y.line(3, "- name: "+yamlQuote(d.Name))
+				y.field(4, "label", d.Label)
+				y.field(4, "type", d.Type)
+				y.field(4, "semantic_type", d.SemanticType)
+				y.field(4, "description", d.Description)
+				y.flag(4, "primary_key", d.PrimaryKey)
+				y.flag(4, "pii", d.PII)
+			}
+		}
+		if len(e.Measures) > 0 {
+			y.line(2, "measures:")
+			for _, m := range e.Measures {
+				y.line(3, "- name: "+yamlQuote(m.Name))
+				y.field(4, "label", m.Label)
+				y.field(4, "type", m.Type)
+				y.field(4, "aggregation", m.Aggregation)
+				y.field(4, "format", m.Format)
+				y.field(4, "description", m.Description)
+			}
+		}
+		if len(e.SampleQuestions) > 0 {
+			y.line(2, "sample_questions:")
+			for _, q := range e.SampleQuestions {
+				y.line(3, "- "+yamlQuote(q))
+			}
+		}
+	}
+	return y.err
+}
+
+// #endregion
+
+// #region lineWriter
+// lineWriter : écriture ligne à ligne (YAML indenté ou Markdown), la première erreur
+// est conservée et les suivantes ignorées.
+type lineWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (y *lineWriter) line(indent int, s string) {
+	if y.err != nil {
+		return
+	}
+	_, y.err = io.WriteString(y.w, strings.Repeat("  ", indent)+s+"\n")
+}
+
+func (y *lineWriter) field(indent int, key, value string) {
+	if value != "" {
+		y.line(indent, key+": "+yamlQuote(value))
+	}
+}
+
+func (y *lineWriter) flag(indent int, key string, value bool) {
+	if value {
+		y.line(indent, key+": true")
+	}
+}
+
+// #endregion
+
+// #region yamlQuote
+// yamlQuote : les échappements de strconv.Quote (\n, \t, \xNN, \uNNNN…) sont tous
+// des échappements valides d'une chaîne YAML double-quotée.
+func yamlQuote(s string) string {
+	return strconv.Quote(s)
+}
+
+// #endregion
+
+// #region WriteDataCatalog
+// WriteDataCatalog écrit le catalogue de données du connecteur en Markdown : une
+// section par table (description, domaine, grain, questions types) et le tableau
+// de ses colonnes avec leur rôle (time, dimension, measure).
+func WriteDataCatalog(w io.Writer, model *SemanticModel) error {
+	y := &lineWriter{w: w}
+
+	title := "Data catalogue"
+	if c := model.Connector; c != nil && c.Name != "" {
+		title = c.Name + " — data catalogue"
+	}
+	y.line(0, "# "+title)
+	if c := model.Connector; c != nil {
+		y.line(0, "")
+		if c.Description != "" {
+			y.line(0, mdText(c.Description))
+			y.line(0, "")
+		}
+		if c.Purpose != "" {
+			y.line(0, mdText(c.Purpose))
+			y.line(0, "")
+		}
+		if c.SKU != "" || c.Category != "" {
+			y.line(0, fmt.Sprintf("- **SKU**: `%s`", c.SKU))
+			y.line(0, "- **Category**: "+mdText(c.Category))
+		}
+	}
+
+	entities := append([]SemanticEntity(nil), model.Entities...)
+	sort.SliceStable(entities, func(i, j int) bool { return entities[i].Name < entities[j].Name })
+	for _, e := range entities {
+		y.line(0, "")
+		heading := "## `" + e.Name + "`"
+		if e.Label != "" && e.Label != e.Name {
+			heading += " — " + mdText(e.Label)
+		}
+		y.line(0, heading)
+		y.line(0, "")
+		for _, text := range []string{e.Description, e.Purpose} {
+			if text != "" {
+				y.line(0, mdText(text))
+				y.line(0, "")
+			}
+		}
+		if e.RequestID != "" {
+			y.line(0, "- **Request**: `"+e.RequestID+"`")
+		}
+		if e.Domain != "" {
+			y.line(0, "- **Domain**: "+mdText(e.Domain))
+		}
+		if e.Grain != "" {
+			y.line(0, "- **Grain**: "+mdText(e.Grain))
+		}
+		if t := e.TimeDimension; t != nil {
+			y.line(0, fmt.Sprintf("- **Time dimension**: `%s` (%s)", t.Name, t.Granularity))
+		}
+
+		y.line(0, "")
+		y.line(0, "| Column | Label | Type | Role | Description |")
+		y.line(0, "|---|---|---|---|---|")
+		if t := e.TimeDimension; t != nil {
+			y.line(0, mdRow(t.Name, t.Label, t.Type, "time", t.Description))
+		}
+		for _, d := range e.Dimensions {
+			role := "dimension"
+			if d.PrimaryKey {
+				role += ", primary key"
+			}
+			if d.PII {
+				role += ", PII"
+			}
+			y.line(0, mdRow(d.Name, d.Label, d.Type, role, d.Description))
+		}
+		for _, m := range e.Measures {
+			role := "measure (" + m.Aggregation + ")"
+			if m.Format != "" {
+				role += ", " + m.Format
+			}
+			y.line(0, mdRow(m.Name, m.Label, m.Type, role, m.Description))
+		}
+
+		if len(e.SampleQuestions) > 0 {
+			y.line(0, "")
+			y.line(0, "Sample questions:")
+			y.line(0, "")
+			for _, q := range e.SampleQuestions {
+				y.line(0, "- "+mdText(q))
+			}
+		}
+	}
+	return y.err
+}
+
+// #endregion
+
+// #region mdRow
+func mdRow(name, label, typ, role, description string) string {
+	return "| `" + name + "` | " + mdCell(label) + " | " + typ + " | " + role + " | " + mdCell(description) + " |"
+}
+
+// #endregion
+
+// #region mdText
+// mdText met un texte libre sur une ligne (un saut de ligne casserait une liste).
+func mdText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// #endregion
+
+// #region mdCell
+func mdCell(s string) string {
+	return strings.ReplaceAll(mdText(s), "|", `\|`)
+}
+
+// #endregion
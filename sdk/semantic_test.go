@@ -0,0 +1,94 @@
+package sdk
+
+import (
+	"strings"
+	"testing"
+)
+
+func semanticFile() *EnrichedPrebuildsFile {
+	return &EnrichedPrebuildsFile{
+		Connector: &ConnectorInfo{SKU: "google_ads", Name: "Google Ads", Category: "marketing"},
+		Prebuilds: []Request{{ConnectorsAccountRequest: ConnectorsAccountRequest{
+			ID:              "ads",
+			Name:            "Performances",
+			BusinessDomain:  "performance",
+			Grain:           "daily",
+			SampleQuestions: []string{"Quelle campagne a le meilleur \"CPA\" ?"},
+			Schema: Schema{TableName: "ads_daily", OrderedFields: []OrderedField{
+				{FieldId: "date", DatabaseMetaData: DatabaseMetaData{Type: "DATE", IsQuantiDate: true, BusinessName: "Jour"}},
+				{FieldId: "campaign_id", DatabaseMetaData: DatabaseMetaData{Type: "STRING", QuantiId: true, SemanticType: "id"}},
+				{FieldId: "email", DatabaseMetaData: DatabaseMetaData{Type: "STRING", IsPII: true, Description: "Contact | owner"}},
+				{FieldId: "cost", DatabaseMetaData: DatabaseMetaData{Type: "INTEGER", IsMetric: true, BusinessName: "Dépenses", FormatHint: "divide_1000000"}},
+				{FieldId: "ctr", DatabaseMetaData: DatabaseMetaData{Type: "FLOAT", IsMetric: true, FormatHint: "percentage"}},
+				{FieldId: "users", DatabaseMetaData: DatabaseMetaData{Type: "STRING", IsMetric: true}},
+			}},
+		}}},
+	}
+}
+
+func TestBuildSemanticModel(t *testing.T) {
+	model, err := BuildSemanticModel(semanticFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := model.Entities[0]
+	if e.Name != "ads_daily" || e.TimeDimension == nil || e.TimeDimension.Name != "date" || e.TimeDimension.Granularity != "day" {
+		t.Fatalf("entity: %#v", e)
+	}
+	if len(e.Dimensions) != 2 || !e.Dimensions[0].PrimaryKey || !e.Dimensions[1].PII {
+		t.Errorf("dimensions: %#v", e.Dimensions)
+	}
+	aggregations := map[string]string{}
+	for _, m := range e.Measures {
+		aggregations[m.Name] = m.Aggregation
+	}
+	if aggregations["cost"] != "sum" || aggregations["ctr"] != "avg" || aggregations["users"] != "count_distinct" {
+		t.Errorf("aggregations: %v", aggregations)
+	}
+
+	if _, err := BuildSemanticModel(&EnrichedPrebuildsFile{}); err == nil {
+		t.Error("an empty file must be an error")
+	}
+}
+
+func TestWriteSemanticYAML(t *testing.T) {
+	model, _ := BuildSemanticModel(semanticFile())
+	var sb strings.Builder
+	if err := WriteSemanticYAML(&sb, model); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"version: 1\nconnector:\n  sku: \"google_ads\"\n",
+		"  - name: \"ads_daily\"\n    request_id: \"ads\"\n",
+		"    time_dimension:\n      name: \"date\"\n      label: \"Jour\"\n      type: \"DATE\"\n      granularity: \"day\"\n",
+		"      - name: \"campaign_id\"\n        label: \"campaign_id\"\n        type: \"STRING\"\n        semantic_type: \"id\"\n        primary_key: true\n",
+		"      - name: \"cost\"\n        label: \"Dépenses\"\n        type: \"INTEGER\"\n        aggregation: \"sum\"\n        format: \"divide_1000000\"\n",
+		"    sample_questions:\n      - \"Quelle campagne a le meilleur \\\"CPA\\\" ?\"\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteDataCatalog(t *testing.T) {
+	model, _ := BuildSemanticModel(semanticFile())
+	var sb strings.Builder
+	if err := WriteDataCatalog(&sb, model); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"# Google Ads — data catalogue\n",
+		"## `ads_daily` — Performances\n",
+		"- **Time dimension**: `date` (day)\n",
+		"| `date` | Jour | DATE | time |  |\n",
+		"| `email` | email | STRING | dimension, PII | Contact \\| owner |\n",
+		"| `ctr` | ctr | FLOAT | measure (avg), percentage |  |\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
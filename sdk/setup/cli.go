@@ -117,6 +117,32 @@ func runStandardCommand(handler SetupHandler, cmd string, ctx *CommandContext) e
 		}
 		return fmt.Errorf("this connector does not support validate")
 
+	case "lint-prebuilds":
+		// Needs no connector code: runs on the prebuilds.json of any connector repo
+		path := ctx.FlagOrDefault("file", "prebuilds.json")
+		if len(ctx.Args) > 0 {
+			path = ctx.Args[0]
+		}
+		return outputLintPrebuildsResult(lintPrebuilds(path))
+
+	case "generate-schema":
+		format := ctx.Flag("format")
+		if format == "" {
+			return fmt.Errorf("generate-schema requires --format=<bigquery|postgres|snowflake|jsonschema>")
+		}
+		resp, err := generateSchemas(ctx.FlagOrDefault("file", "prebuilds.json"), format, ctx.Flag("request"))
+		if err != nil {
+			return err
+		}
+		return OutputJSON(resp)
+
+	case "export-semantic":
+		resp, err := exportSemantic(ctx.FlagOrDefault("file", "prebuilds.json"), ctx.FlagOrDefault("format", "yaml"))
+		if err != nil {
+			return err
+		}
+		return OutputJSON(resp)
+
 	default:
 		return fmt.Errorf("unknown command: %s", cmd)
 	}
@@ -158,6 +184,9 @@ func buildUsage(handler SetupHandler) string {
 	sb.WriteString("  test-query        Test a custom query (--config required)\n")
 	sb.WriteString("  infer-schema      Infer schema from fields (--config required)\n")
 	sb.WriteString("  validate          Validate credentials\n")
+	sb.WriteString("  lint-prebuilds    Check prebuilds.json (--file=<path>, default prebuilds.json)\n")
+	sb.WriteString("  generate-schema   Table definitions from prebuilds.json (--format=<bigquery|postgres|snowflake|jsonschema>, --request=<id>)\n")
+	sb.WriteString("  export-semantic   Semantic model or data catalogue from prebuilds.json (--format=<yaml|markdown>)\n")
 
 	customCmds := handler.Commands()
 	if len(customCmds) > 0 {
@@ -268,3 +297,15 @@ func outputInferSchemaResult(resp *InferSchemaResponse, err error) error {
 	}
 	return nil
 }
+
+// #region outputLintPrebuildsResult
+// outputLintPrebuildsResult outputs a LintPrebuildsResponse and exits with code 1
+// when the file is invalid, so the command can gate a CI job
+func outputLintPrebuildsResult(resp *LintPrebuildsResponse) error {
+	output, _ := json.Marshal(resp)
+	fmt.Println(string(output))
+	if !resp.Valid {
+		os.Exit(1)
+	}
+	return nil
+}
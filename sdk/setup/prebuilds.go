@@ -0,0 +1,105 @@
+package setup
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/quantiio/quanti-sdk/sdk"
+)
+
+// #region lintPrebuilds
+// lintPrebuilds loads a prebuilds.json (bare array or enriched file) and lints it.
+// Warnings alone keep the file valid; Findings is never null in the JSON output.
+func lintPrebuilds(path string) *LintPrebuildsResponse {
+	resp := &LintPrebuildsResponse{File: path, Findings: []sdk.PrebuildFinding{}}
+
+	file, err := sdk.LoadPrebuilds(path)
+	if err != nil {
+		resp.Error = &SetupError{Code: "PREBUILDS_ERROR", Message: err.Error()}
+		return resp
+	}
+
+	resp.Prebuilds = len(file.Prebuilds)
+	if findings := sdk.Lint(file); len(findings) > 0 {
+		resp.Findings = findings
+	}
+	resp.Valid = !sdk.HasLintErrors(resp.Findings)
+	return resp
+}
+
+// #endregion
+
+// #region generateSchemas
+// generateSchemas generates the table definition of every prebuild (or only
+// requestID) in the given format, for dry runs and local test warehouses
+func generateSchemas(path, format, requestID string) (*GenerateSchemaResponse, error) {
+	file, err := sdk.LoadPrebuilds(path)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &GenerateSchemaResponse{Format: format, Tables: []GeneratedTable{}}
+	for _, req := range file.Prebuilds {
+		car := req.ConnectorsAccountRequest
+		if requestID != "" && car.ID != requestID {
+			continue
+		}
+
+		var content string
+		switch format {
+		case "bigquery":
+			var b []byte
+			b, err = sdk.GenerateBigQuerySchema(car.Schema)
+			content = string(b)
+		case "jsonschema":
+			var b []byte
+			b, err = sdk.GenerateJSONSchema(car.Schema)
+			content = string(b)
+		case sdk.DialectPostgres, sdk.DialectSnowflake:
+			content, err = sdk.GenerateDDL(car.Schema, format)
+		default:
+			return nil, fmt.Errorf("unknown format %q (bigquery, postgres, snowflake, jsonschema)", format)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("request %s: %w", car.ID, err)
+		}
+		resp.Tables = append(resp.Tables, GeneratedTable{RequestID: car.ID, TableName: car.Schema.TableName, Content: content})
+	}
+
+	if requestID != "" && len(resp.Tables) == 0 {
+		return nil, fmt.Errorf("request %q not found in %s", requestID, path)
+	}
+	return resp, nil
+}
+
+// #endregion
+
+// #region exportSemantic
+// exportSemantic turns an enriched prebuilds.json into the semantic-layer YAML or
+// the Markdown data catalogue of the connector
+func exportSemantic(path, format string) (*ExportSemanticResponse, error) {
+	file, err := sdk.LoadPrebuilds(path)
+	if err != nil {
+		return nil, err
+	}
+	model, err := sdk.BuildSemanticModel(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	switch format {
+	case "yaml":
+		err = sdk.WriteSemanticYAML(&sb, model)
+	case "markdown":
+		err = sdk.WriteDataCatalog(&sb, model)
+	default:
+		return nil, fmt.Errorf("unknown format %q (yaml, markdown)", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ExportSemanticResponse{Format: format, Content: sb.String()}, nil
+}
+
+// #endregion
@@ -0,0 +1,112 @@
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// #region TestLintPrebuilds
+// Runs the lint-prebuilds command body on real files: a valid legacy array, an
+// enriched file with a missing isQuantiDate, and an unreadable path.
+func TestLintPrebuilds(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	valid := write("valid.json", `[{"connectorsaccountrequest": {"id": "ads", "schema": {"orderedFields": [
+		{"fieldId": "date", "databaseMetaData": {"isQuantiDate": true}}
+	]}}}]`)
+	resp := lintPrebuilds(valid)
+	if !resp.Valid || resp.Prebuilds != 1 || len(resp.Findings) != 0 || resp.Error != nil {
+		t.Errorf("valid file: %+v", resp)
+	}
+
+	invalid := write("invalid.json", `{"connector": {"sku": "ads"}, "prebuilds": [{"connectorsaccountrequest": {"id": "ads", "schema": {"orderedFields": [
+		{"fieldId": "clicks"}
+	]}}}]}`)
+	resp = lintPrebuilds(invalid)
+	if resp.Valid || len(resp.Findings) != 1 || resp.Findings[0].Rule != "missing_quanti_date" {
+		t.Errorf("invalid file: %+v", resp)
+	}
+
+	resp = lintPrebuilds(filepath.Join(dir, "missing.json"))
+	if resp.Valid || resp.Error == nil || resp.Error.Code != "PREBUILDS_ERROR" {
+		t.Errorf("missing file: %+v", resp)
+	}
+}
+
+// #region TestGenerateSchemas
+func TestGenerateSchemas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prebuilds.json")
+	content := `[
+		{"connectorsaccountrequest": {"id": "ads", "schema": {"tableName": "ads", "orderedFields": [
+			{"fieldId": "date", "databaseMetaData": {"type": "DATE", "isQuantiDate": true}}
+		]}}},
+		{"connectorsaccountrequest": {"id": "broken", "schema": {"tableName": "broken", "orderedFields": [
+			{"fieldId": "geo", "databaseMetaData": {"type": "GEOGRAPHY"}}
+		]}}}
+	]`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := generateSchemas(path, "snowflake", "ads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "CREATE TABLE ads (\n  date DATE NOT NULL\n)\nCLUSTER BY (date);\n"
+	if len(resp.Tables) != 1 || resp.Tables[0].Content != want {
+		t.Errorf("tables: %+v", resp.Tables)
+	}
+
+	if _, err := generateSchemas(path, "postgres", ""); err == nil {
+		t.Error("an unsupported column type must fail the whole command")
+	}
+	if _, err := generateSchemas(path, "postgres", "missing"); err == nil {
+		t.Error("an unknown request must be an error")
+	}
+	if _, err := generateSchemas(path, "mysql", "ads"); err == nil {
+		t.Error("an unknown format must be an error")
+	}
+}
+
+// #region TestExportSemantic
+func TestExportSemantic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prebuilds.json")
+	content := `{"connector": {"sku": "ads", "name": "Ads"}, "prebuilds": [
+		{"connectorsaccountrequest": {"id": "ads", "schema": {"tableName": "ads", "orderedFields": [
+			{"fieldId": "date", "databaseMetaData": {"type": "DATE", "isQuantiDate": true}},
+			{"fieldId": "clicks", "databaseMetaData": {"type": "INTEGER", "isMetric": true}}
+		]}}}
+	]}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := exportSemantic(path, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp.Content, "aggregation: \"sum\"") {
+		t.Errorf("yaml: %s", resp.Content)
+	}
+
+	resp, err = exportSemantic(path, "markdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(resp.Content, "# Ads — data catalogue\n") {
+		t.Errorf("markdown: %s", resp.Content)
+	}
+
+	if _, err := exportSemantic(path, "dbt"); err == nil {
+		t.Error("an unknown format must be an error")
+	}
+}
@@ -1,5 +1,7 @@
 package setup
 
+import "github.com/quantiio/quanti-sdk/sdk"
+
 // #region SetupResponse
 // SetupResponse is the standard JSON output for setup commands
 type SetupResponse struct {
@@ -101,3 +103,35 @@ type ValidateResponse struct {
 	Valid bool        `json:"valid"`
 	Error *SetupError `json:"error,omitempty"`
 }
+
+// #region LintPrebuildsResponse
+// LintPrebuildsResponse is the JSON output from lint-prebuilds command
+type LintPrebuildsResponse struct {
+	Valid     bool                  `json:"valid"`
+	File      string                `json:"file"`
+	Prebuilds int                   `json:"prebuilds"`
+	Findings  []sdk.PrebuildFinding `json:"findings"`
+	Error     *SetupError           `json:"error,omitempty"`
+}
+
+// #region GenerateSchemaResponse
+// GenerateSchemaResponse is the JSON output from generate-schema command
+type GenerateSchemaResponse struct {
+	Format string           `json:"format"`
+	Tables []GeneratedTable `json:"tables"`
+}
+
+// #region GeneratedTable
+// GeneratedTable is one generated definition (DDL text or JSON document)
+type GeneratedTable struct {
+	RequestID string `json:"request_id"`
+	TableName string `json:"table_name"`
+	Content   string `json:"content"`
+}
+
+// #region ExportSemanticResponse
+// ExportSemanticResponse is the JSON output from export-semantic command
+type ExportSemanticResponse struct {
+	Format  string `json:"format"`
+	Content string `json:"content"`
+}